@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// apiError is the error envelope the server responds with.
+type apiError struct {
+	Status    int
+	Message   string `json:"error"`
+	RequestId string `json:"requestId"`
+}
+
+func (err *apiError) Error() string {
+	message := fmt.Sprintf("%d %s", err.Status, http.StatusText(err.Status))
+	if err.Message != "" {
+		message += ": " + err.Message
+	}
+	if err.RequestId != "" {
+		message += " (request " + err.RequestId + ")"
+	}
+	return message
+}
+
+type apiClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func newAPIClient(baseURL, token string, client *http.Client) *apiClient {
+	return &apiClient{baseURL: strings.TrimSuffix(baseURL, "/"), token: token, http: client}
+}
+
+// do sends body as JSON, unless it is nil, and decodes the response into
+// destination, unless it is nil. Responses other than 2xx become an *apiError.
+func (api *apiClient) do(ctx context.Context, method, path string, body, destination any) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, api.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if api.token != "" {
+		request.Header.Set("Authorization", "Bearer "+api.token)
+	}
+
+	response, err := api.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		apiErr := &apiError{Status: response.StatusCode}
+		json.NewDecoder(response.Body).Decode(apiErr)
+		return apiErr
+	}
+	if destination == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(destination)
+}
+
+// apiPath joins the escaped segments to a path, so rooms may be given by
+// id, join code or slug and clients by any name.
+func apiPath(segments ...string) string {
+	var path strings.Builder
+	for _, segment := range segments {
+		path.WriteString("/" + url.PathEscape(segment))
+	}
+	return path.String()
+}
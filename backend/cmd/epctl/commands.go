@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+// maxImportBatch is the number of issues the server accepts per request.
+const maxImportBatch = 100
+
+func (cli *cli) listRooms(ctx context.Context, _ []string) error {
+	var got struct {
+		Rooms    []internal.RoomStatus `json:"rooms"`
+		ReadOnly bool                  `json:"readOnly"`
+	}
+	if err := cli.api.do(ctx, http.MethodGet, "/v1/admin/rooms", nil, &got); err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(cli.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tTITLE\tPHASE\tVISIBILITY\tLOCKED\tCLIENTS\tIDLE")
+	for _, room := range got.Rooms {
+		names := make([]string, 0, len(room.Clients))
+		for _, client := range room.Clients {
+			names = append(names, client.Name)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%t\t%d %s\t%s\n",
+			room.Id, dash(room.Title), room.Phase, room.Visibility, room.IsLocked,
+			len(room.Clients), strings.Join(names, ","), time.Since(room.LastActivity).Round(time.Second))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	if got.ReadOnly {
+		fmt.Fprintln(cli.stdout, "\nroom creation is disabled (read-only mode)")
+	}
+	return nil
+}
+
+func (cli *cli) printState(ctx context.Context, args []string) error {
+	return cli.printJSON(ctx, apiPath("v1", "room", args[0], "state"))
+}
+
+func (cli *cli) exportRoom(ctx context.Context, args []string) error {
+	return cli.printJSON(ctx, apiPath("v1", "room", args[0], "export"))
+}
+
+func (cli *cli) printJSON(ctx context.Context, path string) error {
+	var got json.RawMessage
+	if err := cli.api.do(ctx, http.MethodGet, path, nil, &got); err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, got, "", "\t"); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	_, err := indented.WriteTo(cli.stdout)
+	return err
+}
+
+// importIssues adds every non blank line of the file as an issue. Lines
+// starting with # are skipped.
+func (cli *cli) importIssues(ctx context.Context, args []string) error {
+	var input io.Reader = cli.stdin
+	if args[1] != "-" {
+		file, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var titles []string
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			titles = append(titles, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(titles) == 0 {
+		return errors.New("no issues to import")
+	}
+
+	imported := 0
+	for batch := range slices.Chunk(titles, maxImportBatch) {
+		var got struct {
+			Imported int `json:"imported"`
+		}
+		if err := cli.api.do(ctx, http.MethodPost, apiPath("v1", "room", args[0], "issues"), map[string]any{"issues": batch}, &got); err != nil {
+			return fmt.Errorf("imported %d of %d issues: %w", imported, len(titles), err)
+		}
+		imported += got.Imported
+	}
+	fmt.Fprintf(cli.stdout, "imported %d issues\n", imported)
+	return nil
+}
+
+func (cli *cli) kickClient(ctx context.Context, args []string) error {
+	if err := cli.api.do(ctx, http.MethodDelete, apiPath("v1", "admin", "rooms", args[0], "clients", args[1]), nil, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.stdout, "disconnected %s\n", args[1])
+	return nil
+}
+
+func (cli *cli) closeRoom(ctx context.Context, args []string) error {
+	if err := cli.api.do(ctx, http.MethodDelete, apiPath("v1", "admin", "rooms", args[0]), nil, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.stdout, "closed %s\n", args[0])
+	return nil
+}
+
+func (cli *cli) broadcast(ctx context.Context, args []string) error {
+	var got struct {
+		Rooms int `json:"rooms"`
+	}
+	if err := cli.api.do(ctx, http.MethodPost, "/v1/admin/broadcast", internal.Notice{Message: strings.Join(args, " ")}, &got); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.stdout, "notified %d rooms\n", got.Rooms)
+	return nil
+}
+
+func (cli *cli) setReadOnly(ctx context.Context, args []string) error {
+	var enabled bool
+	switch args[0] {
+	case "on":
+		enabled = true
+	case "off":
+	default:
+		return fmt.Errorf("read-only must be on or off, got %q", args[0])
+	}
+
+	if err := cli.api.do(ctx, http.MethodPut, "/v1/admin/read-only", map[string]bool{"enabled": enabled}, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.stdout, "read-only mode %s\n", args[0])
+	return nil
+}
+
+// tailAudit prints the audit trail of a room, one entry per line. When
+// following, the trail is polled until the room closes or ctx is done.
+func (cli *cli) tailAudit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	follow := fs.Bool("f", false, "")
+	interval := fs.Duration("interval", 2*time.Second, "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("audit needs exactly one room")
+	}
+	path := apiPath("v1", "room", fs.Arg(0), "audit")
+
+	// the room keeps a limited number of entries, so following goes by the
+	// seq of the last printed entry instead of counting them
+	since := 0
+	for {
+		var got struct {
+			Entries []internal.AuditEntry `json:"entries"`
+		}
+		query := ""
+		if since > 0 {
+			query = "?since=" + strconv.Itoa(since)
+		}
+		err := cli.api.do(ctx, http.MethodGet, path+query, nil, &got)
+		var apiErr *apiError
+		switch {
+		case *follow && since > 0 && errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound:
+			fmt.Fprintln(cli.stdout, "room closed")
+			return nil
+		case *follow && ctx.Err() != nil:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		for _, entry := range got.Entries {
+			if since > 0 && entry.Seq <= since {
+				continue
+			}
+			if err := printAuditEntry(cli.stdout, entry); err != nil {
+				return err
+			}
+			since = max(since, entry.Seq)
+		}
+
+		if !*follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+func printAuditEntry(writer io.Writer, entry internal.AuditEntry) error {
+	actor := entry.Actor
+	if entry.Role != "" {
+		actor += " (" + entry.Role + ")"
+	}
+	line := fmt.Sprintf("%s  %-20s  %s", entry.Time.Format(time.RFC3339), entry.Action, actor)
+	if entry.Payload != nil {
+		payload, err := json.Marshal(entry.Payload)
+		if err != nil {
+			return err
+		}
+		line += "  " + string(payload)
+	}
+	_, err := fmt.Fprintln(writer, line)
+	return err
+}
+
+func dash(text string) string {
+	if text == "" {
+		return "-"
+	}
+	return text
+}
@@ -0,0 +1,110 @@
+// Command epctl manages an estimation poker server from a terminal through its
+// admin and REST api.
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const usageText = `Usage: epctl [flags] <command> [arguments]
+
+Commands:
+  rooms                       list every room with its phase and clients
+  state <room>                print the state of a room
+  export <room>               print the results of a room as JSON
+  import <room> <file>        add one issue per line of file, - reads stdin
+  kick <room> <name>          disconnect a client from a room
+  close <room>                close a room
+  broadcast <message>         show a maintenance notice in every room
+  read-only on|off            stop or allow the creation of rooms
+  audit [-f] <room>           print the audit trail of a room, -f follows it
+
+Rooms are given by id, join code or slug.
+
+Flags:
+`
+
+// cli is what every command works with.
+type cli struct {
+	api    *apiClient
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+type command struct {
+	run func(*cli, context.Context, []string) error
+	// args is the number of arguments, -1 for at least one
+	args int
+}
+
+var commands = map[string]command{
+	"rooms":     {run: (*cli).listRooms, args: 0},
+	"state":     {run: (*cli).printState, args: 1},
+	"export":    {run: (*cli).exportRoom, args: 1},
+	"import":    {run: (*cli).importIssues, args: 2},
+	"kick":      {run: (*cli).kickClient, args: 2},
+	"close":     {run: (*cli).closeRoom, args: 1},
+	"broadcast": {run: (*cli).broadcast, args: -1},
+	"read-only": {run: (*cli).setReadOnly, args: 1},
+	"audit":     {run: (*cli).tailAudit, args: -1},
+}
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.LookupEnv, os.Stdin, os.Stdout, os.Stderr)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "epctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, lookupEnv func(string) (string, bool), stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("epctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usageText)
+		fs.PrintDefaults()
+	}
+	server := fs.String("server", envOr(lookupEnv, "EPCTL_SERVER", "http://localhost:8080"), "base url of the server (EPCTL_SERVER)")
+	token := fs.String("token", envOr(lookupEnv, "EPCTL_TOKEN", ""), "admin token or api key with the admin scope (EPCTL_TOKEN)")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of each request")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	name, args := fs.Arg(0), fs.Args()[min(1, fs.NArg()):]
+	cmd, ok := commands[name]
+	if !ok || (cmd.args >= 0 && len(args) != cmd.args) || (cmd.args < 0 && len(args) == 0) {
+		if name != "" && !ok {
+			fmt.Fprintf(stderr, "unknown command %q\n", name)
+		}
+		fs.Usage()
+		return errUsage
+	}
+
+	api := newAPIClient(*server, *token, &http.Client{Timeout: *timeout})
+	return cmd.run(&cli{api: api, stdin: stdin, stdout: stdout}, ctx, args)
+}
+
+func envOr(lookupEnv func(string) (string, bool), key, fallback string) string {
+	value, _ := lookupEnv(key)
+	return cmp.Or(value, fallback)
+}
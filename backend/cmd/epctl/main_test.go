@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	auth   string
+	body   string
+}
+
+// newStubServer answers every request with the response registered for its
+// method and path, or 404, and records what it received.
+func newStubServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]recordedRequest) {
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		requests = append(requests, recordedRequest{
+			method: request.Method,
+			path:   request.URL.EscapedPath(),
+			auth:   request.Header.Get("Authorization"),
+			body:   string(body),
+		})
+
+		response, ok := responses[request.Method+" "+request.URL.EscapedPath()]
+		switch {
+		case !ok:
+			writer.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(writer, `{"error": "the requested resource could not be found", "requestId": "abc"}`)
+		case response == "":
+			writer.WriteHeader(http.StatusNoContent)
+		default:
+			fmt.Fprint(writer, response)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestRun(t *testing.T) {
+	issues := filepath.Join(t.TempDir(), "issues.txt")
+	os.WriteFile(issues, []byte("# sprint 12\nLogin page\n\n  Search  \n"), 0o600)
+
+	tests := []struct {
+		name         string
+		args         []string
+		stdin        string
+		responses    map[string]string
+		wantRequests []recordedRequest
+		wantOutput   string
+		wantErr      string
+	}{
+		{
+			name: "lists rooms",
+			args: []string{"rooms"},
+			responses: map[string]string{"GET /v1/admin/rooms": `{"readOnly": true, "rooms": [{"id": "6f1c1d46-3b4c-4a4e-9d2c-0d1a2b3c4d5e", "title": "Sprint", "phase": "estimating", "visibility": "private", "isLocked": true,
+				"clients": [{"name": "Alice", "role": "product-owner"}, {"name": "Bob", "role": "developer"}]}]}`},
+			wantRequests: []recordedRequest{{method: http.MethodGet, path: "/v1/admin/rooms", auth: "Bearer secret"}},
+			wantOutput:   "Sprint  estimating  private     true    2 Alice,Bob",
+		},
+		{
+			name:         "prints the state",
+			args:         []string{"state", "blue-fox"},
+			responses:    map[string]string{"GET /v1/room/blue-fox/state": `{"inProgress":false}`},
+			wantRequests: []recordedRequest{{method: http.MethodGet, path: "/v1/room/blue-fox/state", auth: "Bearer secret"}},
+			wantOutput:   "{\n\t\"inProgress\": false\n}\n",
+		},
+		{
+			name:         "imports the lines of a file",
+			args:         []string{"import", "blue-fox", issues},
+			responses:    map[string]string{"POST /v1/room/blue-fox/issues": `{"imported": 2}`},
+			wantRequests: []recordedRequest{{method: http.MethodPost, path: "/v1/room/blue-fox/issues", auth: "Bearer secret", body: `{"issues":["Login page","Search"]}`}},
+			wantOutput:   "imported 2 issues\n",
+		},
+		{
+			name:         "imports from stdin",
+			args:         []string{"import", "blue-fox", "-"},
+			stdin:        "Logout\n",
+			responses:    map[string]string{"POST /v1/room/blue-fox/issues": `{"imported": 1}`},
+			wantRequests: []recordedRequest{{method: http.MethodPost, path: "/v1/room/blue-fox/issues", auth: "Bearer secret", body: `{"issues":["Logout"]}`}},
+			wantOutput:   "imported 1 issues\n",
+		},
+		{
+			name:         "kicks a client",
+			args:         []string{"kick", "blue-fox", "Bob Builder"},
+			responses:    map[string]string{"DELETE /v1/admin/rooms/blue-fox/clients/Bob%20Builder": ""},
+			wantRequests: []recordedRequest{{method: http.MethodDelete, path: "/v1/admin/rooms/blue-fox/clients/Bob%20Builder", auth: "Bearer secret"}},
+			wantOutput:   "disconnected Bob Builder\n",
+		},
+		{
+			name:         "broadcasts a notice",
+			args:         []string{"broadcast", "restart", "at", "18:00"},
+			responses:    map[string]string{"POST /v1/admin/broadcast": `{"rooms": 3}`},
+			wantRequests: []recordedRequest{{method: http.MethodPost, path: "/v1/admin/broadcast", auth: "Bearer secret", body: `{"message":"restart at 18:00"}`}},
+			wantOutput:   "notified 3 rooms\n",
+		},
+		{
+			name:         "toggles read-only mode",
+			args:         []string{"read-only", "on"},
+			responses:    map[string]string{"PUT /v1/admin/read-only": `{"readOnly": true}`},
+			wantRequests: []recordedRequest{{method: http.MethodPut, path: "/v1/admin/read-only", auth: "Bearer secret", body: `{"enabled":true}`}},
+			wantOutput:   "read-only mode on\n",
+		},
+		{
+			name:         "prints the audit trail",
+			args:         []string{"audit", "blue-fox"},
+			responses:    map[string]string{"GET /v1/room/blue-fox/audit": `{"entries": [{"time": "2024-01-01T10:00:00Z", "actor": "Alice", "role": "product-owner", "action": "estimate", "payload": "Login page"}]}`},
+			wantRequests: []recordedRequest{{method: http.MethodGet, path: "/v1/room/blue-fox/audit", auth: "Bearer secret"}},
+			wantOutput:   "2024-01-01T10:00:00Z  estimate              Alice (product-owner)  \"Login page\"\n",
+		},
+		{
+			name:         "reports error envelopes",
+			args:         []string{"close", "unknown"},
+			wantRequests: []recordedRequest{{method: http.MethodDelete, path: "/v1/admin/rooms/unknown", auth: "Bearer secret"}},
+			wantErr:      "404 Not Found: the requested resource could not be found (request abc)",
+		},
+		{
+			name:    "rejects an invalid read-only value",
+			args:    []string{"read-only", "maybe"},
+			wantErr: `read-only must be on or off, got "maybe"`,
+		},
+		{
+			name:    "rejects unknown commands",
+			args:    []string{"restart"},
+			wantErr: errUsage.Error(),
+		},
+		{
+			name:    "rejects missing arguments",
+			args:    []string{"kick", "blue-fox"},
+			wantErr: errUsage.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newStubServer(t, tt.responses)
+			env := map[string]string{"EPCTL_SERVER": server.URL, "EPCTL_TOKEN": "secret"}
+			lookupEnv := func(key string) (string, bool) {
+				value, ok := env[key]
+				return value, ok
+			}
+			var stdout bytes.Buffer
+
+			err := run(context.Background(), tt.args, lookupEnv, strings.NewReader(tt.stdin), &stdout, io.Discard)
+
+			if tt.wantErr != "" {
+				assert.Equal(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.DeepEqual(t, *requests, tt.wantRequests)
+			assert.StringContains(t, stdout.String(), tt.wantOutput)
+		})
+	}
+}
+
+func TestRun_followsTheAuditTrail(t *testing.T) {
+	// the room keeps a single entry, so the trail never grows
+	var cursors []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		cursors = append(cursors, request.URL.Query().Get("since"))
+		switch len(cursors) {
+		case 1:
+			fmt.Fprint(writer, `{"entries": [{"seq": 1000, "time": "2024-01-01T10:00:00Z", "actor": "Alice", "action": "join"}]}`)
+		case 2:
+			fmt.Fprint(writer, `{"entries": [{"seq": 1001, "time": "2024-01-01T10:01:00Z", "actor": "Alice", "action": "leave"}]}`)
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	var stdout bytes.Buffer
+
+	err := run(context.Background(), []string{"-server", server.URL, "audit", "-f", "-interval", "1ms", "blue-fox"}, func(string) (string, bool) { return "", false }, nil, &stdout, io.Discard)
+
+	assert.NilError(t, err)
+	assert.Equal(t, stdout.String(), "2024-01-01T10:00:00Z  join                  Alice\n2024-01-01T10:01:00Z  leave                 Alice\nroom closed\n")
+	assert.DeepEqual(t, cursors, []string{"", "1000", "1001"})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+const maxNoticeLength = 500
+
+// readOnlyInput requires enabled, an empty body must not switch the mode.
+type readOnlyInput struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// handleAdminRooms lists every room, including private and locked ones.
+func (app *application) handleAdminRooms(writer http.ResponseWriter, request *http.Request) {
+	app.mu.RLock()
+	rooms := slices.Collect(maps.Values(app.rooms))
+	app.mu.RUnlock()
+
+	statuses := make([]internal.RoomStatus, 0, len(rooms))
+	for _, room := range rooms {
+		statuses = append(statuses, room.Status())
+	}
+	slices.SortFunc(statuses, func(a, b internal.RoomStatus) int {
+		return cmp.Or(a.Created.Compare(b.Created), strings.Compare(a.Id.String(), b.Id.String()))
+	})
+
+	err := app.writeJSON(writer, http.StatusOK, envelope{"rooms": statuses, "readOnly": app.readOnly.Load()}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleAdminCloseRoom(writer http.ResponseWriter, request *http.Request) {
+	room, ok := app.readRoom(writer, request)
+	if !ok {
+		return
+	}
+
+	app.logger.Info("room closed by operator", "room", room.Id)
+	app.closeRoom(room, internal.ClosedByOperator)
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) handleAdminDisconnect(writer http.ResponseWriter, request *http.Request) {
+	room, ok := app.readRoom(writer, request)
+	if !ok {
+		return
+	}
+
+	name := httprouter.ParamsFromContext(request.Context()).ByName("name")
+	if !room.Disconnect(name, "disconnected by an operator") {
+		app.notFoundResponse(writer, request)
+		return
+	}
+	app.logger.Info("client disconnected by operator", "room", room.Id, "client", name)
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) handleAdminBroadcast(writer http.ResponseWriter, request *http.Request) {
+	var input internal.Notice
+	err := app.readJSON(writer, request, &input)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	input.Message = strings.TrimSpace(input.Message)
+	switch {
+	case input.Message == "":
+		app.badRequestResponse(writer, request, errors.New("message must be provided"))
+		return
+	case utf8.RuneCountInString(input.Message) > maxNoticeLength:
+		app.badRequestResponse(writer, request, fmt.Errorf("message must not be longer than %d characters", maxNoticeLength))
+		return
+	}
+
+	app.mu.RLock()
+	rooms := slices.Collect(maps.Values(app.rooms))
+	app.mu.RUnlock()
+	for _, room := range rooms {
+		room.Notify(input)
+	}
+
+	err = app.writeJSON(writer, http.StatusOK, envelope{"rooms": len(rooms)}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleSetReadOnly(writer http.ResponseWriter, request *http.Request) {
+	var input readOnlyInput
+	err := app.readJSON(writer, request, &input)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+	if input.Enabled == nil {
+		app.badRequestResponse(writer, request, errors.New("enabled must be provided"))
+		return
+	}
+
+	app.readOnly.Store(*input.Enabled)
+	app.logger.Info("read-only mode changed", "enabled", *input.Enabled)
+
+	err = app.writeJSON(writer, http.StatusOK, envelope{"readOnly": *input.Enabled}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+// readRoom looks up the room named in the path and responds with 400 or 404
+// if there is none.
+func (app *application) readRoom(writer http.ResponseWriter, request *http.Request) (*internal.Room, bool) {
+	roomId, err := app.readIdParam(request)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return nil, false
+	}
+
+	app.mu.RLock()
+	room, ok := app.rooms[roomId]
+	app.mu.RUnlock()
+	if !ok {
+		app.notFoundResponse(writer, request)
+		return nil, false
+	}
+	return room, true
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+// readUntil reads from connection until a message of type arrives and returns
+// its data.
+func readUntil(t *testing.T, connection *websocket.Conn, messageType string) json.RawMessage {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for {
+		var msg struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := wsjson.Read(ctx, connection, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type == messageType {
+			return msg.Data
+		}
+	}
+}
+
+func TestApplication_adminRequiresToken(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.config.Auth.AdminToken = testAdminToken
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+	creator := ts.newAPIKey(t, "ci", internal.ScopeCreateRooms)
+
+	assert.Equal(t, ts.get(t, "/v1/admin/rooms").status, http.StatusUnauthorized)
+	assert.Equal(t, ts.getWithHeaders(t, "/v1/admin/rooms", bearer("wrong")).status, http.StatusUnauthorized)
+	assert.Equal(t, ts.getWithHeaders(t, "/v1/admin/rooms", bearer(creator)).status, http.StatusForbidden)
+	assert.Equal(t, ts.getWithHeaders(t, "/v1/admin/rooms", bearer(testAdminToken)).status, http.StatusOK)
+}
+
+func TestApplication_admin(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.config.Auth.AdminToken = testAdminToken
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+	admin := bearer(testAdminToken)
+
+	created := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester", "visibility": internal.VisibilityUnlisted})
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+	owner, _ := ts.dialOwner(t, room["id"], "Tester")
+	defer owner.CloseNow()
+	developer, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http")+"/v1/room/"+room["id"]+"/developer?name=Dev", nil)
+	assert.NilError(t, err)
+	defer developer.CloseNow()
+	readUntil(t, owner, "users")
+
+	t.Run("lists unlisted rooms with their clients", func(t *testing.T) {
+		response := ts.getWithHeaders(t, "/v1/admin/rooms", admin)
+		assert.Equal(t, response.status, http.StatusOK)
+		var got struct {
+			Rooms    []internal.RoomStatus `json:"rooms"`
+			ReadOnly bool                  `json:"readOnly"`
+		}
+		json.Unmarshal(response.body, &got)
+
+		assert.Equal(t, len(got.Rooms), 1)
+		assert.Equal(t, got.Rooms[0].Id.String(), room["id"])
+		assert.Equal(t, got.Rooms[0].Visibility, internal.VisibilityUnlisted)
+		assert.Equal(t, got.Rooms[0].Phase, internal.PhaseWaiting)
+		assert.DeepEqual(t, got.Rooms[0].Clients, []internal.ClientStatus{
+			{Name: "Dev", Role: internal.Developer},
+			{Name: "Tester", Role: internal.ProductOwner},
+		})
+		assert.False(t, got.ReadOnly)
+	})
+
+	t.Run("broadcasts a notice to every room", func(t *testing.T) {
+		invalid := ts.doJSON(t, http.MethodPost, "/v1/admin/broadcast", map[string]string{"message": " "}, admin)
+		assert.Equal(t, invalid.status, http.StatusBadRequest)
+
+		response := ts.doJSON(t, http.MethodPost, "/v1/admin/broadcast", map[string]string{"message": "restart at 18:00"}, admin)
+		assert.Equal(t, response.status, http.StatusOK)
+		assert.Equal(t, strings.Join(strings.Fields(string(response.body)), ""), `{"rooms":1}`)
+
+		for _, connection := range []*websocket.Conn{owner, developer} {
+			assert.Equal(t, string(readUntil(t, connection, "maintenance")), `{"message":"restart at 18:00"}`)
+		}
+	})
+
+	t.Run("disconnects a client", func(t *testing.T) {
+		assert.Equal(t, ts.doJSON(t, http.MethodDelete, "/v1/admin/rooms/"+room["id"]+"/clients/Nobody", nil, admin).status, http.StatusNotFound)
+		assert.Equal(t, ts.doJSON(t, http.MethodDelete, "/v1/admin/rooms/"+room["id"]+"/clients/Dev", nil, admin).status, http.StatusNoContent)
+
+		assert.Equal(t, string(readUntil(t, developer, "disconnected")), `"disconnected by an operator"`)
+		_, _, err := developer.Read(context.Background())
+		assert.Equal(t, websocket.CloseStatus(err), websocket.StatusNormalClosure)
+	})
+
+	t.Run("read-only mode stops room creation", func(t *testing.T) {
+		assert.Equal(t, ts.doJSON(t, http.MethodPut, "/v1/admin/read-only", map[string]any{}, admin).status, http.StatusBadRequest)
+		assert.Equal(t, ts.doJSON(t, http.MethodPut, "/v1/admin/read-only", map[string]any{"enabled": true}, admin).status, http.StatusOK)
+
+		response := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Other"})
+		assert.Equal(t, response.status, http.StatusServiceUnavailable)
+		assert.StringContains(t, string(response.body), "room creation is disabled for maintenance")
+
+		assert.Equal(t, ts.doJSON(t, http.MethodPut, "/v1/admin/read-only", map[string]any{"enabled": false}, admin).status, http.StatusOK)
+		assert.Equal(t, ts.postJSON(t, "/v1/room", map[string]any{"creator": "Other"}).status, http.StatusCreated)
+	})
+
+	t.Run("the admin token reads owned rooms", func(t *testing.T) {
+		assert.Equal(t, ts.getWithHeaders(t, "/v1/room/"+room["id"]+"/audit", admin).status, http.StatusOK)
+		assert.Equal(t, ts.getWithHeaders(t, "/v1/room/"+room["id"]+"/export", admin).status, http.StatusOK)
+		assert.Equal(t, ts.getWithHeaders(t, "/v1/room/"+room["id"]+"/export", bearer("wrong")).status, http.StatusUnauthorized)
+	})
+
+	t.Run("force-closes a room", func(t *testing.T) {
+		assert.Equal(t, ts.doJSON(t, http.MethodDelete, "/v1/admin/rooms/"+uuid.NewString(), nil, admin).status, http.StatusNotFound)
+		assert.Equal(t, ts.doJSON(t, http.MethodDelete, "/v1/admin/rooms/"+room["id"], nil, admin).status, http.StatusNoContent)
+
+		assert.Equal(t, string(readUntil(t, owner, "room-closed")), `"operator"`)
+		assert.Equal(t, ts.get(t, "/v1/room/"+room["id"]+"/state").status, http.StatusNotFound)
+	})
+}
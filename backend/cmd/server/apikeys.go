@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+const maxAPIKeyNameLength = 100
+
+// createAPIKeyInput takes the lifetime of the key as duration like "720h",
+// keys without one never expire.
+type createAPIKeyInput struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn string   `json:"expiresIn"`
+}
+
+func (app *application) handleFetchAPIKeys(writer http.ResponseWriter, request *http.Request) {
+	keys, err := app.apiKeys.APIKeys()
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+		return
+	}
+
+	err = app.writeJSON(writer, http.StatusOK, envelope{"apiKeys": keys}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleCreateAPIKey(writer http.ResponseWriter, request *http.Request) {
+	var input createAPIKeyInput
+	err := app.readJSON(writer, request, &input)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	var ttl time.Duration
+	if input.ExpiresIn != "" {
+		ttl, err = time.ParseDuration(input.ExpiresIn)
+		if err != nil {
+			ttl = -1
+		}
+	}
+
+	var errs []error
+	if input.Name == "" || utf8.RuneCountInString(input.Name) > maxAPIKeyNameLength {
+		errs = append(errs, fmt.Errorf("name must be 1 to %d characters", maxAPIKeyNameLength))
+	}
+	if err := internal.ValidateScopes(input.Scopes); err != nil {
+		errs = append(errs, err)
+	}
+	if ttl < 0 {
+		errs = append(errs, errors.New("expiresIn must be a positive duration"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	key, secret := internal.NewAPIKey(input.Name, input.Scopes, ttl, time.Now().UTC())
+	err = app.apiKeys.CreateAPIKey(key)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+		return
+	}
+
+	err = app.writeJSON(writer, http.StatusCreated, envelope{"apiKey": key, "secret": secret}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleDeleteAPIKey(writer http.ResponseWriter, request *http.Request) {
+	id, err := uuid.Parse(httprouter.ParamsFromContext(request.Context()).ByName("key"))
+	if err != nil {
+		app.notFoundResponse(writer, request)
+		return
+	}
+
+	err = app.apiKeys.DeleteAPIKey(id)
+	switch {
+	case errors.Is(err, internal.ErrAPIKeyNotFound):
+		app.notFoundResponse(writer, request)
+	case err != nil:
+		app.serverErrorResponse(writer, request, err)
+	default:
+		writer.WriteHeader(http.StatusNoContent)
+	}
+}
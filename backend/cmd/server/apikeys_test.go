@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+const testAdminToken = "test-admin-token"
+
+// newAPIKey creates a key through the api with the admin token and returns
+// its secret.
+func (ts *testServer) newAPIKey(t *testing.T, name string, scopes ...string) string {
+	t.Helper()
+
+	response := ts.doJSON(t, http.MethodPost, "/v1/api-keys", map[string]any{"name": name, "scopes": scopes}, bearer(testAdminToken))
+	assert.Equal(t, response.status, http.StatusCreated)
+	var got struct {
+		Secret string `json:"secret"`
+	}
+	json.Unmarshal(response.body, &got)
+	return got.Secret
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestApplication_manageAPIKeys(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.config.Auth.AdminToken = testAdminToken
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	assert.Equal(t, ts.postJSON(t, "/v1/api-keys", map[string]any{"name": "ci", "scopes": []string{"admin"}}).status, http.StatusUnauthorized)
+
+	invalid := ts.doJSON(t, http.MethodPost, "/v1/api-keys", map[string]any{"name": "", "scopes": []string{"rooms:delete"}, "expiresIn": "-1h"}, bearer(testAdminToken))
+	assert.Equal(t, invalid.status, http.StatusBadRequest)
+	assert.StringContains(t, string(invalid.body), `name must be 1 to 100 characters\nscope must be one of rooms:create, issues:import, exports:read, admin, got \"rooms:delete\"\nexpiresIn must be a positive duration`)
+
+	admin := ts.newAPIKey(t, "ops", internal.ScopeAdmin)
+	creator := ts.newAPIKey(t, "ci", internal.ScopeCreateRooms)
+
+	listed := ts.getWithHeaders(t, "/v1/api-keys", bearer(admin))
+	assert.Equal(t, listed.status, http.StatusOK)
+	var got struct {
+		APIKeys []map[string]any `json:"apiKeys"`
+	}
+	json.Unmarshal(listed.body, &got)
+	assert.Equal(t, len(got.APIKeys), 2)
+	_, hasHash := got.APIKeys[0]["hash"]
+	assert.False(t, hasHash)
+
+	assert.Equal(t, ts.getWithHeaders(t, "/v1/health/details", bearer(admin)).status, http.StatusOK)
+	forbidden := ts.getWithHeaders(t, "/v1/health/details", bearer(creator))
+	assert.Equal(t, forbidden.status, http.StatusForbidden)
+	assert.StringContains(t, string(forbidden.body), "api key lacks the admin scope")
+
+	var id string
+	for _, key := range got.APIKeys {
+		if key["name"] == "ci" {
+			id = key["id"].(string)
+		}
+	}
+	assert.Equal(t, ts.doJSON(t, http.MethodDelete, "/v1/api-keys/"+id, nil, bearer(admin)).status, http.StatusNoContent)
+	assert.Equal(t, ts.doJSON(t, http.MethodDelete, "/v1/api-keys/"+id, nil, bearer(admin)).status, http.StatusNotFound)
+	assert.Equal(t, ts.postJSON(t, "/v1/room", map[string]any{"creator": "Bot"}).status, http.StatusCreated)
+	assert.Equal(t, ts.doJSON(t, http.MethodPost, "/v1/room", map[string]any{"creator": "Bot"}, bearer(creator)).status, http.StatusUnauthorized)
+}
+
+func TestApplication_apiKeyScopes(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.config.Auth.AdminToken = testAdminToken
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	creator := ts.newAPIKey(t, "ci", internal.ScopeCreateRooms, internal.ScopeImportIssues, internal.ScopeReadExports)
+	importer := ts.newAPIKey(t, "jira sync", internal.ScopeImportIssues)
+	exporter := ts.newAPIKey(t, "reporting", internal.ScopeReadExports)
+	operator := ts.newAPIKey(t, "ops", internal.ScopeAdmin)
+	expiredKey, expiredSecret := internal.NewAPIKey("expired", []string{internal.ScopeAdmin}, time.Second, time.Now().Add(-time.Hour))
+	app.apiKeys.CreateAPIKey(expiredKey)
+
+	created := ts.doJSON(t, http.MethodPost, "/v1/room", map[string]any{"creator": "Paula"}, bearer(creator))
+	assert.Equal(t, created.status, http.StatusCreated)
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+	assert.True(t, room["ownerToken"] != "")
+	anonymous := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Paula"})
+	var anonymousRoom map[string]string
+	json.Unmarshal(anonymous.body, &anonymousRoom)
+	assert.Equal(t, anonymousRoom["ownerToken"], "")
+
+	issues := map[string]any{"issues": []string{"Login page", " Logout "}}
+	roomPath := "/v1/room/" + room["id"]
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       any
+		token      string
+		wantStatus int
+	}{
+		{name: "unknown key", method: http.MethodPost, path: "/v1/room", body: map[string]any{"creator": "Bot"}, token: "ep_unknown", wantStatus: http.StatusUnauthorized},
+		{name: "expired key", method: http.MethodPost, path: "/v1/room", body: map[string]any{"creator": "Bot"}, token: expiredSecret, wantStatus: http.StatusUnauthorized},
+		{name: "create without scope", method: http.MethodPost, path: "/v1/room", body: map[string]any{"creator": "Bot"}, token: importer, wantStatus: http.StatusForbidden},
+		{name: "update details", method: http.MethodPatch, path: roomPath, body: map[string]any{"title": "Sprint 42"}, token: creator, wantStatus: http.StatusOK},
+		{name: "import without scope", method: http.MethodPost, path: roomPath + "/issues", body: issues, token: exporter, wantStatus: http.StatusForbidden},
+		{name: "import into a room of another key", method: http.MethodPost, path: roomPath + "/issues", body: issues, token: importer, wantStatus: http.StatusForbidden},
+		{name: "import nothing", method: http.MethodPost, path: roomPath + "/issues", body: map[string]any{"issues": []string{}}, token: creator, wantStatus: http.StatusBadRequest},
+		{name: "import blank title", method: http.MethodPost, path: roomPath + "/issues", body: map[string]any{"issues": []string{" "}}, token: creator, wantStatus: http.StatusBadRequest},
+		{name: "import", method: http.MethodPost, path: roomPath + "/issues", body: issues, token: creator, wantStatus: http.StatusCreated},
+		{name: "import with owner token", method: http.MethodPost, path: roomPath + "/issues", body: map[string]any{"issues": []string{"Signup"}}, token: room["ownerToken"], wantStatus: http.StatusCreated},
+		{name: "export without scope", method: http.MethodGet, path: roomPath + "/export", token: importer, wantStatus: http.StatusForbidden},
+		{name: "audit of a room of another key", method: http.MethodGet, path: roomPath + "/audit", token: exporter, wantStatus: http.StatusForbidden},
+		{name: "audit of an anonymous room", method: http.MethodGet, path: "/v1/room/" + anonymousRoom["id"] + "/audit", token: creator, wantStatus: http.StatusForbidden},
+		{name: "audit with the admin scope", method: http.MethodGet, path: roomPath + "/audit", token: operator, wantStatus: http.StatusOK},
+		{name: "audit after an entry", method: http.MethodGet, path: roomPath + "/audit?since=1", token: operator, wantStatus: http.StatusOK},
+		{name: "audit after an invalid entry", method: http.MethodGet, path: roomPath + "/audit?since=last", token: operator, wantStatus: http.StatusBadRequest},
+		{name: "rotating the owner token needs the owner", method: http.MethodPost, path: roomPath + "/owner-token", token: creator, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := ts.doJSON(t, tt.method, tt.path, tt.body, bearer(tt.token))
+
+			assert.Equal(t, response.status, tt.wantStatus)
+		})
+	}
+
+	exported := ts.getWithHeaders(t, roomPath+"/export", bearer(creator))
+	assert.Equal(t, exported.status, http.StatusOK)
+	var export struct {
+		Room    internal.RoomSnapshot `json:"room"`
+		Details internal.RoomDetails  `json:"details"`
+	}
+	json.Unmarshal(exported.body, &export)
+	assert.DeepEqual(t, export.Room.Issues, []*internal.Issue{{Title: "Login page", Guess: -1}, {Title: "Logout", Guess: -1}, {Title: "Signup", Guess: -1}})
+	assert.Equal(t, export.Details.Title, "Sprint 42")
+	var actors []string
+	for _, entry := range export.Room.AuditTrail {
+		if entry.Action == internal.AuditImportIssues {
+			actors = append(actors, entry.Actor)
+		}
+	}
+	assert.DeepEqual(t, actors, []string{"ci", "Paula"})
+}
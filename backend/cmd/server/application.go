@@ -1,36 +1,181 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"maps"
 	"net/http"
-	"sort"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 
 	"github.com/Hydoc/estimation-poker/backend/internal"
 )
 
+var validDomain = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+type createRoomInput struct {
+	Creator       string         `json:"creator"`
+	Guesses       map[int]string `json:"guesses"`
+	Deck          string         `json:"deck"`
+	Slug          string         `json:"slug"`
+	Visibility    string         `json:"visibility"`
+	AllowedDomain string         `json:"allowedDomain"`
+	TeamId        string         `json:"teamId"`
+	internal.RoomDetails
+}
+
+// roomMetadata tells the lobby whether a room exists before joining it.
+type roomMetadata struct {
+	Exists        bool   `json:"exists"`
+	IsLocked      bool   `json:"isLocked"`
+	Visibility    string `json:"visibility,omitempty"`
+	AllowedDomain string `json:"allowedDomain,omitempty"`
+	internal.RoomDetails
+}
+
+type connectionStateInput struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Invite   string `json:"invite"`
+}
+
+// updateRoomInput changes the fields that are not nil.
+type updateRoomInput struct {
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	Team        *string   `json:"team"`
+	Tags        *[]string `json:"tags"`
+	Visibility  *string   `json:"visibility"`
+}
+
 func (app *application) createNewRoom(writer http.ResponseWriter, request *http.Request) {
+	if app.readOnly.Load() {
+		app.readOnlyResponse(writer, request)
+		return
+	}
+
 	app.mu.Lock()
 	defer app.mu.Unlock()
 
-	var input struct {
-		Creator string         `json:"creator"`
-		Guesses map[int]string `json:"guesses"`
-	}
-
+	var input createRoomInput
 	err := app.readJSON(writer, request, &input)
 	if err != nil {
 		app.badRequestResponse(writer, request, err)
 		return
 	}
 
+	var team internal.Team
+	if input.TeamId != "" {
+		team, err = app.teams.Team(input.TeamId)
+		switch {
+		case errors.Is(err, internal.ErrTeamNotFound):
+			app.badRequestResponse(writer, request, fmt.Errorf("unknown team %q", input.TeamId))
+			return
+		case err != nil:
+			app.serverErrorResponse(writer, request, err)
+			return
+		}
+		input.Deck = cmp.Or(input.Deck, team.Deck)
+		input.Visibility = cmp.Or(input.Visibility, team.Settings.Visibility)
+		input.AllowedDomain = cmp.Or(input.AllowedDomain, team.Settings.AllowedDomain)
+		input.Team = cmp.Or(input.Team, team.Name)
+		if len(input.Tags) == 0 {
+			input.Tags = team.Settings.Tags
+		}
+	}
+
+	details := input.RoomDetails.Normalize()
+	if err := details.Validate(); err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	user := app.readSession(request)
+	if user != nil {
+		input.Creator = app.displayName(user)
+	}
+	allowedDomain := strings.ToLower(strings.TrimSpace(input.AllowedDomain))
+	if allowedDomain != "" && user == nil {
+		app.badRequestResponse(writer, request, errors.New("restricting a room to an email domain requires login"))
+		return
+	}
+	if allowedDomain != "" && !validDomain.MatchString(allowedDomain) {
+		app.badRequestResponse(writer, request, fmt.Errorf("allowedDomain %q is not a valid domain", input.AllowedDomain))
+		return
+	}
+
+	if input.Visibility == "" {
+		input.Visibility = internal.VisibilityPublic
+	}
+	if err := internal.ValidateVisibility(input.Visibility); err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	if input.Deck == "" {
+		input.Deck = defaultDeckName
+	}
+	deck, ok := app.decks[input.Deck]
+	if !ok {
+		app.badRequestResponse(writer, request, fmt.Errorf("unknown deck %q", input.Deck))
+		return
+	}
+
+	if input.Slug != "" {
+		if err := validateSlug(input.Slug); err != nil {
+			app.badRequestResponse(writer, request, err)
+			return
+		}
+	}
+
 	roomId := uuid.New()
-	room := internal.NewRoom(roomId, app.destroyRoom, input.Creator, app.logger, app.guessConfig)
+	if input.Slug != "" && !app.aliases.add(input.Slug, roomId) {
+		app.conflictResponse(writer, request, errSlugTaken)
+		return
+	}
+	code, err := app.aliases.newRoomCode(roomId)
+	if err != nil {
+		app.aliases.removeRoom(roomId)
+		app.serverErrorResponse(writer, request, err)
+		return
+	}
+
+	room := internal.NewRoom(roomId, app.destroyRoom, input.Creator, app.logger, deck, app.auditSink)
+	room.Code = code
+	room.Slug = input.Slug
+	room.AllowedDomain = allowedDomain
+	room.TeamId = team.Id
+	room.Roster = team.Members
+	if user != nil {
+		room.OwnerSubject = user.Subject
+	}
+	if key := app.contextGetAPIKey(request); key != nil {
+		room.CreatorKeyId = key.Id
+	}
 	app.rooms[room.Id] = room
 	go room.Run()
+	if !details.IsZero() {
+		room.SetDetails(details)
+	}
+	if input.Visibility != internal.VisibilityPublic {
+		room.SetVisibility(input.Visibility)
+	}
 
-	err = app.writeJSON(writer, http.StatusCreated, envelope{"id": roomId.String()}, nil)
+	data := envelope{"id": roomId.String(), "code": code}
+	if input.Slug != "" {
+		data["slug"] = input.Slug
+	}
+	// automation clients prepare rooms without ever joining them
+	if app.contextGetAPIKey(request) != nil {
+		data["ownerToken"] = room.OwnerToken()
+	}
+	err = app.writeJSON(writer, http.StatusCreated, data, nil)
 	if err != nil {
 		app.serverErrorResponse(writer, request, err)
 	}
@@ -49,7 +194,7 @@ func (app *application) handleFetchRoomMetadata(writer http.ResponseWriter, requ
 	room, ok := app.rooms[roomId]
 
 	if !ok {
-		err = app.writeJSON(writer, http.StatusOK, envelope{"exists": false, "isLocked": false}, nil)
+		err = app.writeJSON(writer, http.StatusOK, roomMetadata{Visibility: internal.VisibilityPublic}, nil)
 		if err != nil {
 			app.serverErrorResponse(writer, request, err)
 			return
@@ -57,7 +202,22 @@ func (app *application) handleFetchRoomMetadata(writer http.ResponseWriter, requ
 		return
 	}
 
-	err = app.writeJSON(writer, http.StatusOK, envelope{"exists": true, "isLocked": room.IsLocked()}, nil)
+	if !app.mayInspect(request, room) {
+		err = app.writeJSON(writer, http.StatusOK, roomMetadata{Exists: true, IsLocked: room.IsLocked()}, nil)
+		if err != nil {
+			app.serverErrorResponse(writer, request, err)
+		}
+		return
+	}
+
+	metadata := roomMetadata{
+		Exists:        true,
+		IsLocked:      room.IsLocked(),
+		Visibility:    room.Visibility(),
+		AllowedDomain: room.AllowedDomain,
+		RoomDetails:   room.Details(),
+	}
+	err = app.writeJSON(writer, http.StatusOK, metadata, nil)
 	if err != nil {
 		app.serverErrorResponse(writer, request, err)
 		return
@@ -76,11 +236,7 @@ func (app *application) handleConnectionState(writer http.ResponseWriter, reques
 		return
 	}
 
-	var input struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
-
+	var input connectionStateInput
 	err = app.readJSON(writer, request, &input)
 	if err != nil {
 		app.serverErrorResponse(writer, request, err)
@@ -93,7 +249,19 @@ func (app *application) handleConnectionState(writer http.ResponseWriter, reques
 		return
 	}
 
-	err = app.writeJSON(writer, http.StatusOK, actualRoom.ConnectionState(input.Username, input.Password), nil)
+	user := app.readSession(request)
+	if user != nil {
+		input.Username = app.displayName(user)
+	}
+	state := internal.ConnectionState{}
+	invite, err := app.readInvite(input.Invite)
+	if err != nil {
+		state.Reason = err.Error()
+	} else {
+		state = actualRoom.ConnectionState(input.Username, input.Password, invite, user)
+	}
+
+	err = app.writeJSON(writer, http.StatusOK, state, nil)
 	if err != nil {
 		app.serverErrorResponse(writer, request, err)
 	}
@@ -115,6 +283,11 @@ func (app *application) handleFetchRoomState(writer http.ResponseWriter, request
 		return
 	}
 
+	if !app.mayInspect(request, actualRoom) {
+		app.forbiddenResponse(writer, request, internal.ErrPrivateRoom)
+		return
+	}
+
 	err = app.writeJSON(writer, http.StatusOK, actualRoom.State(), nil)
 	if err != nil {
 		app.serverErrorResponse(writer, request, err)
@@ -122,17 +295,116 @@ func (app *application) handleFetchRoomState(writer http.ResponseWriter, request
 }
 
 func (app *application) handleFetchActiveRooms(writer http.ResponseWriter, request *http.Request) {
-	//goland:noinspection GoPreferNilSlice
-	overviewRooms := []internal.Overview{}
-	for _, room := range app.rooms {
-		if !room.IsLocked() {
-			overviewRooms = append(overviewRooms, room.AsOverview())
+	query, err := readRoomListQuery(request.URL.Query())
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	// building the overviews locks each room, which must not wait while
+	// app.mu blocks destroying rooms
+	app.mu.RLock()
+	rooms := slices.Collect(maps.Values(app.rooms))
+	app.mu.RUnlock()
+
+	listings := make([]roomListing, 0, len(rooms))
+	for _, room := range rooms {
+		if room.IsListed() {
+			listings = append(listings, roomListing{overview: room.AsOverview(), inProgress: room.IsInProgress()})
+		}
+	}
+
+	overviews, nextCursor := query.page(listings)
+	data := envelope{"rooms": overviews}
+	if nextCursor != "" {
+		data["nextCursor"] = nextCursor
+	}
+	err = app.writeJSON(writer, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleFetchRoomAudit(writer http.ResponseWriter, request *http.Request) {
+	actualRoom, ok := app.readOwnedRoom(writer, request, internal.ScopeReadExports)
+	if !ok {
+		return
+	}
+
+	since := 0
+	if value := request.URL.Query().Get("since"); value != "" {
+		var err error
+		since, err = strconv.Atoi(value)
+		if err != nil || since < 0 {
+			app.badRequestResponse(writer, request, errors.New("since must be the seq of an audit entry"))
+			return
+		}
+	}
+
+	err := app.writeJSON(writer, http.StatusOK, envelope{"entries": actualRoom.AuditTrailSince(since)}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleExportRoom(writer http.ResponseWriter, request *http.Request) {
+	actualRoom, ok := app.readOwnedRoom(writer, request, internal.ScopeReadExports)
+	if !ok {
+		return
+	}
+
+	err := app.writeJSON(writer, http.StatusOK, envelope{"room": actualRoom.Export(), "details": actualRoom.Details()}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleUpdateRoomDetails(writer http.ResponseWriter, request *http.Request) {
+	actualRoom, ok := app.readOwnedRoom(writer, request, internal.ScopeCreateRooms)
+	if !ok {
+		return
+	}
+
+	var input updateRoomInput
+	err := app.readJSON(writer, request, &input)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	details := actualRoom.Details()
+	if input.Title != nil {
+		details.Title = *input.Title
+	}
+	if input.Description != nil {
+		details.Description = *input.Description
+	}
+	if input.Team != nil {
+		details.Team = *input.Team
+	}
+	if input.Tags != nil {
+		details.Tags = *input.Tags
+	}
+	details = details.Normalize()
+	if err := details.Validate(); err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+	if input.Visibility != nil {
+		if err := internal.ValidateVisibility(*input.Visibility); err != nil {
+			app.badRequestResponse(writer, request, err)
+			return
 		}
 	}
-	sort.Slice(overviewRooms, func(i, j int) bool {
-		return overviewRooms[i].Created.Before(overviewRooms[j].Created)
-	})
-	err := app.writeJSON(writer, http.StatusOK, envelope{"rooms": overviewRooms}, nil)
+	if input.Title != nil || input.Description != nil || input.Team != nil || input.Tags != nil {
+		actualRoom.SetDetails(details)
+	}
+	if input.Visibility != nil && *input.Visibility != actualRoom.Visibility() {
+		actualRoom.SetVisibility(*input.Visibility)
+	}
+
+	data := envelope{"room": actualRoom.AsOverview(), "visibility": actualRoom.Visibility()}
+	err = app.writeJSON(writer, http.StatusOK, data, nil)
 	if err != nil {
 		app.serverErrorResponse(writer, request, err)
 	}
@@ -147,6 +419,7 @@ func (app *application) listenForRoomDestroy(ctx context.Context) {
 			app.mu.Lock()
 			delete(app.rooms, roomId)
 			app.mu.Unlock()
+			app.aliases.removeRoom(roomId)
 		}
 	}
 }
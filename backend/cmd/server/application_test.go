@@ -38,6 +38,22 @@ func TestApplication_createNewRoom(t *testing.T) {
 			},
 			expectedStatusCode: 400,
 		},
+		{
+			name: "create new room with default deck",
+			body: map[string]any{
+				"creator": "Tester",
+				"deck":    defaultDeckName,
+			},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "not create for unknown deck",
+			body: map[string]any{
+				"creator": "Tester",
+				"deck":    "does-not-exist",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -74,21 +90,21 @@ func TestApplication_handleFetchRoomMetadata(t *testing.T) {
 				},
 			},
 			wantStatus: http.StatusOK,
-			wantBody:   envelope{"exists": true, "isLocked": false},
+			wantBody:   envelope{"exists": true, "isLocked": false, "visibility": "public"},
 		},
 		{
 			name:       "does not exist",
 			roomId:     "bd284176-7a5d-4443-b0e0-5058c3e07853",
 			rooms:      make(map[uuid.UUID]*internal.Room),
 			wantStatus: http.StatusOK,
-			wantBody:   envelope{"exists": false, "isLocked": false},
+			wantBody:   envelope{"exists": false, "isLocked": false, "visibility": "public"},
 		},
 		{
 			name:       "room id is invalid",
 			roomId:     "invalid-uuid",
 			rooms:      make(map[uuid.UUID]*internal.Room),
 			wantStatus: http.StatusBadRequest,
-			wantBody:   envelope{"error": "invalid id parameter"},
+			wantBody:   envelope{"error": "invalid id parameter", "requestId": testRequestId},
 		},
 	}
 
@@ -407,7 +423,7 @@ func TestApplication_ListenForRoomDestroy(t *testing.T) {
 	destroyChannel := make(chan uuid.UUID)
 	roomToDestroy := uuid.MustParse("e8563735-ca82-4fad-b9fc-4942c5b0cdb0")
 	app := &application{
-		guessConfig: &internal.GuessConfig{},
+		decks: map[string]*internal.GuessConfig{},
 		rooms: map[uuid.UUID]*internal.Room{
 			roomToDestroy: {
 				Id: roomToDestroy,
@@ -425,3 +441,66 @@ func TestApplication_ListenForRoomDestroy(t *testing.T) {
 		t.Error("expected app to not have room")
 	}
 }
+
+func TestApplication_handleFetchRoomAudit(t *testing.T) {
+	tests := []struct {
+		name       string
+		roomId     string
+		header     string
+		rooms      map[uuid.UUID]*internal.Room
+		wantStatus int
+		wantBody   envelope
+	}{
+		{
+			name:   "missing owner token",
+			roomId: "9c874aaa-c628-4688-a72d-0b1afc708a7d",
+			header: "",
+			rooms: map[uuid.UUID]*internal.Room{
+				uuid.MustParse("9c874aaa-c628-4688-a72d-0b1afc708a7d"): {},
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   envelope{"error": "invalid or missing authentication token", "requestId": testRequestId},
+		},
+		{
+			name:   "wrong owner token",
+			roomId: "9c874aaa-c628-4688-a72d-0b1afc708a7d",
+			header: "Bearer " + uuid.NewString(),
+			rooms: map[uuid.UUID]*internal.Room{
+				uuid.MustParse("9c874aaa-c628-4688-a72d-0b1afc708a7d"): {},
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   envelope{"error": "invalid or missing authentication token", "requestId": testRequestId},
+		},
+		{
+			name:       "room not found",
+			roomId:     "9c874aaa-c628-4688-a72d-0b1afc708a7d",
+			header:     "Bearer " + uuid.NewString(),
+			rooms:      make(map[uuid.UUID]*internal.Room),
+			wantStatus: http.StatusNotFound,
+			wantBody:   envelope{"error": "the requested resource could not be found", "requestId": testRequestId},
+		},
+		{
+			name:       "invalid room id",
+			roomId:     "invalid",
+			rooms:      make(map[uuid.UUID]*internal.Room),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   envelope{"error": "invalid id parameter", "requestId": testRequestId},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, tt.rooms)
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			response := ts.getWithHeaders(t, fmt.Sprintf("/v1/room/%s/audit", tt.roomId), http.Header{"Authorization": {tt.header}})
+
+			var got envelope
+			json.Unmarshal(response.body, &got)
+
+			assert.Equal(t, response.status, tt.wantStatus)
+			assert.DeepEqual(t, got, tt.wantBody)
+		})
+	}
+}
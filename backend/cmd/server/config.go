@@ -0,0 +1,510 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+// config holds every setting of the server. Values are resolved in the
+// following order, later sources overriding earlier ones:
+//
+//  1. built-in defaults (see defaultConfig)
+//  2. the TOML file given with -config or CONFIG_FILE
+//  3. environment variables (see settings for their names)
+//  4. command line flags
+type config struct {
+	Port     int                   `toml:"port"`
+	Env      string                `toml:"env"`
+	LogLevel string                `toml:"log_level"`
+	Timeouts timeoutsConfig        `toml:"timeouts"`
+	Deck     deckConfig            `toml:"deck"`
+	Decks    map[string]deckConfig `toml:"decks"`
+	Limits   limitsConfig          `toml:"limits"`
+	Rooms    roomsConfig           `toml:"rooms"`
+	Storage  storageConfig         `toml:"storage"`
+	Auth     authConfig            `toml:"auth"`
+	OIDC     oidcConfig            `toml:"oidc"`
+	Tracing  tracingConfig         `toml:"tracing"`
+	CORS     corsConfig            `toml:"cors"`
+}
+
+type timeoutsConfig struct {
+	Idle     time.Duration `toml:"idle"`
+	Read     time.Duration `toml:"read"`
+	Write    time.Duration `toml:"write"`
+	Shutdown time.Duration `toml:"shutdown"`
+	Drain    time.Duration `toml:"drain"`
+}
+
+type deckConfig struct {
+	Label        string `toml:"label"`
+	Guesses      string `toml:"guesses"`
+	Descriptions string `toml:"descriptions"`
+}
+
+type limitsConfig struct {
+	Enabled       bool    `toml:"enabled"`
+	RPS           float64 `toml:"rps"`
+	Burst         int     `toml:"burst"`
+	TrustProxy    bool    `toml:"trust_proxy"`
+	MessageRPS    float64 `toml:"message_rps"`
+	MessageBurst  int     `toml:"message_burst"`
+	MaxNameLength int     `toml:"max_name_length"`
+}
+
+// roomsConfig controls when the janitor closes rooms, a zero duration
+// disables the respective limit.
+type roomsConfig struct {
+	IdleTimeout     time.Duration `toml:"idle_timeout"`
+	MaxAge          time.Duration `toml:"max_age"`
+	ExpiryWarning   time.Duration `toml:"expiry_warning"`
+	JanitorInterval time.Duration `toml:"janitor_interval"`
+}
+
+type storageConfig struct {
+	AuditSink       string `toml:"audit_sink"`
+	AuditFile       string `toml:"audit_file"`
+	AuditMaxSize    int64  `toml:"audit_max_size"`
+	AuditMaxBackups int    `toml:"audit_max_backups"`
+	Archive         string `toml:"archive"`
+	ArchiveFile     string `toml:"archive_file"`
+	Teams           string `toml:"teams"`
+	TeamsFile       string `toml:"teams_file"`
+	APIKeyStore     string `toml:"api_key_store"`
+	APIKeyFile      string `toml:"api_key_file"`
+}
+
+type authConfig struct {
+	AdminToken string `toml:"admin_token"`
+	// TokenSecret signs invite tokens and login sessions. Without it a random
+	// secret is used, so neither survives a restart.
+	TokenSecret string `toml:"token_secret"`
+}
+
+// oidcConfig enables login with an OpenID Connect provider. Login is
+// disabled while no issuer is set.
+type oidcConfig struct {
+	Issuer       string        `toml:"issuer"`
+	ClientId     string        `toml:"client_id"`
+	ClientSecret string        `toml:"client_secret"`
+	RedirectURL  string        `toml:"redirect_url"`
+	Scopes       []string      `toml:"scopes"`
+	SessionTTL   time.Duration `toml:"session_ttl"`
+}
+
+func (cfg oidcConfig) enabled() bool {
+	return cfg.Issuer != ""
+}
+
+type tracingConfig struct {
+	Endpoint string `toml:"endpoint"`
+	Insecure bool   `toml:"insecure"`
+}
+
+type corsConfig struct {
+	TrustedOrigins []string `toml:"trusted_origins"`
+}
+
+// originPatterns returns the trusted origins as host patterns, the format
+// expected by websocket.AcceptOptions. Entries may be full origins such as
+// https://poker.example.com or bare patterns such as *.example.com.
+func (cfg corsConfig) originPatterns() []string {
+	patterns := make([]string, 0, len(cfg.TrustedOrigins))
+	for _, origin := range cfg.TrustedOrigins {
+		if parsed, err := url.Parse(origin); err == nil && parsed.Host != "" {
+			patterns = append(patterns, parsed.Host)
+			continue
+		}
+		patterns = append(patterns, origin)
+	}
+	return patterns
+}
+
+func defaultConfig() config {
+	return config{
+		Port:     8080,
+		Env:      "development",
+		LogLevel: "info",
+		Timeouts: timeoutsConfig{
+			Idle:     time.Minute,
+			Read:     5 * time.Second,
+			Write:    10 * time.Second,
+			Shutdown: 30 * time.Second,
+			Drain:    5 * time.Second,
+		},
+		Deck: deckConfig{
+			Label:        "Default",
+			Guesses:      defaultGuesses,
+			Descriptions: defaultGuessesDescription,
+		},
+		Decks: map[string]deckConfig{
+			"fibonacci": {
+				Label:        "Fibonacci",
+				Guesses:      "1,2,3,5,8,13,21,34",
+				Descriptions: "1,2,3,5,8,13,21,34",
+			},
+			"modified-fibonacci": {
+				Label:        "Modified Fibonacci",
+				Guesses:      "0,0.5,1,2,3,5,8,13,20,40,100,?,infinity,coffee",
+				Descriptions: "0,½,1,2,3,5,8,13,20,40,100,?,∞,☕",
+			},
+			"powers-of-two": {
+				Label:        "Powers of two",
+				Guesses:      "1,2,4,8,16,32,64",
+				Descriptions: "1,2,4,8,16,32,64",
+			},
+			"t-shirt": {
+				Label:        "T-shirt sizes",
+				Guesses:      "1,2,3,4,5,6",
+				Descriptions: "XS,S,M,L,XL,XXL",
+			},
+			"hours": {
+				Label:        "Hours",
+				Guesses:      "1,2,4,8,16,24,40",
+				Descriptions: "1h,2h,4h,1 day,2 days,3 days,1 week",
+			},
+		},
+		Limits: limitsConfig{
+			Enabled:       true,
+			RPS:           10,
+			Burst:         20,
+			MessageRPS:    5,
+			MessageBurst:  10,
+			MaxNameLength: 15,
+		},
+		Rooms: roomsConfig{
+			IdleTimeout:     2 * time.Hour,
+			MaxAge:          24 * time.Hour,
+			ExpiryWarning:   5 * time.Minute,
+			JanitorInterval: time.Minute,
+		},
+		Storage: storageConfig{
+			AuditSink:       "stdout",
+			AuditFile:       "audit.log",
+			AuditMaxSize:    10 << 20,
+			AuditMaxBackups: 5,
+			Archive:         "none",
+			ArchiveFile:     "rooms.jsonl",
+			Teams:           "file",
+			TeamsFile:       "teams.json",
+			APIKeyStore:     "file",
+			APIKeyFile:      "api-keys.json",
+		},
+		OIDC: oidcConfig{
+			Scopes:     []string{"openid", "profile", "email"},
+			SessionTTL: 12 * time.Hour,
+		},
+		Tracing: tracingConfig{
+			Insecure: true,
+		},
+	}
+}
+
+func (cfg config) logLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(cfg.LogLevel))
+	return level, err
+}
+
+var validDeckName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// setting binds one config value to a command line flag and an environment
+// variable.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	bind  func(fs *flag.FlagSet, cfg *config, name, usage string)
+}
+
+var settings = []setting{
+	{"port", "PORT", "API server port", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.IntVar(&cfg.Port, name, cfg.Port, usage)
+	}},
+	{"env", "ENV", "Environment (development|test|production)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Env, name, cfg.Env, usage)
+	}},
+	{"log-level", "LOG_LEVEL", "Minimum log level (debug|info|warn|error)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.LogLevel, name, cfg.LogLevel, usage)
+	}},
+	{"idle-timeout", "IDLE_TIMEOUT", "HTTP keep-alive idle timeout", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.DurationVar(&cfg.Timeouts.Idle, name, cfg.Timeouts.Idle, usage)
+	}},
+	{"read-timeout", "READ_TIMEOUT", "HTTP read timeout", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.DurationVar(&cfg.Timeouts.Read, name, cfg.Timeouts.Read, usage)
+	}},
+	{"write-timeout", "WRITE_TIMEOUT", "HTTP write timeout", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.DurationVar(&cfg.Timeouts.Write, name, cfg.Timeouts.Write, usage)
+	}},
+	{"shutdown-timeout", "SHUTDOWN_TIMEOUT", "Grace period for open requests on shutdown", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.DurationVar(&cfg.Timeouts.Shutdown, name, cfg.Timeouts.Shutdown, usage)
+	}},
+	{"drain-timeout", "DRAIN_TIMEOUT", "Time readiness fails before shutting down, so load balancers stop routing requests", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.DurationVar(&cfg.Timeouts.Drain, name, cfg.Timeouts.Drain, usage)
+	}},
+	{"guesses", "POSSIBLE_GUESSES", "Comma separated guesses of the deck", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Deck.Guesses, name, cfg.Deck.Guesses, usage)
+	}},
+	{"guesses-desc", "POSSIBLE_GUESSES_DESC", "Comma separated descriptions of the guesses", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Deck.Descriptions, name, cfg.Deck.Descriptions, usage)
+	}},
+	{"limiter-enabled", "LIMITER_ENABLED", "Enable rate limiting", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.BoolVar(&cfg.Limits.Enabled, name, cfg.Limits.Enabled, usage)
+	}},
+	{"limiter-rps", "LIMITER_RPS", "Rate limiter maximum HTTP requests per second and IP", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.Float64Var(&cfg.Limits.RPS, name, cfg.Limits.RPS, usage)
+	}},
+	{"limiter-burst", "LIMITER_BURST", "Rate limiter maximum HTTP burst per IP", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.IntVar(&cfg.Limits.Burst, name, cfg.Limits.Burst, usage)
+	}},
+	{"limiter-trust-proxy", "LIMITER_TRUST_PROXY", "Identify clients by the X-Forwarded-For header of a trusted reverse proxy", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.BoolVar(&cfg.Limits.TrustProxy, name, cfg.Limits.TrustProxy, usage)
+	}},
+	{"limiter-message-rps", "LIMITER_MESSAGE_RPS", "Rate limiter maximum websocket messages per second and client", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.Float64Var(&cfg.Limits.MessageRPS, name, cfg.Limits.MessageRPS, usage)
+	}},
+	{"limiter-message-burst", "LIMITER_MESSAGE_BURST", "Rate limiter maximum websocket message burst per client", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.IntVar(&cfg.Limits.MessageBurst, name, cfg.Limits.MessageBurst, usage)
+	}},
+	{"max-name-length", "MAX_NAME_LENGTH", "Maximum length of a participant name", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.IntVar(&cfg.Limits.MaxNameLength, name, cfg.Limits.MaxNameLength, usage)
+	}},
+	{"room-idle-timeout", "ROOM_IDLE_TIMEOUT", "Close rooms without activity for this long (0 disables)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.DurationVar(&cfg.Rooms.IdleTimeout, name, cfg.Rooms.IdleTimeout, usage)
+	}},
+	{"room-max-age", "ROOM_MAX_AGE", "Close rooms this long after their creation (0 disables)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.DurationVar(&cfg.Rooms.MaxAge, name, cfg.Rooms.MaxAge, usage)
+	}},
+	{"room-expiry-warning", "ROOM_EXPIRY_WARNING", "Warn clients this long before their room expires (0 disables)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.DurationVar(&cfg.Rooms.ExpiryWarning, name, cfg.Rooms.ExpiryWarning, usage)
+	}},
+	{"room-janitor-interval", "ROOM_JANITOR_INTERVAL", "How often expired rooms are looked for", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.DurationVar(&cfg.Rooms.JanitorInterval, name, cfg.Rooms.JanitorInterval, usage)
+	}},
+	{"audit-sink", "AUDIT_SINK", "Audit log sink (stdout|file|none)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Storage.AuditSink, name, cfg.Storage.AuditSink, usage)
+	}},
+	{"audit-file", "AUDIT_FILE", "Audit log file when using the file sink", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Storage.AuditFile, name, cfg.Storage.AuditFile, usage)
+	}},
+	{"audit-max-size", "AUDIT_MAX_SIZE", "Audit log file size in bytes before it is rotated", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.Int64Var(&cfg.Storage.AuditMaxSize, name, cfg.Storage.AuditMaxSize, usage)
+	}},
+	{"audit-max-backups", "AUDIT_MAX_BACKUPS", "Number of rotated audit log files to keep", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.IntVar(&cfg.Storage.AuditMaxBackups, name, cfg.Storage.AuditMaxBackups, usage)
+	}},
+	{"archive", "ARCHIVE", "Archive for expired rooms (file|none)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Storage.Archive, name, cfg.Storage.Archive, usage)
+	}},
+	{"archive-file", "ARCHIVE_FILE", "File expired rooms are appended to when using the file archive", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Storage.ArchiveFile, name, cfg.Storage.ArchiveFile, usage)
+	}},
+	{"teams", "TEAMS", "Store for teams (file|memory)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Storage.Teams, name, cfg.Storage.Teams, usage)
+	}},
+	{"teams-file", "TEAMS_FILE", "File teams are kept in when using the file store", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Storage.TeamsFile, name, cfg.Storage.TeamsFile, usage)
+	}},
+	{"api-key-store", "API_KEY_STORE", "Store for api keys (file|memory)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Storage.APIKeyStore, name, cfg.Storage.APIKeyStore, usage)
+	}},
+	{"api-key-file", "API_KEY_FILE", "File the hashed api keys are kept in when using the file store", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Storage.APIKeyFile, name, cfg.Storage.APIKeyFile, usage)
+	}},
+	{"admin-token", "ADMIN_TOKEN", "Bearer token for operator endpoints (disabled when empty)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Auth.AdminToken, name, cfg.Auth.AdminToken, usage)
+	}},
+	{"token-secret", "TOKEN_SECRET", "Secret of at least 32 characters invite tokens and login sessions are signed with (random when empty)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Auth.TokenSecret, name, cfg.Auth.TokenSecret, usage)
+	}},
+	{"oidc-issuer", "OIDC_ISSUER", "OpenID Connect issuer URL users log in with (disabled when empty)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.OIDC.Issuer, name, cfg.OIDC.Issuer, usage)
+	}},
+	{"oidc-client-id", "OIDC_CLIENT_ID", "OpenID Connect client id", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.OIDC.ClientId, name, cfg.OIDC.ClientId, usage)
+	}},
+	{"oidc-client-secret", "OIDC_CLIENT_SECRET", "OpenID Connect client secret", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.OIDC.ClientSecret, name, cfg.OIDC.ClientSecret, usage)
+	}},
+	{"oidc-redirect-url", "OIDC_REDIRECT_URL", "Public URL of /v1/auth/callback registered with the provider", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.OIDC.RedirectURL, name, cfg.OIDC.RedirectURL, usage)
+	}},
+	{"oidc-scopes", "OIDC_SCOPES", "OpenID Connect scopes (space separated)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.Func(name, usage, func(val string) error {
+			cfg.OIDC.Scopes = strings.Fields(val)
+			return nil
+		})
+	}},
+	{"session-ttl", "SESSION_TTL", "How long a login stays valid", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.DurationVar(&cfg.OIDC.SessionTTL, name, cfg.OIDC.SessionTTL, usage)
+	}},
+	{"otel-endpoint", "OTEL_ENDPOINT", "OTLP/HTTP collector endpoint for traces, e.g. localhost:4318 (disabled when empty)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.StringVar(&cfg.Tracing.Endpoint, name, cfg.Tracing.Endpoint, usage)
+	}},
+	{"otel-insecure", "OTEL_INSECURE", "Export traces without TLS", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.BoolVar(&cfg.Tracing.Insecure, name, cfg.Tracing.Insecure, usage)
+	}},
+	{"cors-trusted-origins", "CORS_TRUSTED_ORIGINS", "Trusted CORS and websocket origins (space separated)", func(fs *flag.FlagSet, cfg *config, name, usage string) {
+		fs.Func(name, usage, func(val string) error {
+			cfg.CORS.TrustedOrigins = strings.Fields(val)
+			return nil
+		})
+	}},
+}
+
+// loadConfig resolves the configuration from defaults, the config file,
+// environment and args and validates the result.
+func loadConfig(args []string, lookupEnv func(string) (string, bool)) (config, error) {
+	cfg := defaultConfig()
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := fs.String("config", "", "Path to a TOML config file (env CONFIG_FILE)")
+	for _, s := range settings {
+		s.bind(fs, &cfg, s.flag, fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+
+	// the first pass only finds the config file, flags are applied again last
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	cfg = defaultConfig()
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return config{}, err
+		}
+	}
+
+	for _, s := range settings {
+		value, ok := lookupEnv(s.env)
+		if !ok {
+			continue
+		}
+		if err := fs.Set(s.flag, value); err != nil {
+			return config{}, fmt.Errorf("invalid value %q for env %s: %w", value, s.env, err)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+
+	return cfg, cfg.validate()
+}
+
+func (cfg *config) loadFile(path string) error {
+	if ext := filepath.Ext(path); ext != ".toml" {
+		return fmt.Errorf("unsupported config file format %q, expected .toml", ext)
+	}
+
+	meta, err := toml.DecodeFile(path, cfg)
+	if err != nil {
+		return fmt.Errorf("can not read config file %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return fmt.Errorf("config file %s contains unknown keys: %s", path, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// validate reports every invalid setting at once, so operators can fix their
+// configuration in one go.
+func (cfg config) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(cfg.Port > 0 && cfg.Port <= 65535, "port must be between 1 and 65535, got %d", cfg.Port)
+	check(slices.Contains([]string{"development", "test", "production"}, cfg.Env), "env must be one of development, test, production, got %q", cfg.Env)
+	_, err := cfg.logLevel()
+	check(err == nil, "log level must be one of debug, info, warn, error, got %q", cfg.LogLevel)
+
+	check(cfg.Timeouts.Idle > 0, "idle timeout must be greater than 0")
+	check(cfg.Timeouts.Read > 0, "read timeout must be greater than 0")
+	check(cfg.Timeouts.Write > 0, "write timeout must be greater than 0")
+	check(cfg.Timeouts.Shutdown > 0, "shutdown timeout must be greater than 0")
+	check(cfg.Timeouts.Drain >= 0, "drain timeout must not be negative")
+
+	if _, err := internal.NewGuessConfig(cfg.Deck.Guesses, cfg.Deck.Descriptions); err != nil {
+		errs = append(errs, fmt.Errorf("deck: %w", err))
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Decks)) {
+		check(name != defaultDeckName && validDeckName.MatchString(name), "deck name %q must consist of lowercase letters, digits and dashes and must not be %q", name, defaultDeckName)
+		deck := cfg.Decks[name]
+		if _, err := internal.NewGuessConfig(deck.Guesses, deck.Descriptions); err != nil {
+			errs = append(errs, fmt.Errorf("deck %s: %w", name, err))
+		}
+	}
+
+	if cfg.Limits.Enabled {
+		check(cfg.Limits.RPS > 0, "limiter rps must be greater than 0")
+		check(cfg.Limits.Burst > 0, "limiter burst must be greater than 0")
+		check(cfg.Limits.MessageRPS > 0, "limiter message rps must be greater than 0")
+		check(cfg.Limits.MessageBurst > 0, "limiter message burst must be greater than 0")
+	}
+	check(cfg.Limits.MaxNameLength > 0, "max name length must be greater than 0")
+
+	check(cfg.Auth.TokenSecret == "" || len(cfg.Auth.TokenSecret) >= 32, "token secret must be at least 32 characters")
+	if cfg.OIDC.enabled() {
+		issuer, err := url.Parse(cfg.OIDC.Issuer)
+		check(err == nil && issuer.IsAbs(), "oidc issuer must be an absolute url, got %q", cfg.OIDC.Issuer)
+		check(cfg.OIDC.ClientId != "", "oidc client id must be set when login is enabled")
+		redirect, err := url.Parse(cfg.OIDC.RedirectURL)
+		check(err == nil && redirect.IsAbs(), "oidc redirect url must be an absolute url, got %q", cfg.OIDC.RedirectURL)
+		check(slices.Contains(cfg.OIDC.Scopes, "openid"), "oidc scopes must contain openid")
+		check(cfg.OIDC.SessionTTL > 0, "session ttl must be greater than 0")
+	}
+
+	check(cfg.Rooms.IdleTimeout >= 0, "room idle timeout must not be negative")
+	check(cfg.Rooms.MaxAge >= 0, "room max age must not be negative")
+	check(cfg.Rooms.ExpiryWarning >= 0, "room expiry warning must not be negative")
+	check(cfg.Rooms.JanitorInterval > 0, "room janitor interval must be greater than 0")
+
+	check(slices.Contains([]string{"stdout", "file", "none"}, cfg.Storage.AuditSink), "audit sink must be one of stdout, file, none, got %q", cfg.Storage.AuditSink)
+	if cfg.Storage.AuditSink == "file" {
+		check(cfg.Storage.AuditFile != "", "audit file must be set when using the file sink")
+		check(cfg.Storage.AuditMaxSize > 0, "audit max size must be greater than 0")
+		check(cfg.Storage.AuditMaxBackups >= 0, "audit max backups must not be negative")
+	}
+
+	check(slices.Contains([]string{"file", "none"}, cfg.Storage.Archive), "archive must be one of file, none, got %q", cfg.Storage.Archive)
+	if cfg.Storage.Archive == "file" {
+		check(cfg.Storage.ArchiveFile != "", "archive file must be set when using the file archive")
+	}
+
+	check(slices.Contains([]string{"file", "memory"}, cfg.Storage.Teams), "teams must be one of file, memory, got %q", cfg.Storage.Teams)
+	if cfg.Storage.Teams == "file" {
+		check(cfg.Storage.TeamsFile != "", "teams file must be set when using the file store")
+	}
+
+	check(slices.Contains([]string{"file", "memory"}, cfg.Storage.APIKeyStore), "api key store must be one of file, memory, got %q", cfg.Storage.APIKeyStore)
+	if cfg.Storage.APIKeyStore == "file" {
+		check(cfg.Storage.APIKeyFile != "", "api key file must be set when using the file store")
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func envFrom(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	got, err := loadConfig(nil, envFrom(nil))
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, defaultConfig())
+}
+
+func TestLoadConfig_ExampleFileMatchesDefaults(t *testing.T) {
+	got, err := loadConfig([]string{"-config", "../../config.example.toml"}, envFrom(nil))
+
+	assert.NilError(t, err)
+	want := defaultConfig()
+	want.CORS.TrustedOrigins = []string{}
+	assert.DeepEqual(t, got, want)
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	path := writeConfigFile(t, `
+port = 9000
+env = "production"
+
+[timeouts]
+read = "3s"
+
+[limits]
+rps = 10.0
+burst = 20
+
+[cors]
+trusted_origins = ["https://poker.example.com"]
+`)
+
+	got, err := loadConfig(
+		[]string{"-config", path, "-limiter-burst", "30"},
+		envFrom(map[string]string{
+			"PORT":             "9100",
+			"LIMITER_BURST":    "25",
+			"POSSIBLE_GUESSES": "1,2",
+			// descriptions have to match the amount of guesses
+			"POSSIBLE_GUESSES_DESC": "A,B",
+		}),
+	)
+
+	assert.NilError(t, err)
+	assert.Equal(t, got.Port, 9100)
+	assert.Equal(t, got.Env, "production")
+	assert.Equal(t, got.Timeouts.Read, 3*time.Second)
+	assert.Equal(t, got.Timeouts.Write, 10*time.Second)
+	assert.Equal(t, got.Limits.RPS, 10.0)
+	assert.Equal(t, got.Limits.Burst, 30)
+	assert.Equal(t, got.Deck.Guesses, "1,2")
+	assert.DeepEqual(t, got.CORS.TrustedOrigins, []string{"https://poker.example.com"})
+}
+
+func TestLoadConfig_ConfigFileFromEnv(t *testing.T) {
+	path := writeConfigFile(t, `port = 9000`)
+
+	got, err := loadConfig(nil, envFrom(map[string]string{"CONFIG_FILE": path}))
+
+	assert.NilError(t, err)
+	assert.Equal(t, got.Port, 9000)
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown key in file",
+			content: "prot = 9000",
+			wantErr: "contains unknown keys: prot",
+		},
+		{
+			name:    "invalid env value",
+			env:     map[string]string{"PORT": "abc"},
+			wantErr: `invalid value "abc" for env PORT`,
+		},
+		{
+			name:    "validation reports every problem",
+			args:    []string{"-port", "0", "-env", "staging", "-audit-sink", "kafka"},
+			wantErr: "port must be between 1 and 65535, got 0\nenv must be one of development, test, production, got \"staging\"\naudit sink must be one of stdout, file, none, got \"kafka\"",
+		},
+		{
+			name:    "invalid deck",
+			env:     map[string]string{"POSSIBLE_GUESSES": "1,2,P,4,5"},
+			wantErr: "deck: error can not convert guess P to a non-negative number or special card",
+		},
+		{
+			name:    "invalid deck preset",
+			content: "[decks.broken]\nguesses = \"1,2\"\ndescriptions = \"A\"",
+			wantErr: "deck broken: error length for guesses and guesses desc is differrent (guesses = 2, guesses desc = 1)",
+		},
+		{
+			name:    "invalid deck preset name",
+			content: "[decks.Broken_Name]\nguesses = \"1\"\ndescriptions = \"A\"",
+			wantErr: `deck name "Broken_Name" must consist of lowercase letters, digits and dashes`,
+		},
+		{
+			name:    "limiter values",
+			args:    []string{"-limiter-rps", "0"},
+			wantErr: "limiter rps must be greater than 0",
+		},
+		{
+			name:    "drain timeout",
+			env:     map[string]string{"DRAIN_TIMEOUT": "-1s"},
+			wantErr: "drain timeout must not be negative",
+		},
+		{
+			name:    "room limits",
+			args:    []string{"-room-max-age", "-1h", "-room-janitor-interval", "0s"},
+			wantErr: "room max age must not be negative\nroom janitor interval must be greater than 0",
+		},
+		{
+			name:    "short token secret",
+			env:     map[string]string{"TOKEN_SECRET": "secret"},
+			wantErr: "token secret must be at least 32 characters",
+		},
+		{
+			name:    "oidc",
+			args:    []string{"-oidc-issuer", "accounts.example.com", "-oidc-scopes", "profile email"},
+			wantErr: "oidc issuer must be an absolute url, got \"accounts.example.com\"\noidc client id must be set when login is enabled\noidc redirect url must be an absolute url, got \"\"\noidc scopes must contain openid",
+		},
+		{
+			name:    "archive",
+			args:    []string{"-archive", "s3"},
+			wantErr: `archive must be one of file, none, got "s3"`,
+		},
+		{
+			name:    "teams",
+			args:    []string{"-teams", "postgres"},
+			wantErr: `teams must be one of file, memory, got "postgres"`,
+		},
+		{
+			name:    "api key store",
+			args:    []string{"-api-key-store", "vault"},
+			wantErr: `api key store must be one of file, memory, got "vault"`,
+		},
+		{
+			name:    "unsupported file format",
+			args:    []string{"-config", "config.yaml"},
+			wantErr: `unsupported config file format ".yaml", expected .toml`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.content != "" {
+				args = append(args, "-config", writeConfigFile(t, tt.content))
+			}
+
+			_, err := loadConfig(args, envFrom(tt.env))
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			assert.StringContains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+type contextKey string
+
+const (
+	requestInfoContextKey = contextKey("requestInfo")
+	apiKeyContextKey      = contextKey("apiKey")
+)
+
+// requestInfo is shared between the logging middleware and the route
+// handlers, so the handlers can fill in details the middleware logs later on.
+type requestInfo struct {
+	id    string
+	route string
+}
+
+func (app *application) contextSetRequestInfo(request *http.Request, info *requestInfo) *http.Request {
+	ctx := context.WithValue(request.Context(), requestInfoContextKey, info)
+	return request.WithContext(ctx)
+}
+
+func (app *application) contextGetRequestInfo(request *http.Request) *requestInfo {
+	info, ok := request.Context().Value(requestInfoContextKey).(*requestInfo)
+	if !ok {
+		return &requestInfo{}
+	}
+	return info
+}
+
+func (app *application) contextSetAPIKey(request *http.Request, key *internal.APIKey) *http.Request {
+	ctx := context.WithValue(request.Context(), apiKeyContextKey, key)
+	return request.WithContext(ctx)
+}
+
+// contextGetAPIKey returns the api key the request authenticated with or nil
+// if it did not send one.
+func (app *application) contextGetAPIKey(request *http.Request) *internal.APIKey {
+	key, _ := request.Context().Value(apiKeyContextKey).(*internal.APIKey)
+	return key
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+// defaultDeckName addresses the deck of the [deck] section, which is used for
+// rooms created without choosing a deck.
+const defaultDeckName = "default"
+
+func newDecks(cfg config) (map[string]*internal.GuessConfig, error) {
+	decks := make(map[string]*internal.GuessConfig, len(cfg.Decks)+1)
+
+	all := maps.Clone(cfg.Decks)
+	if all == nil {
+		all = make(map[string]deckConfig, 1)
+	}
+	all[defaultDeckName] = cfg.Deck
+
+	for name, deck := range all {
+		guessConfig, err := internal.NewGuessConfig(deck.Guesses, deck.Descriptions)
+		if err != nil {
+			return nil, fmt.Errorf("deck %s: %w", name, err)
+		}
+		guessConfig.Name = name
+		guessConfig.Label = deck.Label
+		decks[name] = guessConfig
+	}
+
+	return decks, nil
+}
+
+func (app *application) handleFetchDecks(writer http.ResponseWriter, request *http.Request) {
+	app.mu.RLock()
+	decks := make([]*internal.GuessConfig, 0, len(app.decks))
+	for _, name := range slices.Sorted(maps.Keys(app.decks)) {
+		decks = append(decks, app.decks[name])
+	}
+	app.mu.RUnlock()
+
+	err := app.writeJSON(writer, http.StatusOK, envelope{"decks": decks, "default": defaultDeckName}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
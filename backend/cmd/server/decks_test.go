@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestNewDecks(t *testing.T) {
+	cfg := defaultConfig()
+
+	got, err := newDecks(cfg)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(got), len(cfg.Decks)+1)
+	assert.Equal(t, got[defaultDeckName].Name, defaultDeckName)
+	assert.Equal(t, got[defaultDeckName].Label, "Default")
+	assert.Equal(t, got["t-shirt"].Label, "T-shirt sizes")
+	assert.Equal(t, got["t-shirt"].Guesses[0].Id, "1")
+	assert.Equal(t, got["t-shirt"].Guesses[0].Label, "XS")
+}
+
+func TestApplication_handleFetchDecks(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.decks = map[string]*internal.GuessConfig{
+		"fibonacci": {
+			Name:    "fibonacci",
+			Label:   "Fibonacci",
+			Guesses: []internal.GuessConfigEntry{{Guess: 1, Description: "1"}},
+		},
+		defaultDeckName: {
+			Name:    defaultDeckName,
+			Label:   "Default",
+			Guesses: []internal.GuessConfigEntry{{Guess: 1, Description: "Up to 4h"}},
+		},
+	}
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	response := ts.get(t, "/v1/decks")
+
+	var got struct {
+		Decks   []*internal.GuessConfig `json:"decks"`
+		Default string                  `json:"default"`
+	}
+	json.Unmarshal(response.body, &got)
+
+	assert.Equal(t, response.status, http.StatusOK)
+	assert.Equal(t, got.Default, defaultDeckName)
+	assert.DeepEqual(t, got.Decks, []*internal.GuessConfig{app.decks[defaultDeckName], app.decks["fibonacci"]})
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestApplication_roomDetails(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	created := ts.postJSON(t, "/v1/room", map[string]any{
+		"creator":     "Tester",
+		"title":       "Sprint 42",
+		"description": "Planning for the next sprint",
+		"team":        "Rocket",
+		"tags":        []string{"Backend", "q3"},
+	})
+	assert.Equal(t, created.status, http.StatusCreated)
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+	other := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Other", "title": "Retro"})
+	assert.Equal(t, other.status, http.StatusCreated)
+
+	t.Run("metadata", func(t *testing.T) {
+		response := ts.get(t, "/v1/room/"+room["id"]+"/metadata")
+
+		var got envelope
+		json.Unmarshal(response.body, &got)
+		assert.DeepEqual(t, got, envelope{
+			"exists":      true,
+			"isLocked":    false,
+			"visibility":  "public",
+			"title":       "Sprint 42",
+			"description": "Planning for the next sprint",
+			"team":        "Rocket",
+			"tags":        []any{"backend", "q3"},
+		})
+	})
+
+	t.Run("state", func(t *testing.T) {
+		response := ts.get(t, "/v1/room/"+room["id"]+"/state")
+
+		var got internal.State
+		json.Unmarshal(response.body, &got)
+		assert.Equal(t, got.Title, "Sprint 42")
+		assert.DeepEqual(t, got.Tags, []string{"backend", "q3"})
+	})
+
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{query: "", want: []string{"Sprint 42", "Retro"}},
+		{query: "?tag=backend", want: []string{"Sprint 42"}},
+		{query: "?q=retro", want: []string{"Retro"}},
+		{query: "?q=rocket&tag=q3", want: []string{"Sprint 42"}},
+		{query: "?tag=frontend", want: []string{}},
+	} {
+		t.Run("filter "+tt.query, func(t *testing.T) {
+			response := ts.get(t, "/v1/rooms"+tt.query)
+
+			var got struct {
+				Rooms []internal.Overview `json:"rooms"`
+			}
+			json.Unmarshal(response.body, &got)
+			titles := []string{}
+			for _, overview := range got.Rooms {
+				titles = append(titles, overview.Title)
+			}
+			assert.DeepEqual(t, titles, tt.want)
+		})
+	}
+}
+
+func TestApplication_handleUpdateRoomDetails(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	created := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester", "title": "Sprint 42", "team": "Rocket"})
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+
+	connection, ownerToken := ts.dialOwner(t, room["id"], "Tester")
+	defer connection.CloseNow()
+
+	tests := []struct {
+		name       string
+		key        string
+		body       map[string]any
+		wantStatus int
+		wantRoom   internal.RoomDetails
+	}{
+		{
+			name:       "missing owner token",
+			body:       map[string]any{"title": "Hijacked"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid details",
+			key:        ownerToken,
+			body:       map[string]any{"tags": []string{"not valid"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "partial update",
+			key:        ownerToken,
+			body:       map[string]any{"title": "Sprint 43", "tags": []string{"Backend"}},
+			wantStatus: http.StatusOK,
+			wantRoom:   internal.RoomDetails{Title: "Sprint 43", Team: "Rocket", Tags: []string{"backend"}},
+		},
+		{
+			name:       "clear a field",
+			key:        ownerToken,
+			body:       map[string]any{"team": ""},
+			wantStatus: http.StatusOK,
+			wantRoom:   internal.RoomDetails{Title: "Sprint 43", Tags: []string{"backend"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := ts.doJSON(t, http.MethodPatch, "/v1/room/"+room["code"], tt.body, http.Header{"Authorization": {"Bearer " + tt.key}})
+
+			assert.Equal(t, response.status, tt.wantStatus)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got struct {
+				Room internal.Overview `json:"room"`
+			}
+			json.Unmarshal(response.body, &got)
+			assert.DeepEqual(t, got.Room.RoomDetails, tt.wantRoom)
+		})
+	}
+}
@@ -1,17 +1,25 @@
 package main
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+)
 
 func (app *application) logError(request *http.Request, err error) {
 	var (
 		method = request.Method
-		uri    = request.URL.RequestURI()
+		uri    = loggableURI(request.URL)
 	)
-	app.logger.Error(err.Error(), "method", method, "uri", uri)
+	app.logger.Error(err.Error(), "requestId", app.contextGetRequestInfo(request).id, "method", method, "uri", uri)
 }
 
 func (app *application) errorResponse(writer http.ResponseWriter, request *http.Request, status int, message any) {
-	err := app.writeJSON(writer, status, envelope{"error": message}, nil)
+	data := envelope{"error": message}
+	if id := app.contextGetRequestInfo(request).id; id != "" {
+		data["requestId"] = id
+	}
+
+	err := app.writeJSON(writer, status, data, nil)
 	if err != nil {
 		app.logError(request, err)
 		writer.WriteHeader(500)
@@ -32,3 +40,31 @@ func (app *application) notFoundResponse(writer http.ResponseWriter, request *ht
 func (app *application) badRequestResponse(writer http.ResponseWriter, request *http.Request, err error) {
 	app.errorResponse(writer, request, http.StatusBadRequest, err.Error())
 }
+
+func (app *application) conflictResponse(writer http.ResponseWriter, request *http.Request, err error) {
+	app.errorResponse(writer, request, http.StatusConflict, err.Error())
+}
+
+func (app *application) unauthorizedResponse(writer http.ResponseWriter, request *http.Request) {
+	message := "invalid or missing authentication token"
+	app.errorResponse(writer, request, http.StatusUnauthorized, message)
+}
+
+func (app *application) forbiddenResponse(writer http.ResponseWriter, request *http.Request, err error) {
+	app.errorResponse(writer, request, http.StatusForbidden, err.Error())
+}
+
+func (app *application) missingScopeResponse(writer http.ResponseWriter, request *http.Request, scope string) {
+	message := fmt.Sprintf("api key lacks the %s scope", scope)
+	app.errorResponse(writer, request, http.StatusForbidden, message)
+}
+
+func (app *application) readOnlyResponse(writer http.ResponseWriter, request *http.Request) {
+	message := "room creation is disabled for maintenance"
+	app.errorResponse(writer, request, http.StatusServiceUnavailable, message)
+}
+
+func (app *application) rateLimitExceededResponse(writer http.ResponseWriter, request *http.Request) {
+	message := "rate limit exceeded"
+	app.errorResponse(writer, request, http.StatusTooManyRequests, message)
+}
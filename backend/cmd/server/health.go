@@ -1,14 +1,135 @@
 package main
 
-import "net/http"
+import (
+	"context"
+	"net"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+const readinessTimeout = 2 * time.Second
+
+type readinessCheck func(ctx context.Context) error
+
+// checker is implemented by the stores which can fail after startup, like
+// the file based ones once their directory becomes read only.
+type checker interface {
+	Check(ctx context.Context) error
+}
+
+// registerReadinessChecks adds a check for every store that can report its
+// health. The trace collector is left out, an outage of telemetry must not
+// take the server out of the load balancer, healthDetailsHandler reports it.
+func (app *application) registerReadinessChecks() {
+	stores := map[string]any{
+		"teams":    app.teams,
+		"api-keys": app.apiKeys,
+		"audit":    app.auditSink,
+		"archive":  app.archive,
+	}
+	for name, store := range stores {
+		if store, ok := store.(checker); ok {
+			app.readinessChecks[name] = store.Check
+		}
+	}
+}
+
+// tracingStatus tells whether the trace collector is reachable, or "" when
+// tracing is disabled.
+func (app *application) tracingStatus(ctx context.Context) string {
+	endpoint := app.config.Tracing.Endpoint
+	if endpoint == "" {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return err.Error()
+	}
+	conn.Close()
+	return "ok"
+}
 
 func (app *application) healthcheckHandler(writer http.ResponseWriter, request *http.Request) {
 	data := envelope{
 		"status": "available",
 		"systemInfo": map[string]string{
-			"environment": app.config.env,
+			"environment": app.config.Env,
+			"version":     version,
+		},
+	}
+
+	err := app.writeJSON(writer, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) livenessHandler(writer http.ResponseWriter, request *http.Request) {
+	err := app.writeJSON(writer, http.StatusOK, envelope{"status": "alive"}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) readinessHandler(writer http.ResponseWriter, request *http.Request) {
+	if app.draining.Load() {
+		err := app.writeJSON(writer, http.StatusServiceUnavailable, envelope{"status": "draining", "checks": map[string]string{}}, nil)
+		if err != nil {
+			app.serverErrorResponse(writer, request, err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(request.Context(), readinessTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	checks := make(map[string]string, len(app.readinessChecks))
+	for name, check := range app.readinessChecks {
+		if err := check(ctx); err != nil {
+			app.logger.Warn("readiness check failed", "check", name, "error", err)
+			checks[name] = err.Error()
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		checks[name] = "ok"
+	}
+
+	data := envelope{"status": "ready", "checks": checks}
+	if status != http.StatusOK {
+		data["status"] = "unavailable"
+	}
+
+	err := app.writeJSON(writer, status, data, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) healthDetailsHandler(writer http.ResponseWriter, request *http.Request) {
+	app.mu.RLock()
+	roomCount := len(app.rooms)
+	app.mu.RUnlock()
+
+	data := envelope{
+		"status": "available",
+		"systemInfo": map[string]string{
+			"environment": app.config.Env,
 			"version":     version,
 		},
+		"uptime":     time.Since(app.started).Round(time.Second).String(),
+		"goroutines": runtime.NumGoroutine(),
+		"rooms":      roomCount,
+		"build":      buildInfo(),
+	}
+	if status := app.tracingStatus(request.Context()); status != "" {
+		data["tracing"] = status
 	}
 
 	err := app.writeJSON(writer, http.StatusOK, data, nil)
@@ -16,3 +137,23 @@ func (app *application) healthcheckHandler(writer http.ResponseWriter, request *
 		app.serverErrorResponse(writer, request, err)
 	}
 }
+
+func buildInfo() map[string]string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return map[string]string{}
+	}
+
+	out := map[string]string{
+		"goVersion": info.GoVersion,
+		"path":      info.Path,
+		"module":    info.Main.Version,
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision", "vcs.time", "vcs.modified", "GOOS", "GOARCH":
+			out[setting.Key] = setting.Value
+		}
+	}
+	return out
+}
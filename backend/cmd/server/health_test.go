@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/uuid"
@@ -31,3 +36,182 @@ func TestApplication_healthcheckHandler(t *testing.T) {
 	assert.Equal(t, http.StatusOK, response.status)
 	assert.DeepEqual(t, got, want)
 }
+
+func TestApplication_livenessHandler(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	response := ts.get(t, "/v1/health/live")
+
+	var got envelope
+	json.Unmarshal(response.body, &got)
+
+	assert.Equal(t, http.StatusOK, response.status)
+	assert.DeepEqual(t, got, envelope{"status": "alive"})
+}
+
+func TestApplication_readinessHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		draining   bool
+		checks     map[string]readinessCheck
+		wantStatus int
+		want       envelope
+	}{
+		{
+			name:       "ready without checks",
+			checks:     map[string]readinessCheck{},
+			wantStatus: http.StatusOK,
+			want:       envelope{"status": "ready", "checks": map[string]any{}},
+		},
+		{
+			name: "ready when every check passes",
+			checks: map[string]readinessCheck{
+				"store": func(ctx context.Context) error { return nil },
+			},
+			wantStatus: http.StatusOK,
+			want:       envelope{"status": "ready", "checks": map[string]any{"store": "ok"}},
+		},
+		{
+			name: "unavailable when a check fails",
+			checks: map[string]readinessCheck{
+				"store":  func(ctx context.Context) error { return nil },
+				"pubsub": func(ctx context.Context) error { return errors.New("connection refused") },
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			want:       envelope{"status": "unavailable", "checks": map[string]any{"store": "ok", "pubsub": "connection refused"}},
+		},
+		{
+			name:     "unavailable while draining",
+			draining: true,
+			checks: map[string]readinessCheck{
+				"store": func(ctx context.Context) error { return nil },
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			want:       envelope{"status": "draining", "checks": map[string]any{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+			app.readinessChecks = tt.checks
+			app.draining.Store(tt.draining)
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			response := ts.get(t, "/v1/health/ready")
+
+			var got envelope
+			json.Unmarshal(response.body, &got)
+
+			assert.Equal(t, response.status, tt.wantStatus)
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestApplication_healthDetailsHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminToken string
+		header     string
+		wantStatus int
+	}{
+		{
+			name:       "authorized",
+			adminToken: "secret",
+			header:     "Bearer secret",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "wrong token",
+			adminToken: "secret",
+			header:     "Bearer wrong",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing header",
+			adminToken: "secret",
+			header:     "",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "disabled when no token is configured",
+			adminToken: "",
+			header:     "Bearer ",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, map[uuid.UUID]*internal.Room{
+				uuid.New(): {},
+				uuid.New(): {},
+			})
+			app.config.Auth.AdminToken = tt.adminToken
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			response := ts.getWithHeaders(t, "/v1/health/details", http.Header{"Authorization": {tt.header}})
+
+			assert.Equal(t, response.status, tt.wantStatus)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got struct {
+				Rooms      int               `json:"rooms"`
+				Goroutines int               `json:"goroutines"`
+				Uptime     string            `json:"uptime"`
+				Build      map[string]string `json:"build"`
+			}
+			json.Unmarshal(response.body, &got)
+
+			assert.Equal(t, got.Rooms, 2)
+			assert.True(t, got.Goroutines > 0)
+			assert.True(t, got.Uptime != "")
+			assert.True(t, got.Build["goVersion"] != "")
+		})
+	}
+}
+
+func TestApplication_registerReadinessChecks(t *testing.T) {
+	dir := t.TempDir()
+	teams, err := internal.OpenFileTeamStore(filepath.Join(dir, "teams.json"))
+	assert.NilError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	endpoint := listener.Addr().String()
+	listener.Close()
+
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.teams = teams
+	app.config.Tracing.Endpoint = endpoint
+	app.config.Auth.AdminToken = "secret"
+	app.registerReadinessChecks()
+	assert.NilError(t, os.RemoveAll(dir))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	response := ts.get(t, "/v1/health/ready")
+	details := ts.getWithHeaders(t, "/v1/health/details", http.Header{"Authorization": {"Bearer secret"}})
+
+	var got struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	assert.NilError(t, json.Unmarshal(response.body, &got))
+	assert.Equal(t, response.status, http.StatusServiceUnavailable)
+	assert.Equal(t, got.Status, "unavailable")
+	assert.Equal(t, len(got.Checks), 1)
+	assert.StringContains(t, got.Checks["teams"], "no such file or directory")
+	var detailed struct {
+		Tracing string `json:"tracing"`
+	}
+	assert.NilError(t, json.Unmarshal(details.body, &detailed))
+	assert.Equal(t, details.status, http.StatusOK)
+	assert.StringContains(t, detailed.Tracing, "connection refused")
+}
@@ -17,14 +17,25 @@ type envelope map[string]any
 
 func (app *application) readIdParam(request *http.Request) (uuid.UUID, error) {
 	params := httprouter.ParamsFromContext(request.Context())
+	param := params.ByName("id")
 
-	id, err := uuid.Parse(params.ByName("id"))
-	if err != nil {
+	if id, err := uuid.Parse(param); err == nil {
+		return id, nil
+	}
+
+	// join codes and slugs are only known while their room exists
+	id, ok := app.aliases.resolve(param)
+	if !ok {
 		return uuid.Nil, errors.New("invalid id parameter")
 	}
 	return id, nil
 }
 
+func (app *application) readBearerToken(request *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+	return token, ok && token != ""
+}
+
 func (app *application) writeJSON(writer http.ResponseWriter, status int, data any, headers http.Header) error {
 	jsonResponse, err := json.MarshalIndent(data, "", "\t")
 	if err != nil {
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+const (
+	defaultInviteTTL = 24 * time.Hour
+	maxInviteTTL     = 30 * 24 * time.Hour
+)
+
+// createInviteInput takes the lifetime of the invite as duration like "2h".
+type createInviteInput struct {
+	Role      string `json:"role"`
+	ExpiresIn string `json:"expiresIn"`
+	MaxUses   int    `json:"maxUses"`
+}
+
+func (app *application) handleCreateInvite(writer http.ResponseWriter, request *http.Request) {
+	actualRoom, ok := app.readOwnedRoom(writer, request, internal.ScopeCreateRooms)
+	if !ok {
+		return
+	}
+
+	var input createInviteInput
+	err := app.readJSON(writer, request, &input)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	if input.Role == "" {
+		input.Role = internal.Developer
+	}
+	ttl := defaultInviteTTL
+	if input.ExpiresIn != "" {
+		ttl, err = time.ParseDuration(input.ExpiresIn)
+		if err != nil {
+			ttl = 0
+		}
+	}
+
+	var errs []error
+	if input.Role != internal.Developer && input.Role != internal.ProductOwner {
+		errs = append(errs, fmt.Errorf("role must be one of %s, %s, got %q", internal.Developer, internal.ProductOwner, input.Role))
+	}
+	if ttl <= 0 || ttl > maxInviteTTL {
+		errs = append(errs, fmt.Errorf("expiresIn must be a duration between 1s and %s", maxInviteTTL))
+	}
+	if input.MaxUses < 0 {
+		errs = append(errs, errors.New("maxUses must not be negative"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	invite := actualRoom.NewInvite(input.Role, ttl, input.MaxUses)
+	data := envelope{"token": app.tokens.SignInvite(invite), "invite": invite}
+	err = app.writeJSON(writer, http.StatusCreated, data, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+// readInvite verifies the invite token if one was sent. Without a token it
+// returns nil and no error.
+func (app *application) readInvite(token string) (*internal.Invite, error) {
+	if token == "" {
+		return nil, nil
+	}
+	invite, err := app.tokens.VerifyInvite(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestApplication_handleCreateInvite(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	created := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester"})
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+	connection, ownerToken := ts.dialOwner(t, room["id"], "Tester")
+	defer connection.CloseNow()
+
+	tests := []struct {
+		name       string
+		key        string
+		body       map[string]any
+		wantStatus int
+		wantErr    string
+	}{
+		{
+			name:       "missing owner token",
+			body:       map[string]any{},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    "invalid or missing authentication token",
+		},
+		{
+			name:       "invalid input",
+			key:        ownerToken,
+			body:       map[string]any{"role": "admin", "expiresIn": "90d", "maxUses": -1},
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "role must be one of developer, product-owner, got \"admin\"\nexpiresIn must be a duration between 1s and 720h0m0s\nmaxUses must not be negative",
+		},
+		{
+			name:       "defaults",
+			key:        ownerToken,
+			body:       map[string]any{},
+			wantStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := ts.doJSON(t, http.MethodPost, "/v1/room/"+room["id"]+"/invites", tt.body, http.Header{"Authorization": {"Bearer " + tt.key}})
+
+			assert.Equal(t, response.status, tt.wantStatus)
+			var got struct {
+				Error  string          `json:"error"`
+				Token  string          `json:"token"`
+				Invite internal.Invite `json:"invite"`
+			}
+			json.Unmarshal(response.body, &got)
+			assert.Equal(t, got.Error, tt.wantErr)
+			if tt.wantStatus == http.StatusCreated {
+				assert.Equal(t, got.Invite.Role, internal.Developer)
+				assert.Equal(t, got.Invite.MaxUses, 0)
+				assert.True(t, got.Token != "")
+			}
+		})
+	}
+}
+
+func TestApplication_joinWithInvite(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	created := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester", "visibility": "private"})
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+	connection, ownerToken := ts.dialOwner(t, room["id"], "Tester")
+	defer connection.CloseNow()
+
+	mint := func(body map[string]any) string {
+		response := ts.doJSON(t, http.MethodPost, "/v1/room/"+room["id"]+"/invites", body, http.Header{"Authorization": {"Bearer " + ownerToken}})
+		var got struct {
+			Token string `json:"token"`
+		}
+		json.Unmarshal(response.body, &got)
+		return got.Token
+	}
+	dial := func(role, name, token string) int {
+		query := url.Values{"name": {name}, "invite": {token}}
+		connection, response, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http")+"/v1/room/"+room["id"]+"/"+role+"?"+query.Encode(), nil)
+		if err == nil {
+			connection.CloseNow()
+		}
+		return response.StatusCode
+	}
+	once := mint(map[string]any{"maxUses": 1})
+
+	t.Run("connection state", func(t *testing.T) {
+		for _, tt := range []struct {
+			invite string
+			want   internal.ConnectionState
+		}{
+			{invite: "", want: internal.ConnectionState{Reason: "room is private"}},
+			{invite: "forged.token", want: internal.ConnectionState{Reason: "invite is invalid"}},
+			{invite: once, want: internal.ConnectionState{CanConnect: true, Role: internal.Developer}},
+		} {
+			response := ts.postJSON(t, "/v1/room/"+room["id"]+"/connection-state", map[string]string{"username": "Dev", "invite": tt.invite})
+
+			var got internal.ConnectionState
+			json.Unmarshal(response.body, &got)
+			assert.Equal(t, got, tt.want)
+		}
+	})
+
+	t.Run("websocket", func(t *testing.T) {
+		assert.Equal(t, dial("product-owner", "Dev", once), http.StatusForbidden)
+		assert.Equal(t, dial("developer", "Dev", once), http.StatusSwitchingProtocols)
+		assert.Equal(t, dial("developer", "Other", once), http.StatusForbidden)
+		assert.Equal(t, dial("developer", "Other", ""), http.StatusForbidden)
+		assert.Equal(t, dial("product-owner", "Other", mint(map[string]any{"role": "product-owner"})), http.StatusSwitchingProtocols)
+	})
+}
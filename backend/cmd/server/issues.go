@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+const (
+	maxImportedIssues   = 100
+	maxIssueTitleLength = 200
+)
+
+type importIssuesInput struct {
+	Issues []string `json:"issues"`
+}
+
+func (app *application) handleImportIssues(writer http.ResponseWriter, request *http.Request) {
+	actualRoom, ok := app.readOwnedRoom(writer, request, internal.ScopeImportIssues)
+	if !ok {
+		return
+	}
+
+	var input importIssuesInput
+	err := app.readJSON(writer, request, &input)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	if len(input.Issues) == 0 || len(input.Issues) > maxImportedIssues {
+		app.badRequestResponse(writer, request, fmt.Errorf("issues must contain 1 to %d titles", maxImportedIssues))
+		return
+	}
+	var errs []error
+	for i, title := range input.Issues {
+		input.Issues[i] = strings.TrimSpace(title)
+		if input.Issues[i] == "" || utf8.RuneCountInString(input.Issues[i]) > maxIssueTitleLength {
+			errs = append(errs, fmt.Errorf("issue %d must have a title of 1 to %d characters", i+1, maxIssueTitleLength))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	actor := actualRoom.NameOfCreator
+	if key := app.contextGetAPIKey(request); key != nil {
+		actor = key.Name
+	}
+	actualRoom.ImportIssues(actor, input.Issues)
+
+	err = app.writeJSON(writer, http.StatusCreated, envelope{"imported": len(input.Issues)}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
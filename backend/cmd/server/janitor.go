@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+// runJanitor periodically closes rooms which were idle or open for too long.
+func (app *application) runJanitor(ctx context.Context) {
+	interval := app.currentConfig().Rooms.JanitorInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			app.expireRooms(now)
+			if next := app.currentConfig().Rooms.JanitorInterval; next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
+		}
+	}
+}
+
+func (app *application) expireRooms(now time.Time) {
+	cfg := app.currentConfig().Rooms
+
+	app.mu.RLock()
+	rooms := slices.Collect(maps.Values(app.rooms))
+	app.mu.RUnlock()
+
+	for _, room := range rooms {
+		expiresAt, reason := roomExpiry(room, cfg)
+		switch {
+		case expiresAt.IsZero():
+		case !now.Before(expiresAt):
+			app.closeRoom(room, reason)
+		case cfg.ExpiryWarning > 0 && !now.Before(expiresAt.Add(-cfg.ExpiryWarning)):
+			room.WarnExpiry(expiresAt, reason)
+		}
+	}
+}
+
+// roomExpiry returns when and why room expires next, or a zero time if no
+// limit is configured.
+func roomExpiry(room *internal.Room, cfg roomsConfig) (time.Time, string) {
+	var (
+		expiresAt time.Time
+		reason    string
+	)
+	if cfg.IdleTimeout > 0 {
+		expiresAt, reason = room.LastActivity().Add(cfg.IdleTimeout), internal.ExpiryIdle
+	}
+	if cfg.MaxAge > 0 {
+		maxAge := room.Created.Add(cfg.MaxAge)
+		if expiresAt.IsZero() || maxAge.Before(expiresAt) {
+			expiresAt, reason = maxAge, internal.ExpiryMaxAge
+		}
+	}
+	return expiresAt, reason
+}
+
+func (app *application) closeRoom(room *internal.Room, reason string) {
+	app.mu.Lock()
+	delete(app.rooms, room.Id)
+	app.mu.Unlock()
+	app.aliases.removeRoom(room.Id)
+
+	if app.archive != nil {
+		if err := app.archive.Archive(room.Snapshot(reason)); err != nil {
+			app.logger.Error("failed to archive room", "room", room.Id, "error", err)
+		}
+	}
+	room.Close(reason)
+	app.logger.Info("closed room", "room", room.Id, "reason", reason)
+}
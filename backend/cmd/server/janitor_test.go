@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestRoomExpiry(t *testing.T) {
+	room := internal.NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(internal.GuessConfig), nil)
+	tests := []struct {
+		name       string
+		cfg        roomsConfig
+		wantAt     time.Time
+		wantReason string
+	}{
+		{
+			name: "no limits",
+		},
+		{
+			name:       "idle timeout",
+			cfg:        roomsConfig{IdleTimeout: time.Hour},
+			wantAt:     room.LastActivity().Add(time.Hour),
+			wantReason: internal.ExpiryIdle,
+		},
+		{
+			name:       "max age before idle timeout",
+			cfg:        roomsConfig{IdleTimeout: time.Hour, MaxAge: time.Minute},
+			wantAt:     room.Created.Add(time.Minute),
+			wantReason: internal.ExpiryMaxAge,
+		},
+		{
+			name:       "idle timeout before max age",
+			cfg:        roomsConfig{IdleTimeout: time.Minute, MaxAge: time.Hour},
+			wantAt:     room.LastActivity().Add(time.Minute),
+			wantReason: internal.ExpiryIdle,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAt, gotReason := roomExpiry(room, tt.cfg)
+
+			assert.Equal(t, gotAt, tt.wantAt)
+			assert.Equal(t, gotReason, tt.wantReason)
+		})
+	}
+}
+
+func TestApplication_expireRooms(t *testing.T) {
+	var archived bytes.Buffer
+	destroy := make(chan uuid.UUID)
+	logger := slog.New(slog.DiscardHandler)
+	expired := internal.NewRoom(uuid.New(), destroy, "Tester", logger, new(internal.GuessConfig), nil)
+	fresh := internal.NewRoom(uuid.New(), destroy, "Tester", logger, new(internal.GuessConfig), nil)
+	fresh.Created = fresh.Created.Add(2 * time.Hour)
+	go expired.Run()
+	go fresh.Run()
+	app := newTestApplication(t, map[uuid.UUID]*internal.Room{
+		expired.Id: expired,
+		fresh.Id:   fresh,
+	})
+	app.config.Rooms = roomsConfig{MaxAge: time.Hour}
+	app.archive = internal.NewJSONRoomArchive(&archived)
+
+	app.expireRooms(time.Now().Add(90 * time.Minute))
+
+	var snapshot internal.RoomSnapshot
+	assert.NilError(t, json.Unmarshal(archived.Bytes(), &snapshot))
+	assert.Equal(t, snapshot.Id, expired.Id)
+	assert.Equal(t, snapshot.Reason, internal.ExpiryMaxAge)
+	assert.Equal(t, len(app.rooms), 1)
+	_, ok := app.rooms[fresh.Id]
+	assert.True(t, ok)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var roomSorts = []string{"created", "-created", "players", "-players", "title", "-title"}
+
+// roomListing is a room as it was when the listing snapshot was taken.
+type roomListing struct {
+	overview   internal.Overview
+	inProgress bool
+}
+
+type roomListQuery struct {
+	limit        int
+	cursor       *roomCursor
+	sort         string
+	active       *bool
+	minPlayers   int
+	createdAfter time.Time
+	tag          string
+	search       string
+}
+
+// roomCursor points behind the last room of a page. It holds the sort keys
+// instead of an offset, so rooms created or removed in between don't shift
+// the following pages.
+type roomCursor struct {
+	Sort    string    `json:"s"`
+	Created time.Time `json:"c"`
+	Players int       `json:"p"`
+	Title   string    `json:"t"`
+	Id      uuid.UUID `json:"i"`
+}
+
+func readRoomListQuery(values url.Values) (roomListQuery, error) {
+	query := roomListQuery{
+		limit:  defaultPageSize,
+		sort:   "created",
+		tag:    values.Get("tag"),
+		search: values.Get("q"),
+	}
+	var errs []error
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			errs = append(errs, fmt.Errorf("limit must be between 1 and %d", maxPageSize))
+		}
+		query.limit = limit
+	}
+	if value := values.Get("sort"); value != "" {
+		if !slices.Contains(roomSorts, value) {
+			errs = append(errs, fmt.Errorf("sort must be one of %s", strings.Join(roomSorts, ", ")))
+		}
+		query.sort = value
+	}
+	if value := values.Get("active"); value != "" {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, errors.New("active must be true or false"))
+		}
+		query.active = &active
+	}
+	if value := values.Get("minPlayers"); value != "" {
+		minPlayers, err := strconv.Atoi(value)
+		if err != nil || minPlayers < 0 {
+			errs = append(errs, errors.New("minPlayers must be a non-negative number"))
+		}
+		query.minPlayers = minPlayers
+	}
+	if value := values.Get("createdAfter"); value != "" {
+		createdAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			errs = append(errs, errors.New("createdAfter must be a RFC 3339 timestamp"))
+		}
+		query.createdAfter = createdAfter
+	}
+	if value := values.Get("cursor"); value != "" {
+		cursor, err := decodeRoomCursor(value)
+		if err != nil || cursor.Sort != query.sort {
+			errs = append(errs, errors.New("cursor is invalid or belongs to another sort order"))
+		}
+		query.cursor = cursor
+	}
+
+	return query, errors.Join(errs...)
+}
+
+// page filters and sorts listings and returns the requested page together
+// with the cursor of the next one, which is empty on the last page.
+func (query roomListQuery) page(listings []roomListing) ([]internal.Overview, string) {
+	overviews := []internal.Overview{}
+	for _, listing := range listings {
+		if query.matches(listing) {
+			overviews = append(overviews, listing.overview)
+		}
+	}
+	slices.SortFunc(overviews, query.compare)
+
+	if query.cursor != nil {
+		after := query.cursor.overview()
+		start, _ := slices.BinarySearchFunc(overviews, after, query.compare)
+		if start < len(overviews) && query.compare(overviews[start], after) == 0 {
+			start++
+		}
+		overviews = overviews[start:]
+	}
+
+	if len(overviews) <= query.limit {
+		return overviews, ""
+	}
+	overviews = overviews[:query.limit]
+	return overviews, encodeRoomCursor(query.sort, overviews[len(overviews)-1])
+}
+
+func (query roomListQuery) matches(listing roomListing) bool {
+	overview := listing.overview
+	switch {
+	case query.active != nil && *query.active != listing.inProgress:
+		return false
+	case overview.PlayerCount < query.minPlayers:
+		return false
+	case !query.createdAfter.IsZero() && !overview.Created.After(query.createdAfter):
+		return false
+	}
+	return overview.Matches(query.tag, query.search)
+}
+
+func (query roomListQuery) compare(a, b internal.Overview) int {
+	field, descending := strings.CutPrefix(query.sort, "-")
+
+	var result int
+	switch field {
+	case "players":
+		result = cmp.Compare(a.PlayerCount, b.PlayerCount)
+	case "title":
+		result = cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	}
+	if result == 0 {
+		result = a.Created.Compare(b.Created)
+	}
+	if descending {
+		result = -result
+	}
+	if result == 0 {
+		result = strings.Compare(a.Id.String(), b.Id.String())
+	}
+	return result
+}
+
+func encodeRoomCursor(sort string, overview internal.Overview) string {
+	cursor, _ := json.Marshal(roomCursor{
+		Sort:    sort,
+		Created: overview.Created,
+		Players: overview.PlayerCount,
+		Title:   overview.Title,
+		Id:      overview.Id,
+	})
+	return base64.RawURLEncoding.EncodeToString(cursor)
+}
+
+func decodeRoomCursor(value string) (*roomCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor roomCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func (cursor *roomCursor) overview() internal.Overview {
+	return internal.Overview{
+		Id:          cursor.Id,
+		Created:     cursor.Created,
+		PlayerCount: cursor.Players,
+		RoomDetails: internal.RoomDetails{Title: cursor.Title},
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func testListings() []roomListing {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	listing := func(id string, minutes, players int, title string, inProgress bool, tags ...string) roomListing {
+		return roomListing{
+			overview: internal.Overview{
+				Id:          uuid.MustParse(id),
+				PlayerCount: players,
+				Created:     created.Add(time.Duration(minutes) * time.Minute),
+				RoomDetails: internal.RoomDetails{Title: title, Tags: tags},
+			},
+			inProgress: inProgress,
+		}
+	}
+	return []roomListing{
+		listing("00000000-0000-0000-0000-000000000003", 3, 1, "Charlie", false),
+		listing("00000000-0000-0000-0000-000000000001", 1, 5, "alpha", true, "backend"),
+		listing("00000000-0000-0000-0000-000000000004", 4, 0, "Delta", false, "backend"),
+		listing("00000000-0000-0000-0000-000000000002", 2, 3, "Bravo", true),
+	}
+}
+
+func titles(overviews []internal.Overview) []string {
+	out := []string{}
+	for _, overview := range overviews {
+		out = append(out, overview.Title)
+	}
+	return out
+}
+
+func TestRoomListQuery_page(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "default sorts by creation", query: "", want: []string{"alpha", "Bravo", "Charlie", "Delta"}},
+		{name: "newest first", query: "sort=-created", want: []string{"Delta", "Charlie", "Bravo", "alpha"}},
+		{name: "by players", query: "sort=-players", want: []string{"alpha", "Bravo", "Charlie", "Delta"}},
+		{name: "by title ignoring case", query: "sort=-title", want: []string{"Delta", "Charlie", "Bravo", "alpha"}},
+		{name: "active rounds", query: "active=true", want: []string{"alpha", "Bravo"}},
+		{name: "idle rooms", query: "active=false", want: []string{"Charlie", "Delta"}},
+		{name: "min players", query: "minPlayers=3", want: []string{"alpha", "Bravo"}},
+		{name: "created after", query: "createdAfter=2026-01-01T00:02:00Z", want: []string{"Charlie", "Delta"}},
+		{name: "tag", query: "tag=backend&sort=-created", want: []string{"Delta", "alpha"}},
+		{name: "limit", query: "limit=2", want: []string{"alpha", "Bravo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			query, err := readRoomListQuery(values)
+			assert.NilError(t, err)
+
+			got, _ := query.page(testListings())
+
+			assert.DeepEqual(t, titles(got), tt.want)
+		})
+	}
+}
+
+func TestRoomListQuery_pageWithCursor(t *testing.T) {
+	for _, sort := range roomSorts {
+		t.Run(sort, func(t *testing.T) {
+			values := url.Values{"sort": {sort}, "limit": {"1"}}
+			var walked []string
+			for range len(testListings()) + 1 {
+				query, err := readRoomListQuery(values)
+				assert.NilError(t, err)
+				page, next := query.page(testListings())
+				walked = append(walked, titles(page)...)
+				if next == "" {
+					break
+				}
+				values.Set("cursor", next)
+			}
+
+			query, err := readRoomListQuery(url.Values{"sort": {sort}})
+			assert.NilError(t, err)
+			want, _ := query.page(testListings())
+			assert.DeepEqual(t, walked, titles(want))
+		})
+	}
+}
+
+func TestRoomListQuery_cursorSurvivesRemovedRoom(t *testing.T) {
+	query, err := readRoomListQuery(url.Values{"limit": {"2"}})
+	assert.NilError(t, err)
+	listings := testListings()
+	_, next := query.page(listings)
+
+	// Bravo, the last room of the first page, is gone before the next page is fetched
+	remaining := []roomListing{listings[0], listings[1], listings[2]}
+	query, err = readRoomListQuery(url.Values{"limit": {"2"}, "cursor": {next}})
+	assert.NilError(t, err)
+	got, next := query.page(remaining)
+
+	assert.DeepEqual(t, titles(got), []string{"Charlie", "Delta"})
+	assert.Equal(t, next, "")
+}
+
+func TestReadRoomListQuery_Errors(t *testing.T) {
+	created, _ := readRoomListQuery(url.Values{"limit": {"1"}})
+	_, cursor := created.page(testListings())
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{name: "limit too big", query: "limit=101", wantErr: "limit must be between 1 and 100"},
+		{name: "limit not a number", query: "limit=all", wantErr: "limit must be between 1 and 100"},
+		{name: "unknown sort", query: "sort=name", wantErr: "sort must be one of created, -created, players, -players, title, -title"},
+		{name: "active", query: "active=maybe", wantErr: "active must be true or false"},
+		{name: "min players", query: "minPlayers=-1", wantErr: "minPlayers must be a non-negative number"},
+		{name: "created after", query: "createdAfter=yesterday", wantErr: "createdAfter must be a RFC 3339 timestamp"},
+		{name: "garbage cursor", query: "cursor=not-base64!", wantErr: "cursor is invalid or belongs to another sort order"},
+		{name: "cursor of other sort", query: "sort=players&cursor=" + cursor, wantErr: "cursor is invalid or belongs to another sort order"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+
+			_, err := readRoomListQuery(values)
+
+			assert.Equal(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestApplication_handleFetchActiveRoomsPagination(t *testing.T) {
+	rooms := make(map[uuid.UUID]*internal.Room)
+	for i := range 3 {
+		id := uuid.New()
+		rooms[id] = &internal.Room{
+			Id:      id,
+			Created: time.Now().Add(time.Duration(i) * time.Minute),
+		}
+	}
+	app := newTestApplication(t, rooms)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	var seen []string
+	path := "/v1/rooms?limit=2"
+	for path != "" {
+		response := ts.get(t, path)
+		assert.Equal(t, response.status, http.StatusOK)
+
+		var got struct {
+			Rooms      []internal.Overview `json:"rooms"`
+			NextCursor string              `json:"nextCursor"`
+		}
+		json.Unmarshal(response.body, &got)
+		for _, overview := range got.Rooms {
+			seen = append(seen, overview.Id.String())
+		}
+		path = ""
+		if got.NextCursor != "" {
+			path = fmt.Sprintf("/v1/rooms?limit=2&cursor=%s", got.NextCursor)
+		}
+	}
+
+	assert.Equal(t, len(seen), 3)
+
+	response := ts.get(t, "/v1/rooms?limit=0")
+	assert.Equal(t, response.status, http.StatusBadRequest)
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/Hydoc/go-message"
 	"github.com/google/uuid"
@@ -19,63 +24,182 @@ const (
 	version                   = "1.21.0"
 )
 
-type config struct {
-	port int
-	env  string
-}
-
 type application struct {
-	mu sync.RWMutex
-
-	config      config
-	bus         message.Bus
-	logger      *slog.Logger
-	guessConfig *internal.GuessConfig
-	rooms       map[uuid.UUID]*internal.Room
-	destroyRoom chan uuid.UUID
+	mu       sync.RWMutex
+	configMu sync.RWMutex
+
+	config          config
+	loadConfig      func() (config, error)
+	logLevel        *slog.LevelVar
+	bus             message.Bus
+	logger          *slog.Logger
+	decks           map[string]*internal.GuessConfig
+	rooms           map[uuid.UUID]*internal.Room
+	aliases         roomAliases
+	destroyRoom     chan uuid.UUID
+	started         time.Time
+	draining        atomic.Bool
+	readOnly        atomic.Bool
+	readinessChecks map[string]readinessCheck
+	auditSink       internal.AuditSink
+	archive         internal.RoomArchive
+	teams           internal.TeamStore
+	apiKeys         internal.APIKeyStore
+	ipLimiter       *ipRateLimiter
+	tokens          *internal.TokenSigner
+	oidc            *oidcProvider
 }
 
 func main() {
-	var cfg config
-	flag.IntVar(&cfg.port, "port", 8080, "API server port")
-	flag.StringVar(&cfg.env, "env", "development", "Environment (development|test|production)")
-	flag.Parse()
+	logLevel := new(slog.LevelVar)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	load := func() (config, error) {
+		return loadConfig(os.Args[1:], os.LookupEnv)
+	}
+	cfg, err := load()
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logger.Error("invalid configuration", "error", err)
+		os.Exit(2)
+	}
+	level, _ := cfg.logLevel()
+	logLevel.Set(level)
 
-	var possibleGuesses, possibleGuessesDescription string
+	logger.Info(fmt.Sprintf("using possible guesses %s", cfg.Deck.Guesses))
+	logger.Info(fmt.Sprintf("using possible guesses description %s", cfg.Deck.Descriptions))
 
-	possibleGuesses, ok := os.LookupEnv("POSSIBLE_GUESSES")
-	possibleGuessesDescription, okDesc := os.LookupEnv("POSSIBLE_GUESSES_DESC")
-	if !ok {
-		logger.Info("can not find env POSSIBLE_GUESSES")
-		possibleGuesses = defaultGuesses
+	decks, err := newDecks(cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		return
 	}
-	if !okDesc {
-		logger.Info("can not find env POSSIBLE_GUESSES_DESC")
-		possibleGuessesDescription = defaultGuessesDescription
+
+	auditSink, err := newAuditSink(cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	archive, err := newRoomArchive(cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	teams, err := newTeamStore(cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		return
 	}
-	logger.Info(fmt.Sprintf("using possible guesses %s", possibleGuesses))
-	logger.Info(fmt.Sprintf("using possible guesses description %s", possibleGuessesDescription))
 
-	guessConfig, err := internal.NewGuessConfig(possibleGuesses, possibleGuessesDescription)
+	apiKeys, err := newAPIKeyStore(cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	tokens, err := internal.NewTokenSigner([]byte(cfg.Auth.TokenSecret))
 	if err != nil {
 		logger.Error(err.Error())
 		return
 	}
 
 	app := &application{
-		logger:      logger,
-		config:      cfg,
-		guessConfig: guessConfig,
-		rooms:       make(map[uuid.UUID]*internal.Room),
-		destroyRoom: make(chan uuid.UUID),
-		bus:         internal.CreateBus(),
+		logger:          logger,
+		logLevel:        logLevel,
+		config:          cfg,
+		loadConfig:      load,
+		decks:           decks,
+		rooms:           make(map[uuid.UUID]*internal.Room),
+		destroyRoom:     make(chan uuid.UUID),
+		bus:             internal.CreateBus(),
+		started:         time.Now(),
+		readinessChecks: make(map[string]readinessCheck),
+		auditSink:       auditSink,
+		archive:         archive,
+		teams:           teams,
+		apiKeys:         apiKeys,
+		ipLimiter:       newIPRateLimiter(cfg.Limits.RPS, cfg.Limits.Burst),
+		tokens:          tokens,
+	}
+	app.registerReadinessChecks()
+	if cfg.OIDC.enabled() {
+		app.oidc = newOIDCProvider(cfg.OIDC, &http.Client{Timeout: 10 * time.Second})
+	}
+
+	shutdownTracing, err := setupTracing(context.Background(), cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		return
 	}
 
 	err = app.serve()
+	if shutdownErr := shutdownTracing(context.Background()); shutdownErr != nil {
+		logger.Error("failed to flush traces", "error", shutdownErr)
+	}
 	if err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
 	}
 }
+
+func newAuditSink(cfg config) (internal.AuditSink, error) {
+	switch cfg.Storage.AuditSink {
+	case "stdout":
+		return internal.NewJSONAuditSink(os.Stdout), nil
+	case "file":
+		return internal.NewRotatingFileAuditSink(cfg.Storage.AuditFile, cfg.Storage.AuditMaxSize, cfg.Storage.AuditMaxBackups)
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown audit sink %q", cfg.Storage.AuditSink)
+	}
+}
+
+func newTeamStore(cfg config) (internal.TeamStore, error) {
+	switch cfg.Storage.Teams {
+	case "file":
+		store, err := internal.OpenFileTeamStore(cfg.Storage.TeamsFile)
+		if err != nil {
+			return nil, fmt.Errorf("can not open teams file %s: %w", cfg.Storage.TeamsFile, err)
+		}
+		return store, nil
+	case "memory":
+		return internal.NewMemoryTeamStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown team store %q", cfg.Storage.Teams)
+	}
+}
+
+func newAPIKeyStore(cfg config) (internal.APIKeyStore, error) {
+	switch cfg.Storage.APIKeyStore {
+	case "file":
+		store, err := internal.OpenFileAPIKeyStore(cfg.Storage.APIKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can not open api key file %s: %w", cfg.Storage.APIKeyFile, err)
+		}
+		return store, nil
+	case "memory":
+		return internal.NewMemoryAPIKeyStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown api key store %q", cfg.Storage.APIKeyStore)
+	}
+}
+
+func newRoomArchive(cfg config) (internal.RoomArchive, error) {
+	switch cfg.Storage.Archive {
+	case "file":
+		file, err := os.OpenFile(cfg.Storage.ArchiveFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			return nil, fmt.Errorf("can not open archive file %s: %w", cfg.Storage.ArchiveFile, err)
+		}
+		return internal.NewJSONRoomArchive(file), nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown archive %q", cfg.Storage.Archive)
+	}
+}
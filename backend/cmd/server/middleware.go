@@ -1,19 +1,50 @@
 package main
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
 )
 
+const requestIdHeader = "X-Request-ID"
+
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// secretQueryParams carry credentials and are never logged.
+var secretQueryParams = []string{"ownerToken", "invite", "password", "code", "state"}
+
+// loggableURI returns the request uri with the values of secret query params
+// replaced.
+func loggableURI(u *url.URL) string {
+	query := u.Query()
+	redacted := false
+	for _, key := range secretQueryParams {
+		if query.Has(key) {
+			query.Set(key, "[redacted]")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.RequestURI()
+	}
+	return u.EscapedPath() + "?" + query.Encode()
+}
+
 func (app *application) withRequiredQueryParam(param string, next http.HandlerFunc) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		queryParam := request.URL.Query().Get(param)
 
 		if len(queryParam) == 0 || !request.URL.Query().Has(param) {
-			err := app.writeJSON(writer, http.StatusBadRequest, envelope{"message": fmt.Sprintf("%s is missing in query", param)}, nil)
-			if err != nil {
-				app.serverErrorResponse(writer, request, err)
-			}
+			app.badRequestResponse(writer, request, fmt.Errorf("%s is missing in query", param))
 			return
 		}
 
@@ -34,3 +65,173 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 	})
 
 }
+
+// requireAdmin lets requests through that carry the admin token or an api
+// key with the admin scope.
+func (app *application) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return app.requireScope(internal.ScopeAdmin, func(writer http.ResponseWriter, request *http.Request) {
+		if app.contextGetAPIKey(request) != nil {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		if !app.hasAdminToken(request) {
+			app.unauthorizedResponse(writer, request)
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// hasAdminToken reports whether the request carries the configured admin
+// token as bearer token.
+func (app *application) hasAdminToken(request *http.Request) bool {
+	token, ok := app.readBearerToken(request)
+	return ok && app.config.Auth.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(app.config.Auth.AdminToken)) == 1
+}
+
+// authenticate resolves api keys sent as bearer token. Other bearer tokens are
+// left to the handlers, which know whether they expect an owner token.
+func (app *application) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		token, ok := app.readBearerToken(request)
+		if !ok || !strings.HasPrefix(token, internal.APIKeyPrefix) {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		key, err := app.apiKeys.Authenticate(token)
+		if err != nil || key.Expired(time.Now()) {
+			app.unauthorizedResponse(writer, request)
+			return
+		}
+		next.ServeHTTP(writer, app.contextSetAPIKey(request, &key))
+	})
+}
+
+// requireScope rejects requests made with an api key lacking scope. Requests
+// without an api key pass, it is up to next to authenticate them otherwise.
+func (app *application) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if key := app.contextGetAPIKey(request); key != nil && !key.HasScope(scope) {
+			app.missingScopeResponse(writer, request, scope)
+			return
+		}
+		next.ServeHTTP(writer, request)
+	}
+}
+
+func (app *application) withRoute(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		app.contextGetRequestInfo(request).route = route
+		app.traceRoute(request, route)
+		next.ServeHTTP(writer, request)
+	}
+}
+
+// logRequest assigns every request a correlation id, either taken from the
+// X-Request-ID header of the caller or freshly generated, and writes an access
+// log line once the request has been served.
+func (app *application) logRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+
+		id := request.Header.Get(requestIdHeader)
+		if !validRequestId.MatchString(id) {
+			id = uuid.NewString()
+		}
+		info := &requestInfo{id: id}
+		request = app.contextSetRequestInfo(request, info)
+		writer.Header().Set(requestIdHeader, id)
+
+		recorder := &statusRecorder{ResponseWriter: writer}
+		next.ServeHTTP(recorder, request)
+
+		app.logger.Info("request",
+			"requestId", info.id,
+			"method", request.Method,
+			"route", info.route,
+			"uri", loggableURI(request.URL),
+			"status", recorder.statusOrDefault(),
+			"duration", time.Since(start),
+			"remoteAddr", request.RemoteAddr,
+		)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(b []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	return recorder.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController and the websocket upgrade reach the
+// underlying writer, e.g. to hijack the connection.
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+func (recorder *statusRecorder) statusOrDefault() int {
+	if recorder.status == 0 {
+		return http.StatusOK
+	}
+	return recorder.status
+}
+
+// enableCORS allows browsers on the configured origins to call the JSON API.
+// Requests from other origins are served without CORS headers, so the
+// browser refuses to hand the response to the calling script.
+func (app *application) enableCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Add("Vary", "Origin")
+		writer.Header().Add("Vary", "Access-Control-Request-Method")
+
+		origin := request.Header.Get("Origin")
+		if origin != "" && app.originAllowed(origin) {
+			writer.Header().Set("Access-Control-Allow-Origin", origin)
+			writer.Header().Set("Access-Control-Allow-Credentials", "true")
+			writer.Header().Set("Access-Control-Expose-Headers", requestIdHeader)
+
+			if request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != "" {
+				writer.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET, POST, PUT, PATCH, DELETE")
+				writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+requestIdHeader)
+				writer.Header().Set("Access-Control-Max-Age", "600")
+				writer.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// originAllowed matches the host of origin against the configured patterns
+// the same way the websocket upgrade checks its OriginPatterns.
+func (app *application) originAllowed(origin string) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+
+	for _, pattern := range app.currentConfig().CORS.originPatterns() {
+		matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(parsed.Host))
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestApplication_logRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		requestId     string
+		wantGenerated bool
+	}{
+		{
+			name:          "keeps id of caller",
+			requestId:     "abc-123",
+			wantGenerated: false,
+		},
+		{
+			name:          "generates id when missing",
+			requestId:     "",
+			wantGenerated: true,
+		},
+		{
+			name:          "generates id when invalid",
+			requestId:     "not valid\twith spaces",
+			wantGenerated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuffer bytes.Buffer
+			app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+			app.logger = slog.New(slog.NewTextHandler(&logBuffer, nil))
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			response := ts.getWithHeaders(t, "/v1/room/invalid/state", http.Header{requestIdHeader: {tt.requestId}})
+			gotId := response.headers.Get(requestIdHeader)
+
+			if tt.wantGenerated {
+				_, err := uuid.Parse(gotId)
+				assert.NilError(t, err)
+			} else {
+				assert.Equal(t, gotId, tt.requestId)
+			}
+			assert.StringContains(t, string(response.body), `"requestId": "`+gotId+`"`)
+			assert.StringContains(t, logBuffer.String(), "requestId="+gotId)
+			assert.StringContains(t, logBuffer.String(), "route=/v1/room/:id/state")
+			assert.StringContains(t, logBuffer.String(), "status=400")
+			assert.StringContains(t, logBuffer.String(), "method=GET")
+		})
+	}
+}
+
+func TestLoggableURI(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		want string
+	}{
+		{name: "without query", uri: "/v1/rooms", want: "/v1/rooms"},
+		{name: "without secrets", uri: "/v1/rooms?sort=name&tag=a", want: "/v1/rooms?sort=name&tag=a"},
+		{name: "owner token", uri: "/v1/room/abc/product-owner?name=Alice&ownerToken=secret", want: "/v1/room/abc/product-owner?name=Alice&ownerToken=%5Bredacted%5D"},
+		{name: "invite and password", uri: "/v1/room/abc/developer?invite=secret&name=Bob&password=secret", want: "/v1/room/abc/developer?invite=%5Bredacted%5D&name=Bob&password=%5Bredacted%5D"},
+		{name: "login callback", uri: "/v1/auth/callback?code=secret&state=secret", want: "/v1/auth/callback?code=%5Bredacted%5D&state=%5Bredacted%5D"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.ParseRequestURI(tt.uri)
+			assert.NilError(t, err)
+
+			assert.Equal(t, loggableURI(u), tt.want)
+		})
+	}
+}
+
+func TestApplication_enableCORS(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedOrigins []string
+		method         string
+		headers        http.Header
+		wantStatus     int
+		wantOrigin     string
+		wantMethods    string
+	}{
+		{
+			name:           "trusted origin",
+			trustedOrigins: []string{"https://poker.example.com"},
+			method:         http.MethodGet,
+			headers:        http.Header{"Origin": {"https://poker.example.com"}},
+			wantStatus:     http.StatusOK,
+			wantOrigin:     "https://poker.example.com",
+		},
+		{
+			name:           "trusted origin through wildcard pattern",
+			trustedOrigins: []string{"*.example.com"},
+			method:         http.MethodGet,
+			headers:        http.Header{"Origin": {"https://team.example.com"}},
+			wantStatus:     http.StatusOK,
+			wantOrigin:     "https://team.example.com",
+		},
+		{
+			name:           "untrusted origin",
+			trustedOrigins: []string{"https://poker.example.com"},
+			method:         http.MethodGet,
+			headers:        http.Header{"Origin": {"https://evil.example.org"}},
+			wantStatus:     http.StatusOK,
+			wantOrigin:     "",
+		},
+		{
+			name:           "preflight of trusted origin",
+			trustedOrigins: []string{"https://poker.example.com"},
+			method:         http.MethodOptions,
+			headers: http.Header{
+				"Origin":                        {"https://poker.example.com"},
+				"Access-Control-Request-Method": {http.MethodPost},
+			},
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "https://poker.example.com",
+			wantMethods: "OPTIONS, GET, POST, PUT, PATCH, DELETE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+			app.config.CORS.TrustedOrigins = tt.trustedOrigins
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			request, err := http.NewRequest(tt.method, ts.URL+"/v1/rooms", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header = tt.headers
+			response, err := ts.Client().Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+
+			assert.Equal(t, response.StatusCode, tt.wantStatus)
+			assert.Equal(t, response.Header.Get("Access-Control-Allow-Origin"), tt.wantOrigin)
+			assert.Equal(t, response.Header.Get("Access-Control-Allow-Methods"), tt.wantMethods)
+			// the session cookie only travels along for trusted origins
+			assert.Equal(t, response.Header.Get("Access-Control-Allow-Credentials") == "true", tt.wantOrigin != "")
+		})
+	}
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+// The cookie names double as purposes of the tokens stored in them.
+const (
+	sessionCookie    = "ep_session"
+	loginStateCookie = "ep_login"
+	loginStateTTL    = 10 * time.Minute
+	// clockSkew is tolerated between the identity provider and this server.
+	clockSkew = time.Minute
+)
+
+var errInvalidIDToken = errors.New("id token is invalid")
+
+// oidcProvider logs users in with the authorization code flow and PKCE. The
+// discovery document and signing keys are fetched on first use, so the server
+// starts even while the provider is unreachable.
+type oidcProvider struct {
+	config oidcConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type idTokenClaims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	ExpiresAt         int64    `json:"exp"`
+	Nonce             string   `json:"nonce"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Email             string   `json:"email"`
+	EmailVerified     bool     `json:"email_verified"`
+	Picture           string   `json:"picture"`
+}
+
+// audience is either a single string or a list of strings.
+type audience []string
+
+func (aud *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = audience{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(aud))
+}
+
+// loginState survives the round trip to the identity provider in a signed
+// cookie, which binds the callback to the browser that started the login.
+type loginState struct {
+	State     string    `json:"state"`
+	Nonce     string    `json:"nonce"`
+	Verifier  string    `json:"verifier"`
+	Redirect  string    `json:"redirect"`
+	ExpiresAt time.Time `json:"loginExpiresAt"`
+}
+
+// session is the signed content of the session cookie.
+type session struct {
+	internal.User
+	ExpiresAt time.Time `json:"sessionExpiresAt"`
+}
+
+func newOIDCProvider(cfg oidcConfig, client *http.Client) *oidcProvider {
+	return &oidcProvider{config: cfg, client: client}
+}
+
+func (provider *oidcProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.discovery != nil {
+		return provider.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	err := provider.getJSON(ctx, strings.TrimSuffix(provider.config.Issuer, "/")+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if discovery.Issuer != provider.config.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match the configured issuer %q", discovery.Issuer, provider.config.Issuer)
+	}
+	provider.discovery = &discovery
+	return provider.discovery, nil
+}
+
+func (provider *oidcProvider) authCodeURL(ctx context.Context, state loginState) (string, error) {
+	discovery, err := provider.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(state.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.config.ClientId},
+		"redirect_uri":          {provider.config.RedirectURL},
+		"scope":                 {strings.Join(provider.config.Scopes, " ")},
+		"state":                 {state.State},
+		"nonce":                 {state.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// exchange trades the authorization code for the verified identity of the
+// user.
+func (provider *oidcProvider) exchange(ctx context.Context, code string, state loginState) (internal.User, error) {
+	discovery, err := provider.discover(ctx)
+	if err != nil {
+		return internal.User{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.config.RedirectURL},
+		"client_id":     {provider.config.ClientId},
+		"code_verifier": {state.Verifier},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return internal.User{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if provider.config.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(provider.config.ClientId), url.QueryEscape(provider.config.ClientSecret))
+	}
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return internal.User{}, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return internal.User{}, fmt.Errorf("oidc token exchange: unexpected status %d", response.StatusCode)
+	}
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&tokens); err != nil {
+		return internal.User{}, fmt.Errorf("oidc token exchange: %w", err)
+	}
+
+	claims, err := provider.verifyIDToken(ctx, tokens.IDToken, state.Nonce, time.Now())
+	if err != nil {
+		return internal.User{}, err
+	}
+	user := internal.User{
+		Subject: claims.Subject,
+		Name:    cmp.Or(claims.Name, claims.PreferredUsername, claims.Email, claims.Subject),
+		Picture: claims.Picture,
+	}
+	if claims.EmailVerified {
+		user.Email = claims.Email
+	}
+	return user, nil
+}
+
+// verifyIDToken checks the RS256 signature and the claims of an id token.
+func (provider *oidcProvider) verifyIDToken(ctx context.Context, raw, nonce string, now time.Time) (idTokenClaims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return idTokenClaims{}, errInvalidIDToken
+	}
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyId     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Algorithm != "RS256" {
+		return idTokenClaims{}, errInvalidIDToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return idTokenClaims{}, errInvalidIDToken
+	}
+	key, err := provider.key(ctx, header.KeyId)
+	if err != nil {
+		return idTokenClaims{}, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return idTokenClaims{}, errInvalidIDToken
+	}
+
+	var claims idTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return idTokenClaims{}, errInvalidIDToken
+	}
+	switch {
+	case claims.Issuer != provider.config.Issuer:
+		return idTokenClaims{}, fmt.Errorf("%w: unexpected issuer %q", errInvalidIDToken, claims.Issuer)
+	case !slices.Contains(claims.Audience, provider.config.ClientId):
+		return idTokenClaims{}, fmt.Errorf("%w: not issued for this client", errInvalidIDToken)
+	case !now.Before(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)):
+		return idTokenClaims{}, fmt.Errorf("%w: expired", errInvalidIDToken)
+	case claims.Nonce != nonce:
+		return idTokenClaims{}, fmt.Errorf("%w: nonce does not match", errInvalidIDToken)
+	case claims.Subject == "":
+		return idTokenClaims{}, fmt.Errorf("%w: missing subject", errInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// key returns the signing key with id. The key set is fetched again once for
+// unknown ids, since providers rotate their keys.
+func (provider *oidcProvider) key(ctx context.Context, id string) (*rsa.PublicKey, error) {
+	provider.mu.Lock()
+	key, ok := provider.keys[id]
+	provider.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	discovery, err := provider.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			KeyType  string `json:"kty"`
+			KeyId    string `json:"kid"`
+			Modulus  string `json:"n"`
+			Exponent string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := provider.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" {
+			continue
+		}
+		modulus, errN := base64.RawURLEncoding.DecodeString(jwk.Modulus)
+		exponent, errE := base64.RawURLEncoding.DecodeString(jwk.Exponent)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.KeyId] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(modulus),
+			E: int(new(big.Int).SetBytes(exponent).Int64()),
+		}
+	}
+
+	provider.mu.Lock()
+	provider.keys = keys
+	provider.mu.Unlock()
+	if key, ok := keys[id]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", errInvalidIDToken, id)
+}
+
+func (provider *oidcProvider) getJSON(ctx context.Context, endpoint string, destination any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", response.StatusCode, endpoint)
+	}
+	return json.NewDecoder(response.Body).Decode(destination)
+}
+
+func decodeSegment(segment string, destination any) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, destination)
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (app *application) handleLogin(writer http.ResponseWriter, request *http.Request) {
+	redirect := request.URL.Query().Get("redirect")
+	// only paths on this site, anything else would make us an open redirect
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		redirect = "/"
+	}
+	state := loginState{
+		State:     randomString(),
+		Nonce:     randomString(),
+		Verifier:  randomString(),
+		Redirect:  redirect,
+		ExpiresAt: time.Now().Add(loginStateTTL),
+	}
+
+	location, err := app.oidc.authCodeURL(request.Context(), state)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+		return
+	}
+	http.SetCookie(writer, app.cookie(loginStateCookie, app.tokens.ForPurpose(loginStateCookie).Sign(state), loginStateTTL))
+	http.Redirect(writer, request, location, http.StatusFound)
+}
+
+func (app *application) handleLoginCallback(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	if reason := query.Get("error"); reason != "" {
+		app.unauthorizedResponse(writer, request)
+		return
+	}
+
+	var state loginState
+	cookie, err := request.Cookie(loginStateCookie)
+	if err != nil || app.tokens.ForPurpose(loginStateCookie).Verify(cookie.Value, &state) != nil || state.State == "" || state.State != query.Get("state") || time.Now().After(state.ExpiresAt) {
+		app.badRequestResponse(writer, request, errors.New("login state is invalid or expired, please try again"))
+		return
+	}
+	http.SetCookie(writer, app.cookie(loginStateCookie, "", -1))
+
+	user, err := app.oidc.exchange(request.Context(), query.Get("code"), state)
+	if err != nil {
+		app.logError(request, err)
+		app.unauthorizedResponse(writer, request)
+		return
+	}
+
+	ttl := app.config.OIDC.SessionTTL
+	http.SetCookie(writer, app.cookie(sessionCookie, app.tokens.ForPurpose(sessionCookie).Sign(session{User: user, ExpiresAt: time.Now().Add(ttl)}), ttl))
+	app.logger.Info("user logged in", "requestId", app.contextGetRequestInfo(request).id, "sub", user.Subject)
+	http.Redirect(writer, request, state.Redirect, http.StatusSeeOther)
+}
+
+func (app *application) handleCurrentUser(writer http.ResponseWriter, request *http.Request) {
+	user := app.readSession(request)
+	if user == nil {
+		app.unauthorizedResponse(writer, request)
+		return
+	}
+
+	err := app.writeJSON(writer, http.StatusOK, envelope{"user": user}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleLogout(writer http.ResponseWriter, request *http.Request) {
+	http.SetCookie(writer, app.cookie(sessionCookie, "", -1))
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// readSession returns the user logged in with the request, or nil for
+// guests.
+func (app *application) readSession(request *http.Request) *internal.User {
+	if app.oidc == nil {
+		return nil
+	}
+	cookie, err := request.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	var current session
+	if app.tokens.ForPurpose(sessionCookie).Verify(cookie.Value, &current) != nil || current.Subject == "" || time.Now().After(current.ExpiresAt) {
+		return nil
+	}
+	return &current.User
+}
+
+// displayName is the verified name of user, shortened to the maximum name
+// length.
+func (app *application) displayName(user *internal.User) string {
+	name := []rune(user.Name)
+	if limit := app.currentConfig().Limits.MaxNameLength; len(name) > limit {
+		name = name[:limit]
+	}
+	return string(name)
+}
+
+// cookie builds an http only cookie, a negative maxAge deletes it.
+func (app *application) cookie(name, value string, maxAge time.Duration) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   app.config.Env == "production",
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(maxAge.Seconds()),
+	}
+	if maxAge < 0 {
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+const testClientId = "estimation-poker"
+
+// mockIdP is a minimal OpenID Connect provider which logs in whoever is set
+// as user without asking.
+type mockIdP struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu             sync.Mutex
+	user           map[string]any
+	authorizations map[string]url.Values
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, authorizations: make(map[string]url.Values)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(writer http.ResponseWriter, request *http.Request) {
+		json.NewEncoder(writer).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /authorize", func(writer http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+		code := uuid.NewString()
+		idp.mu.Lock()
+		idp.authorizations[code] = query
+		idp.mu.Unlock()
+		http.Redirect(writer, request, query.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {query.Get("state")}}.Encode(), http.StatusFound)
+	})
+	mux.HandleFunc("POST /token", func(writer http.ResponseWriter, request *http.Request) {
+		idp.mu.Lock()
+		authorization, ok := idp.authorizations[request.PostFormValue("code")]
+		delete(idp.authorizations, request.PostFormValue("code"))
+		user := idp.user
+		idp.mu.Unlock()
+
+		clientId, secret, _ := request.BasicAuth()
+		challenge := sha256.Sum256([]byte(request.PostFormValue("code_verifier")))
+		if !ok || clientId != testClientId || secret != "secret" || base64.RawURLEncoding.EncodeToString(challenge[:]) != authorization.Get("code_challenge") {
+			http.Error(writer, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		claims := map[string]any{"nonce": authorization.Get("nonce")}
+		for key, value := range user {
+			claims[key] = value
+		}
+		json.NewEncoder(writer).Encode(map[string]string{"id_token": idp.sign(t, idp.key, claims)})
+	})
+	mux.HandleFunc("GET /jwks", func(writer http.ResponseWriter, request *http.Request) {
+		json.NewEncoder(writer).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}}})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (idp *mockIdP) setUser(sub, name, email string) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.user = map[string]any{
+		"sub":            sub,
+		"name":           name,
+		"email":          email,
+		"email_verified": true,
+		"picture":        "https://example.com/" + sub + ".png",
+	}
+}
+
+// sign issues an id token with claims, filling in the registered claims a
+// valid token needs unless claims sets them.
+func (idp *mockIdP) sign(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+
+	full := map[string]any{"iss": idp.URL, "aud": testClientId, "exp": time.Now().Add(time.Minute).Unix()}
+	for name, value := range claims {
+		full[name] = value
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(full)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newOIDCTestServer(t *testing.T) (*testServer, *mockIdP) {
+	t.Helper()
+
+	idp := newMockIdP(t)
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.config.OIDC = oidcConfig{
+		Issuer:       idp.URL,
+		ClientId:     testClientId,
+		ClientSecret: "secret",
+		Scopes:       []string{"openid", "profile", "email"},
+		SessionTTL:   time.Hour,
+	}
+	app.oidc = newOIDCProvider(app.config.OIDC, idp.Client())
+	ts := newTestServer(t, app.routes())
+	t.Cleanup(ts.Close)
+	app.oidc.config.RedirectURL = ts.URL + "/v1/auth/callback"
+	return ts, idp
+}
+
+// login runs the authorization code flow against the mock provider and
+// returns the session cookie.
+func (ts *testServer) login(t *testing.T) string {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	login, err := client.Get(ts.URL + "/v1/auth/login?redirect=/room/abc")
+	assert.NilError(t, err)
+	login.Body.Close()
+	assert.Equal(t, login.StatusCode, http.StatusFound)
+
+	authorized, err := client.Get(login.Header.Get("Location"))
+	assert.NilError(t, err)
+	authorized.Body.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, authorized.Header.Get("Location"), nil)
+	for _, cookie := range login.Cookies() {
+		request.AddCookie(cookie)
+	}
+	callback, err := client.Do(request)
+	assert.NilError(t, err)
+	callback.Body.Close()
+	assert.Equal(t, callback.StatusCode, http.StatusSeeOther)
+	assert.Equal(t, callback.Header.Get("Location"), "/room/abc")
+
+	for _, cookie := range callback.Cookies() {
+		if cookie.Name == sessionCookie && cookie.Value != "" {
+			return sessionCookie + "=" + cookie.Value
+		}
+	}
+	t.Fatal("no session cookie was set")
+	return ""
+}
+
+func TestApplication_oidcLogin(t *testing.T) {
+	ts, idp := newOIDCTestServer(t)
+	idp.setUser("alice", "Alice Example", "alice@example.com")
+
+	session := ts.login(t)
+
+	response := ts.getWithHeaders(t, "/v1/auth/me", http.Header{"Cookie": {session}})
+	assert.Equal(t, response.status, http.StatusOK)
+	var got struct {
+		User internal.User `json:"user"`
+	}
+	json.Unmarshal(response.body, &got)
+	assert.Equal(t, got.User, internal.User{
+		Subject: "alice",
+		Name:    "Alice Example",
+		Email:   "alice@example.com",
+		Picture: "https://example.com/alice.png",
+	})
+
+	assert.Equal(t, ts.get(t, "/v1/auth/me").status, http.StatusUnauthorized)
+	forged := ts.getWithHeaders(t, "/v1/auth/me", http.Header{"Cookie": {sessionCookie + "=forged.token"}})
+	assert.Equal(t, forged.status, http.StatusUnauthorized)
+
+	logout := ts.doJSON(t, http.MethodPost, "/v1/auth/logout", nil, http.Header{"Cookie": {session}})
+	assert.Equal(t, logout.status, http.StatusNoContent)
+	assert.Equal(t, logout.cookies[0].MaxAge, -1)
+}
+
+func TestApplication_oidcCallbackErrors(t *testing.T) {
+	ts, _ := newOIDCTestServer(t)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "missing login state", query: "?code=abc&state=xyz", wantStatus: http.StatusBadRequest},
+		{name: "provider error", query: "?error=access_denied", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := ts.get(t, "/v1/auth/callback"+tt.query)
+
+			assert.Equal(t, response.status, tt.wantStatus)
+		})
+	}
+}
+
+func TestApplication_readSession(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.oidc = newOIDCProvider(oidcConfig{}, nil)
+	claims := session{User: internal.User{Subject: "alice"}, ExpiresAt: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name   string
+		token  string
+		wantOk bool
+	}{
+		{name: "session", token: app.tokens.ForPurpose(sessionCookie).Sign(claims), wantOk: true},
+		{name: "signed as login state", token: app.tokens.ForPurpose(loginStateCookie).Sign(claims)},
+		{name: "signed without purpose", token: app.tokens.Sign(claims)},
+		{name: "expired", token: app.tokens.ForPurpose(sessionCookie).Sign(session{User: claims.User, ExpiresAt: time.Now().Add(-time.Minute)})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/v1/auth/me", nil)
+			request.AddCookie(&http.Cookie{Name: sessionCookie, Value: tt.token})
+
+			assert.Equal(t, app.readSession(request) != nil, tt.wantOk)
+		})
+	}
+}
+
+func TestApplication_oidcDisabled(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	assert.Equal(t, ts.get(t, "/v1/auth/login").status, http.StatusNotFound)
+}
+
+func TestOIDCProvider_verifyIDToken(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := newOIDCProvider(oidcConfig{Issuer: idp.URL, ClientId: testClientId}, idp.Client())
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{
+			name:  "valid",
+			token: idp.sign(t, idp.key, map[string]any{"sub": "alice", "nonce": "n"}),
+		},
+		{
+			name:    "audience list without this client",
+			token:   idp.sign(t, idp.key, map[string]any{"sub": "alice", "nonce": "n", "aud": []string{"other"}}),
+			wantErr: "id token is invalid: not issued for this client",
+		},
+		{
+			name:    "other issuer",
+			token:   idp.sign(t, idp.key, map[string]any{"sub": "alice", "nonce": "n", "iss": "https://evil.example.com"}),
+			wantErr: `id token is invalid: unexpected issuer "https://evil.example.com"`,
+		},
+		{
+			name:    "expired",
+			token:   idp.sign(t, idp.key, map[string]any{"sub": "alice", "nonce": "n", "exp": now.Add(-time.Hour).Unix()}),
+			wantErr: "id token is invalid: expired",
+		},
+		{
+			name:    "replayed nonce",
+			token:   idp.sign(t, idp.key, map[string]any{"sub": "alice", "nonce": "other"}),
+			wantErr: "id token is invalid: nonce does not match",
+		},
+		{
+			name:    "signed by another key",
+			token:   idp.sign(t, otherKey, map[string]any{"sub": "alice", "nonce": "n"}),
+			wantErr: "id token is invalid",
+		},
+		{
+			name:    "unsigned",
+			token:   base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice"}`)) + ".",
+			wantErr: "id token is invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.verifyIDToken(context.Background(), tt.token, "n", now)
+
+			if tt.wantErr != "" {
+				assert.Equal(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got.Subject, "alice")
+		})
+	}
+}
+
+func TestApplication_roomsWithAccounts(t *testing.T) {
+	ts, idp := newOIDCTestServer(t)
+	idp.setUser("alice", "Alice Example", "alice@example.com")
+	alice := ts.login(t)
+	idp.setUser("bob", "Bob", "bob@example.com")
+	bob := ts.login(t)
+	idp.setUser("mallory", "Mallory", "mallory@other.com")
+	mallory := ts.login(t)
+	idp.setUser("carol", "Carol", "carol@example.com")
+	carol := ts.login(t)
+
+	guest := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Guest", "allowedDomain": "example.com"})
+	assert.Equal(t, guest.status, http.StatusBadRequest)
+
+	created := ts.doJSON(t, http.MethodPost, "/v1/room", map[string]any{"creator": "ignored", "allowedDomain": "Example.com"}, http.Header{"Cookie": {alice}})
+	assert.Equal(t, created.status, http.StatusCreated)
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+
+	dial := func(role, cookie string) (*websocket.Conn, int, internal.Permissions) {
+		options := &websocket.DialOptions{HTTPHeader: http.Header{}}
+		if cookie != "" {
+			options.HTTPHeader.Set("Cookie", cookie)
+		}
+		connection, response, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http")+"/v1/room/"+room["id"]+"/"+role+"?name=anything", options)
+		var permissions struct {
+			Data internal.Permissions `json:"data"`
+		}
+		if err == nil {
+			assert.NilError(t, wsjson.Read(context.Background(), connection, &permissions))
+		}
+		return connection, response.StatusCode, permissions.Data
+	}
+
+	// keeps the room alive while the others reconnect
+	anchor, _, _ := dial("developer", carol)
+	defer anchor.CloseNow()
+
+	t.Run("connection state", func(t *testing.T) {
+		for _, tt := range []struct {
+			cookie string
+			want   internal.ConnectionState
+		}{
+			{cookie: "", want: internal.ConnectionState{Reason: "room requires login"}},
+			{cookie: mallory, want: internal.ConnectionState{Reason: "room is restricted to another email domain example.com"}},
+			{cookie: bob, want: internal.ConnectionState{CanConnect: true}},
+		} {
+			response := ts.doJSON(t, http.MethodPost, "/v1/room/"+room["id"]+"/connection-state", map[string]string{"username": "anything"}, http.Header{"Cookie": {tt.cookie}})
+
+			var got internal.ConnectionState
+			json.Unmarshal(response.body, &got)
+			assert.Equal(t, got, tt.want)
+		}
+	})
+
+	t.Run("ownership follows the account", func(t *testing.T) {
+		first, status, permissions := dial("product-owner", alice)
+		assert.Equal(t, status, http.StatusSwitchingProtocols)
+		assert.True(t, permissions.CanLockRoom)
+		first.Close(websocket.StatusNormalClosure, "")
+
+		second, _, permissions := dial("product-owner", alice)
+		defer second.CloseNow()
+		assert.True(t, permissions.CanLockRoom)
+	})
+
+	t.Run("only the allowed domain may join", func(t *testing.T) {
+		_, status, _ := dial("developer", "")
+		assert.Equal(t, status, http.StatusForbidden)
+		_, status, _ = dial("developer", mallory)
+		assert.Equal(t, status, http.StatusForbidden)
+
+		connection, status, permissions := dial("developer", bob)
+		defer connection.CloseNow()
+		assert.Equal(t, status, http.StatusSwitchingProtocols)
+		assert.False(t, permissions.CanLockRoom)
+
+		// the users message of an earlier join may still be on its way
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var bobInRoom map[string]any
+		for bobInRoom == nil {
+			var users struct {
+				Type string           `json:"type"`
+				Data []map[string]any `json:"data"`
+			}
+			if err := wsjson.Read(ctx, connection, &users); err != nil {
+				t.Fatal(err)
+			}
+			for _, user := range users.Data {
+				if users.Type == "users" && user["name"] == "Bob" {
+					bobInRoom = user
+				}
+			}
+		}
+		assert.DeepEqual(t, bobInRoom, map[string]any{
+			"name":     "Bob",
+			"role":     "developer",
+			"isDone":   false,
+			"verified": true,
+			"avatar":   "https://example.com/bob.png",
+		})
+	})
+}
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+// errorEnvelope is the body of every error response, see errors.go.
+type errorEnvelope struct {
+	Error     string `json:"error"`
+	RequestId string `json:"requestId,omitempty"`
+}
+
+// The envelopes the handlers respond with. They only document the responses,
+// the conformance tests keep them in line with the handlers.
+type (
+	roomCreatedResponse struct {
+		Id   string `json:"id"`
+		Code string `json:"code"`
+		Slug string `json:"slug,omitempty"`
+		// OwnerToken is only returned to api keys, which never join the room.
+		OwnerToken string `json:"ownerToken,omitempty"`
+	}
+	roomUpdatedResponse struct {
+		Room       internal.Overview `json:"room"`
+		Visibility string            `json:"visibility"`
+	}
+	roomsResponse struct {
+		Rooms      []internal.Overview `json:"rooms"`
+		NextCursor string              `json:"nextCursor,omitempty"`
+	}
+	ownerTokenResponse struct {
+		OwnerToken string `json:"ownerToken"`
+	}
+	inviteCreatedResponse struct {
+		Token  string          `json:"token"`
+		Invite internal.Invite `json:"invite"`
+	}
+	issuesImportedResponse struct {
+		Imported int `json:"imported"`
+	}
+	auditResponse struct {
+		Entries []internal.AuditEntry `json:"entries"`
+	}
+	exportResponse struct {
+		Room    internal.RoomSnapshot `json:"room"`
+		Details internal.RoomDetails  `json:"details"`
+	}
+	decksResponse struct {
+		Decks   []*internal.GuessConfig `json:"decks"`
+		Default string                  `json:"default"`
+	}
+	teamsResponse struct {
+		Teams []internal.Team `json:"teams"`
+	}
+	teamResponse struct {
+		Team internal.Team `json:"team"`
+	}
+	userResponse struct {
+		User internal.User `json:"user"`
+	}
+	healthResponse struct {
+		Status     string            `json:"status"`
+		SystemInfo map[string]string `json:"systemInfo"`
+	}
+	livenessResponse struct {
+		Status string `json:"status"`
+	}
+	readinessResponse struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	healthDetailsResponse struct {
+		healthResponse
+		Uptime     string            `json:"uptime"`
+		Goroutines int               `json:"goroutines"`
+		Rooms      int               `json:"rooms"`
+		Build      map[string]string `json:"build"`
+		// Tracing tells whether the trace collector is reachable, it is
+		// omitted without tracing.
+		Tracing string `json:"tracing,omitempty"`
+	}
+	apiKeysResponse struct {
+		APIKeys []internal.APIKey `json:"apiKeys"`
+	}
+	apiKeyCreatedResponse struct {
+		APIKey internal.APIKey `json:"apiKey"`
+		// Secret is only shown once.
+		Secret string `json:"secret"`
+	}
+	adminRoomsResponse struct {
+		Rooms    []internal.RoomStatus `json:"rooms"`
+		ReadOnly bool                  `json:"readOnly"`
+	}
+	broadcastResponse struct {
+		Rooms int `json:"rooms"`
+	}
+	readOnlyStatus struct {
+		ReadOnly bool `json:"readOnly"`
+	}
+)
+
+// Ways to authenticate an operation.
+const (
+	authNone = iota
+	// authOptional operations accept a bearer token but work without one.
+	authOptional
+	authBearer
+	authSession
+)
+
+type apiParameter struct {
+	name        string
+	description string
+	required    bool
+	schema      map[string]any
+}
+
+// apiOperation documents a route of routes(). Responses maps the success
+// statuses to a value of the Go type of their body, nil for empty bodies.
+// Every operation may also fail with 429 and 500.
+type apiOperation struct {
+	method      string
+	path        string
+	summary     string
+	description string
+	auth        int
+	query       []apiParameter
+	body        any
+	responses   map[int]any
+	errors      []int
+}
+
+var (
+	stringSchema  = map[string]any{"type": "string"}
+	booleanSchema = map[string]any{"type": "boolean"}
+	integerSchema = map[string]any{"type": "integer"}
+	// freeForm is the schema of the openapi and asyncapi documents.
+	freeForm = map[string]any{}
+)
+
+var pathParameters = map[string]string{
+	"id":   "The id, join code or slug of the room.",
+	"team": "The id of the team.",
+	"key":  "The id of the api key.",
+	"name": "The name of the client.",
+}
+
+var websocketQuery = []apiParameter{
+	{name: "name", description: "The name to join with.", required: true, schema: stringSchema},
+	{name: "ownerToken", description: "Claims ownership of the room.", schema: stringSchema},
+	{name: "invite", description: "An invite token, which replaces the password.", schema: stringSchema},
+}
+
+// inviteQuery lets invited clients inspect private rooms.
+var inviteQuery = []apiParameter{
+	{name: "invite", description: "An invite token to the room.", schema: stringSchema},
+}
+
+const (
+	privateRoom = "Private rooms hide their details, unless the caller sends the owner token, the admin token, an invite or the session of an admitted account."
+	ownerOrKey  = "Needs the owner token, the admin token or an api key with the %s scope."
+	adminOnly   = "Needs the admin token or an api key with the admin scope."
+)
+
+var apiOperations = []apiOperation{
+	{
+		method: http.MethodPost, path: "/v1/room", summary: "Create a room",
+		description: "Api keys need the rooms:create scope and receive the owner token. Logged in users create the room under their verified name.",
+		auth:        authOptional,
+		body:        createRoomInput{},
+		responses:   map[int]any{http.StatusCreated: roomCreatedResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusServiceUnavailable},
+	},
+	{
+		method: http.MethodPatch, path: "/v1/room/:id", summary: "Update the details and visibility of a room",
+		description: fmt.Sprintf(ownerOrKey, internal.ScopeCreateRooms),
+		auth:        authBearer,
+		body:        updateRoomInput{},
+		responses:   map[int]any{http.StatusOK: roomUpdatedResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodPost, path: "/v1/room/:id/connection-state", summary: "Check whether a name may join a room",
+		body:      connectionStateInput{},
+		responses: map[int]any{http.StatusOK: internal.ConnectionState{}},
+		errors:    []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		method: http.MethodPost, path: "/v1/room/:id/invites", summary: "Create an invite",
+		description: fmt.Sprintf(ownerOrKey, internal.ScopeCreateRooms),
+		auth:        authBearer,
+		body:        createInviteInput{},
+		responses:   map[int]any{http.StatusCreated: inviteCreatedResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodPost, path: "/v1/room/:id/owner-token", summary: "Rotate the owner token",
+		description: "Needs the owner token or the admin token.",
+		auth:        authBearer,
+		responses:   map[int]any{http.StatusOK: ownerTokenResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodPost, path: "/v1/room/:id/issues", summary: "Import issues",
+		description: fmt.Sprintf(ownerOrKey, internal.ScopeImportIssues),
+		auth:        authBearer,
+		body:        importIssuesInput{},
+		responses:   map[int]any{http.StatusCreated: issuesImportedResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/room/:id/product-owner", summary: "Join a room as product owner",
+		description: "Upgrades to the websocket protocol described by /v1/asyncapi.json.",
+		query:       websocketQuery,
+		responses:   map[int]any{http.StatusSwitchingProtocols: nil},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/room/:id/developer", summary: "Join a room as developer",
+		description: "Upgrades to the websocket protocol described by /v1/asyncapi.json.",
+		query:       websocketQuery,
+		responses:   map[int]any{http.StatusSwitchingProtocols: nil},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/room/:id/metadata", summary: "Look up a room before joining it",
+		description: privateRoom,
+		query:       inviteQuery,
+		responses:   map[int]any{http.StatusOK: roomMetadata{}},
+		errors:      []int{http.StatusBadRequest},
+	},
+	{
+		method: http.MethodGet, path: "/v1/room/:id/state", summary: "Fetch the state of a room",
+		description: privateRoom,
+		query:       inviteQuery,
+		responses:   map[int]any{http.StatusOK: internal.State{}},
+		errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/room/:id/audit", summary: "Fetch the audit trail of a room",
+		description: fmt.Sprintf(ownerOrKey, internal.ScopeReadExports),
+		auth:        authBearer,
+		query:       []apiParameter{{name: "since", description: "Only the entries after the one with this seq.", schema: integerSchema}},
+		responses:   map[int]any{http.StatusOK: auditResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/room/:id/export", summary: "Export a room",
+		description: fmt.Sprintf(ownerOrKey, internal.ScopeReadExports),
+		auth:        authBearer,
+		responses:   map[int]any{http.StatusOK: exportResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/rooms", summary: "List the public rooms",
+		query: []apiParameter{
+			{name: "limit", description: "The size of the page, 1 to 100.", schema: integerSchema},
+			{name: "cursor", description: "The nextCursor of the previous page.", schema: stringSchema},
+			{name: "sort", schema: map[string]any{"type": "string", "enum": roomSorts}},
+			{name: "active", description: "Only rooms that are, or are not, estimating.", schema: booleanSchema},
+			{name: "minPlayers", schema: integerSchema},
+			{name: "createdAfter", schema: map[string]any{"type": "string", "format": "date-time"}},
+			{name: "tag", schema: stringSchema},
+			{name: "q", description: "Searches title, description and team.", schema: stringSchema},
+		},
+		responses: map[int]any{http.StatusOK: roomsResponse{}},
+		errors:    []int{http.StatusBadRequest},
+	},
+	{
+		method: http.MethodGet, path: "/v1/decks", summary: "List the decks",
+		responses: map[int]any{http.StatusOK: decksResponse{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/teams", summary: "List the teams",
+		responses: map[int]any{http.StatusOK: teamsResponse{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/teams/:team", summary: "Fetch a team",
+		responses: map[int]any{http.StatusOK: teamResponse{}},
+		errors:    []int{http.StatusNotFound},
+	},
+	{
+		method: http.MethodPost, path: "/v1/teams", summary: "Create a team",
+		body:      createTeamInput{},
+		responses: map[int]any{http.StatusCreated: teamResponse{}},
+		errors:    []int{http.StatusBadRequest, http.StatusConflict},
+	},
+	{
+		method: http.MethodPut, path: "/v1/teams/:team", summary: "Replace a team",
+		body:      teamInput{},
+		responses: map[int]any{http.StatusOK: teamResponse{}},
+		errors:    []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/teams/:team", summary: "Delete a team",
+		responses: map[int]any{http.StatusNoContent: nil},
+		errors:    []int{http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/auth/login", summary: "Log in with the identity provider",
+		description: "Responds with 404 unless login is configured. Redirects to the identity provider.",
+		query:       []apiParameter{{name: "redirect", description: "The path to return to after logging in.", schema: stringSchema}},
+		responses:   map[int]any{http.StatusFound: nil},
+		errors:      []int{http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/auth/callback", summary: "Finish logging in",
+		description: "Responds with 404 unless login is configured. Sets the session cookie and redirects back.",
+		query: []apiParameter{
+			{name: "code", schema: stringSchema},
+			{name: "state", schema: stringSchema},
+			{name: "error", schema: stringSchema},
+		},
+		responses: map[int]any{http.StatusSeeOther: nil},
+		errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/auth/me", summary: "Fetch the logged in user",
+		description: "Responds with 404 unless login is configured.",
+		auth:        authSession,
+		responses:   map[int]any{http.StatusOK: userResponse{}},
+		errors:      []int{http.StatusUnauthorized, http.StatusNotFound},
+	},
+	{
+		method: http.MethodPost, path: "/v1/auth/logout", summary: "Log out",
+		description: "Responds with 404 unless login is configured.",
+		responses:   map[int]any{http.StatusNoContent: nil},
+		errors:      []int{http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/health", summary: "Report the version of the server",
+		responses: map[int]any{http.StatusOK: healthResponse{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/health/live", summary: "Liveness probe",
+		responses: map[int]any{http.StatusOK: livenessResponse{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/health/ready", summary: "Readiness probe",
+		description: "Responds with 503 and the failed checks while a dependency is unavailable or the server is draining.",
+		responses:   map[int]any{http.StatusOK: readinessResponse{}, http.StatusServiceUnavailable: readinessResponse{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/health/details", summary: "Report runtime details",
+		description: adminOnly,
+		auth:        authBearer,
+		responses:   map[int]any{http.StatusOK: healthDetailsResponse{}},
+		errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	},
+	{
+		method: http.MethodGet, path: "/v1/api-keys", summary: "List the api keys",
+		description: adminOnly,
+		auth:        authBearer,
+		responses:   map[int]any{http.StatusOK: apiKeysResponse{}},
+		errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	},
+	{
+		method: http.MethodPost, path: "/v1/api-keys", summary: "Create an api key",
+		description: adminOnly,
+		auth:        authBearer,
+		body:        createAPIKeyInput{},
+		responses:   map[int]any{http.StatusCreated: apiKeyCreatedResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/api-keys/:key", summary: "Revoke an api key",
+		description: adminOnly,
+		auth:        authBearer,
+		responses:   map[int]any{http.StatusNoContent: nil},
+		errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/v1/admin/rooms", summary: "List every room",
+		description: adminOnly,
+		auth:        authBearer,
+		responses:   map[int]any{http.StatusOK: adminRoomsResponse{}},
+		errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/admin/rooms/:id", summary: "Close a room",
+		description: adminOnly,
+		auth:        authBearer,
+		responses:   map[int]any{http.StatusNoContent: nil},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/admin/rooms/:id/clients/:name", summary: "Disconnect a client",
+		description: adminOnly,
+		auth:        authBearer,
+		responses:   map[int]any{http.StatusNoContent: nil},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		method: http.MethodPost, path: "/v1/admin/broadcast", summary: "Send a notice to every room",
+		description: adminOnly,
+		auth:        authBearer,
+		body:        internal.Notice{},
+		responses:   map[int]any{http.StatusOK: broadcastResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	},
+	{
+		method: http.MethodPut, path: "/v1/admin/read-only", summary: "Switch the read-only mode",
+		description: adminOnly + " Rooms can't be created while the server is read-only.",
+		auth:        authBearer,
+		body:        readOnlyInput{},
+		responses:   map[int]any{http.StatusOK: readOnlyStatus{}},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	},
+	{
+		method: http.MethodGet, path: "/v1/asyncapi.json", summary: "Fetch the AsyncAPI document of the websocket protocol",
+		responses: map[int]any{http.StatusOK: freeForm},
+	},
+	{
+		method: http.MethodGet, path: "/v1/openapi.json", summary: "Fetch this document",
+		responses: map[int]any{http.StatusOK: freeForm},
+	},
+}
+
+var routeParameter = regexp.MustCompile(`:(\w+)`)
+
+// openAPI returns the OpenAPI document of apiOperations.
+var openAPI = sync.OnceValue(func() map[string]any {
+	generator := internal.NewSchemaGenerator("#/components/schemas/")
+	errorSchema := generator.Of(errorEnvelope{})
+	paths := make(map[string]any)
+	for _, operation := range apiOperations {
+		path := routeParameter.ReplaceAllString(operation.path, "{$1}")
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			item = make(map[string]any)
+			paths[path] = item
+		}
+
+		var parameters []any
+		for _, match := range routeParameter.FindAllStringSubmatch(operation.path, -1) {
+			parameters = append(parameters, map[string]any{
+				"name": match[1], "in": "path", "required": true,
+				"description": pathParameters[match[1]], "schema": stringSchema,
+			})
+		}
+		for _, parameter := range operation.query {
+			parameters = append(parameters, map[string]any{
+				"name": parameter.name, "in": "query", "required": parameter.required,
+				"description": parameter.description, "schema": parameter.schema,
+			})
+		}
+
+		responses := make(map[string]any)
+		for status, body := range operation.responses {
+			response := map[string]any{"description": http.StatusText(status)}
+			if body != nil {
+				response["content"] = map[string]any{"application/json": map[string]any{"schema": generator.Of(body)}}
+			}
+			responses[strconv.Itoa(status)] = response
+		}
+		for _, status := range slices.Concat(operation.errors, []int{http.StatusTooManyRequests, http.StatusInternalServerError}) {
+			responses[strconv.Itoa(status)] = map[string]any{
+				"description": http.StatusText(status),
+				"content":     map[string]any{"application/json": map[string]any{"schema": errorSchema}},
+			}
+		}
+
+		spec := map[string]any{
+			"operationId": operationId(operation),
+			"summary":     operation.summary,
+			"responses":   responses,
+		}
+		if operation.description != "" {
+			spec["description"] = operation.description
+		}
+		if len(parameters) > 0 {
+			spec["parameters"] = parameters
+		}
+		if operation.body != nil {
+			spec["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": generator.Of(operation.body)}},
+			}
+		}
+		switch operation.auth {
+		case authOptional:
+			spec["security"] = []any{map[string]any{}, map[string]any{"bearerAuth": []string{}}}
+		case authBearer:
+			spec["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+		case authSession:
+			spec["security"] = []any{map[string]any{"sessionCookie": []string{}}}
+		}
+		item[strings.ToLower(operation.method)] = spec
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Estimation Poker",
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": generator.Definitions(),
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "An owner token, the admin token or an api key.",
+				},
+				"sessionCookie": map[string]any{"type": "apiKey", "in": "cookie", "name": sessionCookie},
+			},
+		},
+	}
+})
+
+// operationId turns "DELETE /v1/admin/rooms/:id" into "delete-admin-rooms-id".
+func operationId(operation apiOperation) string {
+	id := strings.ToLower(operation.method)
+	for _, segment := range routeSegments.FindAllString(operation.path[len("/v1"):], -1) {
+		id += "-" + segment
+	}
+	return id
+}
+
+var routeSegments = regexp.MustCompile(`[a-z0-9-]+`)
+
+func (app *application) handleFetchOpenAPI(writer http.ResponseWriter, request *http.Request) {
+	err := app.writeJSON(writer, http.StatusOK, openAPI(), nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+// openAPIDocument is openAPI() the way clients see it.
+var openAPIDocument = sync.OnceValue(func() map[string]any {
+	raw, err := json.Marshal(openAPI())
+	if err != nil {
+		panic(err)
+	}
+	var document map[string]any
+	if err := json.Unmarshal(raw, &document); err != nil {
+		panic(err)
+	}
+	return document
+})
+
+// conformingTo validates every response of h to a documented operation
+// against the OpenAPI document. Websocket upgrades are passed through.
+func conformingTo(t *testing.T, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		operation, ok := findOperation(request)
+		if !ok || request.Header.Get("Upgrade") != "" {
+			h.ServeHTTP(writer, request)
+			return
+		}
+
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, request)
+		if err := validateResponse(operation, recorder); err != nil {
+			t.Errorf("%s %s: %v", request.Method, request.URL.Path, err)
+		}
+
+		for key, values := range recorder.Header() {
+			writer.Header()[key] = values
+		}
+		writer.WriteHeader(recorder.Code)
+		writer.Write(recorder.Body.Bytes())
+	})
+}
+
+// findOperation returns the documented operation of request.
+func findOperation(request *http.Request) (map[string]any, bool) {
+	for _, operation := range apiOperations {
+		pattern := "^" + routeParameter.ReplaceAllString(regexp.QuoteMeta(operation.path), `[^/]+`) + "$"
+		if operation.method != request.Method || !regexp.MustCompile(pattern).MatchString(request.URL.Path) {
+			continue
+		}
+		path := routeParameter.ReplaceAllString(operation.path, "{$1}")
+		paths := openAPIDocument()["paths"].(map[string]any)
+		return paths[path].(map[string]any)[strings.ToLower(operation.method)].(map[string]any), true
+	}
+	return nil, false
+}
+
+func validateResponse(operation map[string]any, recorder *httptest.ResponseRecorder) error {
+	response, ok := operation["responses"].(map[string]any)[strconv.Itoa(recorder.Code)].(map[string]any)
+	if !ok {
+		return fmt.Errorf("status %d is not documented", recorder.Code)
+	}
+	content, ok := response["content"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		return fmt.Errorf("content type is %q", got)
+	}
+	var body any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		return err
+	}
+	schema := content["application/json"].(map[string]any)["schema"].(map[string]any)
+	return validateSchema(schema, body, "body")
+}
+
+// validateSchema checks value against the subset of JSON Schema the
+// document uses. Formats are not checked.
+func validateSchema(schema map[string]any, value any, at string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, ok := openAPIDocument()["components"].(map[string]any)["schemas"].(map[string]any)[name].(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: unknown reference %s", at, ref)
+		}
+		return validateSchema(resolved, value, at)
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matches := 0
+		for _, option := range oneOf {
+			if validateSchema(option.(map[string]any), value, at) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: %v matches %d schemas of oneOf", at, value, matches)
+		}
+		return nil
+	}
+	if want, ok := schema["const"]; ok && value != want {
+		return fmt.Errorf("%s: %v is not %v", at, value, want)
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", at, value, enum)
+	}
+
+	switch schema["type"] {
+	case "null":
+		if value != nil {
+			return fmt.Errorf("%s: %v is not null", at, value)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: %v is not a string", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: %v is not a boolean", at, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: %v is not a number", at, value)
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			return fmt.Errorf("%s: %v is not an integer", at, value)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: %v is not an array", at, value)
+		}
+		for i, item := range items {
+			if err := validateSchema(schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: %v is not an object", at, value)
+		}
+		return validateObject(schema, object, at)
+	}
+	return nil
+}
+
+func validateObject(schema map[string]any, object map[string]any, at string) error {
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := object[name.(string)]; !ok {
+			return fmt.Errorf("%s: %s is missing", at, name)
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	for name, field := range object {
+		fieldSchema, ok := properties[name].(map[string]any)
+		if !ok {
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s: %s is not documented", at, name)
+				}
+				continue
+			case map[string]any:
+				fieldSchema = additional
+			default:
+				continue
+			}
+		}
+		if err := validateSchema(fieldSchema, field, at+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema := map[string]any{"$ref": "#/components/schemas/ReadinessResponse"}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{name: "valid", value: `{"status":"ready","checks":{"bus":"ok"}}`},
+		{name: "missing field", value: `{"status":"ready"}`, wantErr: "body: checks is missing"},
+		{name: "undocumented field", value: `{"status":"ready","checks":{},"extra":1}`, wantErr: "body: extra is not documented"},
+		{name: "wrong type", value: `{"status":"ready","checks":{"bus":1}}`, wantErr: "body.checks.bus: 1 is not a string"},
+		{name: "null", value: `null`, wantErr: "body: <nil> is not an object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value any
+			assert.NilError(t, json.Unmarshal([]byte(tt.value), &value))
+
+			err := validateSchema(schema, value, "body")
+
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Equal(t, fmt.Sprint(err), tt.wantErr)
+		})
+	}
+}
+
+func TestOpenAPI_documentsEveryRoute(t *testing.T) {
+	source, err := os.ReadFile("routes.go")
+	assert.NilError(t, err)
+
+	var routes []string
+	for _, match := range regexp.MustCompile(`handle\(http\.Method(\w+), "([^"]+)"`).FindAllStringSubmatch(string(source), -1) {
+		routes = append(routes, strings.ToUpper(match[1])+" "+match[2])
+	}
+	var documented []string
+	for _, operation := range apiOperations {
+		documented = append(documented, operation.method+" "+operation.path)
+	}
+
+	slices.Sort(routes)
+	slices.Sort(documented)
+	assert.DeepEqual(t, documented, routes)
+}
+
+func TestApplication_handleFetchOpenAPI(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	response := ts.get(t, "/v1/openapi.json")
+
+	assert.Equal(t, response.status, http.StatusOK)
+	var document struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NilError(t, json.Unmarshal(response.body, &document))
+	assert.Equal(t, document.OpenAPI, "3.1.0")
+	assert.Equal(t, len(document.Paths["/v1/teams/{team}"]), 3)
+	assert.StringContains(t, string(document.Components.Schemas["ErrorEnvelope"]), `"requestId"`)
+	assert.StringContains(t, string(document.Components.Schemas["CreateRoomInput"]), `"creator"`)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+var errForeignRoom = errors.New("api key did not create this room")
+
+// readOwnedRoom returns the room of the id parameter if the request carries
+// its owner token or the admin token as bearer token, or an api key with
+// scope unless scope is empty. Api keys only act on the rooms they created,
+// unless they have the admin scope. Otherwise it writes the error response
+// and returns false.
+func (app *application) readOwnedRoom(writer http.ResponseWriter, request *http.Request, scope string) (*internal.Room, bool) {
+	roomId, err := app.readIdParam(request)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return nil, false
+	}
+
+	app.mu.RLock()
+	actualRoom, ok := app.rooms[roomId]
+	app.mu.RUnlock()
+	if !ok {
+		app.notFoundResponse(writer, request)
+		return nil, false
+	}
+
+	if key := app.contextGetAPIKey(request); key != nil {
+		if scope == "" || !key.HasScope(scope) {
+			app.missingScopeResponse(writer, request, cmp.Or(scope, "owner"))
+			return nil, false
+		}
+		if actualRoom.CreatorKeyId != key.Id && !slices.Contains(key.Scopes, internal.ScopeAdmin) {
+			app.forbiddenResponse(writer, request, errForeignRoom)
+			return nil, false
+		}
+		return actualRoom, true
+	}
+
+	if app.hasAdminToken(request) {
+		return actualRoom, true
+	}
+	token, ok := app.readBearerToken(request)
+	if !ok || !actualRoom.IsOwnerToken(token) {
+		app.unauthorizedResponse(writer, request)
+		return nil, false
+	}
+	return actualRoom, true
+}
+
+// mayInspect reports whether the caller of request may see the details of
+// room. Private rooms only show them to their owner, invited clients and the
+// accounts they admit.
+func (app *application) mayInspect(request *http.Request, room *internal.Room) bool {
+	if room.Visibility() != internal.VisibilityPrivate || app.hasAdminToken(request) {
+		return true
+	}
+	if key := app.contextGetAPIKey(request); key != nil {
+		return room.CreatorKeyId == key.Id || slices.Contains(key.Scopes, internal.ScopeAdmin)
+	}
+	if token, ok := app.readBearerToken(request); ok && room.IsOwnerToken(token) {
+		return true
+	}
+	if invite, err := app.readInvite(request.URL.Query().Get("invite")); err == nil && invite != nil && invite.RoomId == room.Id {
+		return true
+	}
+	user := app.readSession(request)
+	return user != nil && room.Admits("", user)
+}
+
+func (app *application) handleRotateOwnerToken(writer http.ResponseWriter, request *http.Request) {
+	actualRoom, ok := app.readOwnedRoom(writer, request, "")
+	if !ok {
+		return
+	}
+
+	err := app.writeJSON(writer, http.StatusOK, envelope{"ownerToken": actualRoom.RotateOwnerToken()}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
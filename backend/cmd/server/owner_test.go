@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestApplication_ownerSession(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	created := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester"})
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+	owner, ownerToken := ts.dialOwner(t, room["id"], "Tester")
+	defer owner.CloseNow()
+
+	dial := func(name, token string) (*websocket.Conn, *http.Response, internal.Permissions) {
+		query := url.Values{"name": {name}}
+		if token != "" {
+			query.Set("ownerToken", token)
+		}
+		connection, response, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http")+"/v1/room/"+room["id"]+"/product-owner?"+query.Encode(), nil)
+		var permissions struct {
+			Data internal.Permissions `json:"data"`
+		}
+		if err == nil {
+			assert.NilError(t, wsjson.Read(context.Background(), connection, &permissions))
+		}
+		return connection, response, permissions.Data
+	}
+
+	t.Run("the creator's name alone does not take over the room", func(t *testing.T) {
+		_, response, _ := dial("Tester", "")
+
+		assert.Equal(t, response.StatusCode, http.StatusForbidden)
+	})
+
+	t.Run("an invalid owner token is refused", func(t *testing.T) {
+		_, response, _ := dial("Other", "forged.token")
+
+		assert.Equal(t, response.StatusCode, http.StatusUnauthorized)
+	})
+
+	t.Run("the owner token moves ownership to another session", func(t *testing.T) {
+		connection, _, permissions := dial("Laptop", ownerToken)
+		defer connection.CloseNow()
+
+		assert.True(t, permissions.CanLockRoom)
+		assert.Equal(t, permissions.OwnerToken, ownerToken)
+	})
+
+	t.Run("rotation invalidates the old token", func(t *testing.T) {
+		response := ts.doJSON(t, http.MethodPost, "/v1/room/"+room["id"]+"/owner-token", nil, http.Header{"Authorization": {"Bearer " + ownerToken}})
+		assert.Equal(t, response.status, http.StatusOK)
+		var got struct {
+			OwnerToken string `json:"ownerToken"`
+		}
+		json.Unmarshal(response.body, &got)
+
+		audit := func(token string) int {
+			return ts.getWithHeaders(t, "/v1/room/"+room["id"]+"/audit", http.Header{"Authorization": {"Bearer " + token}}).status
+		}
+		assert.Equal(t, audit(ownerToken), http.StatusUnauthorized)
+		assert.Equal(t, audit(got.OwnerToken), http.StatusOK)
+
+		again := ts.doJSON(t, http.MethodPost, "/v1/room/"+room["id"]+"/owner-token", nil, http.Header{"Authorization": {"Bearer " + ownerToken}})
+		assert.Equal(t, again.status, http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+// handleFetchAsyncAPI serves the AsyncAPI document of the websocket protocol,
+// which is generated from the message types.
+func (app *application) handleFetchAsyncAPI(writer http.ResponseWriter, request *http.Request) {
+	err := app.writeJSON(writer, http.StatusOK, internal.AsyncAPI(), nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+// readReply reads until the ack or error of the command with id.
+func readReply(t *testing.T, connection *websocket.Conn, id string) internal.IncomingWebsocketMessage {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for {
+		var msg internal.IncomingWebsocketMessage
+		if err := wsjson.Read(ctx, connection, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Id == id {
+			return msg
+		}
+	}
+}
+
+func TestApplication_protocol(t *testing.T) {
+	deck, err := internal.NewGuessConfig("1,2,3,5", "1,2,3,5")
+	assert.NilError(t, err)
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.bus = internal.CreateBus()
+	app.destroyRoom = make(chan uuid.UUID, 1)
+	app.decks[defaultDeckName] = deck
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	created := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Alice", "visibility": internal.VisibilityUnlisted})
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+	endpoint := "ws" + strings.TrimPrefix(ts.URL, "http") + "/v1/room/" + room["id"]
+
+	owner, _, err := websocket.Dial(context.Background(), endpoint+"/product-owner?name=Alice", &websocket.DialOptions{
+		Subprotocols: []string{"estimation-poker.v2", internal.ProtocolV1},
+	})
+	assert.NilError(t, err)
+	defer owner.CloseNow()
+	developer, _, err := websocket.Dial(context.Background(), endpoint+"/developer?name=Bob", nil)
+	assert.NilError(t, err)
+	defer developer.CloseNow()
+	readUntil(t, developer, "users")
+
+	t.Run("negotiates the subprotocol", func(t *testing.T) {
+		assert.Equal(t, owner.Subprotocol(), internal.ProtocolV1)
+		assert.Equal(t, developer.Subprotocol(), "")
+	})
+
+	t.Run("acknowledges commands with an id", func(t *testing.T) {
+		assert.NilError(t, wsjson.Write(context.Background(), owner, map[string]any{"id": "1", "type": "estimate", "data": "PROJ-1"}))
+
+		got := readReply(t, owner, "1")
+		assert.Equal(t, got.Type, "ack")
+		assert.Equal(t, string(readUntil(t, developer, "estimate")), `"PROJ-1"`)
+	})
+
+	t.Run("rejects commands with an error", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data any
+			want string
+		}{
+			{name: "not permitted", data: map[string]any{"id": "2", "type": "guess", "data": "1"}, want: `"command is not permitted"`},
+			{name: "invalid data", data: map[string]any{"id": "2", "type": "estimate", "data": 1}, want: `"ticket is invalid"`},
+			{name: "unknown type", data: map[string]any{"id": "2", "type": "shuffle"}, want: `"message not found"`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.NilError(t, wsjson.Write(context.Background(), owner, tt.data))
+
+				got := readReply(t, owner, "2")
+				assert.Equal(t, got.Type, "error")
+				assert.Equal(t, string(got.Data), tt.want)
+			})
+		}
+	})
+
+	t.Run("legacy clients never receive ids, acks or rejections", func(t *testing.T) {
+		assert.NilError(t, wsjson.Write(context.Background(), developer, map[string]any{"id": "3", "type": "estimate", "data": "PROJ-2"}))
+		assert.NilError(t, wsjson.Write(context.Background(), developer, map[string]any{"id": "4", "type": "guess", "data": "no such card"}))
+		assert.NilError(t, wsjson.Write(context.Background(), developer, map[string]any{"id": "5", "type": "guess", "data": "3"}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for {
+			var msg map[string]json.RawMessage
+			assert.NilError(t, wsjson.Read(ctx, developer, &msg))
+			_, hasId := msg["id"]
+			assert.False(t, hasId)
+			assert.False(t, string(msg["type"]) == `"ack"` || string(msg["type"]) == `"error"`)
+			if string(msg["type"]) == `"you-guessed"` {
+				break
+			}
+		}
+	})
+}
+
+func TestApplication_handleFetchAsyncAPI(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	response := ts.get(t, "/v1/asyncapi.json")
+
+	assert.Equal(t, response.status, http.StatusOK)
+	var document struct {
+		AsyncAPI string `json:"asyncapi"`
+		Info     struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Components struct {
+			Messages map[string]json.RawMessage `json:"messages"`
+			Schemas  map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NilError(t, json.Unmarshal(response.body, &document))
+	assert.Equal(t, document.AsyncAPI, "3.0.0")
+	assert.Equal(t, document.Info.Version, internal.ProtocolV1)
+	assert.Equal(t, len(document.Components.Messages), len(internal.Commands)+len(internal.Events))
+	assert.StringContains(t, string(document.Components.Schemas["RevealedGuess"]), `"doSkip"`)
+}
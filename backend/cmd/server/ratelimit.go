@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	limiterCleanupInterval = time.Minute
+	limiterMaxIdle         = 3 * time.Minute
+)
+
+type limitedClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// ipRateLimiter keeps a token bucket per remote address.
+type ipRateLimiter struct {
+	mu      sync.Mutex
+	rps     rate.Limit
+	burst   int
+	clients map[string]*limitedClient
+}
+
+func newIPRateLimiter(rps float64, burst int) *ipRateLimiter {
+	return &ipRateLimiter{
+		rps:     rate.Limit(rps),
+		burst:   burst,
+		clients: make(map[string]*limitedClient),
+	}
+}
+
+// setLimit changes the rate of every known and future client.
+func (limiter *ipRateLimiter) setLimit(rps float64, burst int) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.rps = rate.Limit(rps)
+	limiter.burst = burst
+	for _, client := range limiter.clients {
+		client.limiter.SetLimit(limiter.rps)
+		client.limiter.SetBurst(limiter.burst)
+	}
+}
+
+func (limiter *ipRateLimiter) allow(ip string) bool {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	client, ok := limiter.clients[ip]
+	if !ok {
+		client = &limitedClient{limiter: rate.NewLimiter(limiter.rps, limiter.burst)}
+		limiter.clients[ip] = client
+	}
+	client.lastSeen = time.Now()
+
+	return client.limiter.Allow()
+}
+
+func (limiter *ipRateLimiter) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(limiterCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			limiter.mu.Lock()
+			for ip, client := range limiter.clients {
+				if time.Since(client.lastSeen) > limiterMaxIdle {
+					delete(limiter.clients, ip)
+				}
+			}
+			limiter.mu.Unlock()
+		}
+	}
+}
+
+func (app *application) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// probes must not be throttled and websocket connections are limited
+		// per message instead
+		exempt := strings.HasPrefix(request.URL.Path, "/v1/health") || isWebsocketJoin(request)
+		if app.ipLimiter == nil || !app.currentConfig().Limits.Enabled || exempt {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		if !app.ipLimiter.allow(app.clientIP(request)) {
+			app.rateLimitExceededResponse(writer, request)
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// isWebsocketJoin reports whether request is the opening handshake of a
+// websocket to one of the join routes.
+func isWebsocketJoin(request *http.Request) bool {
+	if request.Method != http.MethodGet || request.Header.Get("Sec-WebSocket-Key") == "" ||
+		!strings.EqualFold(request.Header.Get("Upgrade"), "websocket") ||
+		!strings.Contains(strings.ToLower(request.Header.Get("Connection")), "upgrade") {
+		return false
+	}
+	for _, pattern := range []string{"/v1/room/*/developer", "/v1/room/*/product-owner"} {
+		if matched, _ := path.Match(pattern, request.URL.Path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the caller. Behind a trusted reverse proxy
+// the last X-Forwarded-For entry is used, since that is the one the proxy
+// appended itself.
+func (app *application) clientIP(request *http.Request) string {
+	if app.currentConfig().Limits.TrustProxy {
+		if forwarded := request.Header.Get("X-Forwarded-For"); forwarded != "" {
+			entries := strings.Split(forwarded, ",")
+			return strings.TrimSpace(entries[len(entries)-1])
+		}
+	}
+
+	ip, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return ip
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestIPRateLimiter_allow(t *testing.T) {
+	limiter := newIPRateLimiter(0.001, 2)
+
+	assert.True(t, limiter.allow("10.0.0.1"))
+	assert.True(t, limiter.allow("10.0.0.1"))
+	assert.False(t, limiter.allow("10.0.0.1"))
+	assert.True(t, limiter.allow("10.0.0.2"))
+}
+
+func TestApplication_rateLimit(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.config.Limits.Enabled = true
+	app.ipLimiter = newIPRateLimiter(0.001, 1)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	first := ts.get(t, "/v1/rooms")
+	second := ts.get(t, "/v1/rooms")
+	health := ts.get(t, "/v1/health")
+	handshake := http.Header{"Upgrade": {"websocket"}, "Connection": {"Upgrade"}, "Sec-WebSocket-Key": {"dGhlIHNhbXBsZSBub25jZQ=="}, "Sec-WebSocket-Version": {"13"}}
+	upgrade := ts.getWithHeaders(t, "/v1/room/9c874aaa-c628-4688-a72d-0b1afc708a7d/developer?name=Dev", handshake)
+	createRoom := ts.doJSON(t, http.MethodPost, "/v1/room", map[string]any{"name": "Paula"}, handshake)
+
+	var got envelope
+	json.Unmarshal(second.body, &got)
+
+	assert.Equal(t, first.status, http.StatusOK)
+	assert.Equal(t, second.status, http.StatusTooManyRequests)
+	assert.DeepEqual(t, got, envelope{"error": "rate limit exceeded", "requestId": testRequestId})
+	assert.Equal(t, health.status, http.StatusOK)
+	assert.Equal(t, upgrade.status, http.StatusNotFound)
+	assert.Equal(t, createRoom.status, http.StatusTooManyRequests)
+}
+
+func TestIPRateLimiter_setLimit(t *testing.T) {
+	limiter := newIPRateLimiter(0.001, 1)
+	assert.True(t, limiter.allow("10.0.0.1"))
+	assert.False(t, limiter.allow("10.0.0.1"))
+
+	limiter.setLimit(0.001, 3)
+
+	assert.Equal(t, limiter.clients["10.0.0.1"].limiter.Burst(), 3)
+	assert.True(t, limiter.allow("10.0.0.2"))
+	assert.True(t, limiter.allow("10.0.0.2"))
+	assert.True(t, limiter.allow("10.0.0.2"))
+	assert.False(t, limiter.allow("10.0.0.2"))
+}
+
+func TestApplication_rateLimitDisabled(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.ipLimiter = newIPRateLimiter(0.001, 1)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	first := ts.get(t, "/v1/rooms")
+	second := ts.get(t, "/v1/rooms")
+
+	assert.Equal(t, first.status, http.StatusOK)
+	assert.Equal(t, second.status, http.StatusOK)
+}
+
+func TestApplication_clientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		forwarded  string
+		want       string
+	}{
+		{
+			name:       "remote address",
+			trustProxy: false,
+			forwarded:  "1.1.1.1",
+			want:       "192.0.2.1",
+		},
+		{
+			name:       "last forwarded entry behind trusted proxy",
+			trustProxy: true,
+			forwarded:  "1.1.1.1, 2.2.2.2",
+			want:       "2.2.2.2",
+		},
+		{
+			name:       "remote address behind trusted proxy without header",
+			trustProxy: true,
+			forwarded:  "",
+			want:       "192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+			app.config.Limits.TrustProxy = tt.trustProxy
+			request := httptest.NewRequest(http.MethodGet, "/v1/rooms", nil)
+			request.Header.Set("X-Forwarded-For", tt.forwarded)
+
+			assert.Equal(t, app.clientIP(request), tt.want)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// reloadableSections are the top level config keys which are applied by
+// reload, every other change only takes effect after a restart.
+var reloadableSections = []string{"deck", "decks", "limits", "rooms", "log_level", "cors"}
+
+// secretKeys are never written to the log, a change is only reported.
+var secretKeys = []string{"auth.admin_token", "auth.token_secret", "oidc.client_secret"}
+
+type configChange struct {
+	key string
+	old string
+	new string
+}
+
+// currentConfig returns a copy of the config which is safe to use while a
+// reload happens concurrently.
+func (app *application) currentConfig() config {
+	app.configMu.RLock()
+	defer app.configMu.RUnlock()
+	return app.config
+}
+
+// reload loads the configuration again and applies the reloadable sections.
+// Rooms which already exist keep their deck, new decks and defaults are only
+// used for rooms created afterwards. An invalid configuration is rejected as a
+// whole and the server keeps running with the previous one.
+func (app *application) reload() error {
+	cfg, err := app.loadConfig()
+	if err != nil {
+		return fmt.Errorf("reload config: %w", err)
+	}
+	decks, err := newDecks(cfg)
+	if err != nil {
+		return fmt.Errorf("reload config: %w", err)
+	}
+	level, err := cfg.logLevel()
+	if err != nil {
+		return fmt.Errorf("reload config: %w", err)
+	}
+
+	app.configMu.Lock()
+	changes := diffConfig(app.config, cfg)
+	app.config.Deck = cfg.Deck
+	app.config.Decks = cfg.Decks
+	app.config.Limits = cfg.Limits
+	app.config.Rooms = cfg.Rooms
+	app.config.LogLevel = cfg.LogLevel
+	app.config.CORS = cfg.CORS
+	app.configMu.Unlock()
+
+	app.logLevel.Set(level)
+	if app.ipLimiter != nil {
+		app.ipLimiter.setLimit(cfg.Limits.RPS, cfg.Limits.Burst)
+	}
+
+	messageLimit := rate.Limit(cfg.Limits.MessageRPS)
+	if !cfg.Limits.Enabled {
+		messageLimit = rate.Inf
+	}
+	app.mu.Lock()
+	app.decks = decks
+	rooms := slices.Collect(maps.Values(app.rooms))
+	app.mu.Unlock()
+	for _, room := range rooms {
+		room.SetMessageLimit(messageLimit, cfg.Limits.MessageBurst)
+	}
+
+	for _, change := range changes {
+		if !isReloadable(change.key) {
+			app.logger.Warn("config change requires a restart", "key", change.key)
+			continue
+		}
+		app.logger.Info("config changed", "key", change.key, "old", change.old, "new", change.new)
+	}
+	app.logger.Info("reloaded config", "changes", len(changes))
+	return nil
+}
+
+func isReloadable(key string) bool {
+	section, _, _ := strings.Cut(key, ".")
+	return slices.Contains(reloadableSections, section)
+}
+
+// diffConfig returns every setting which differs between before and after,
+// keyed by its path in the config file, e.g. limits.rps.
+func diffConfig(before, after config) []configChange {
+	oldValues := make(map[string]string)
+	newValues := make(map[string]string)
+	flattenConfig("", reflect.ValueOf(before), oldValues)
+	flattenConfig("", reflect.ValueOf(after), newValues)
+
+	keys := slices.Collect(maps.Keys(oldValues))
+	for key := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var changes []configChange
+	for _, key := range keys {
+		oldValue, newValue := oldValues[key], newValues[key]
+		if oldValue == newValue {
+			continue
+		}
+		if slices.Contains(secretKeys, key) {
+			oldValue, newValue = "[redacted]", "[redacted]"
+		}
+		changes = append(changes, configChange{key: key, old: oldValue, new: newValue})
+	}
+	return changes
+}
+
+func flattenConfig(prefix string, value reflect.Value, out map[string]string) {
+	switch value.Kind() {
+	case reflect.Struct:
+		for i := range value.NumField() {
+			field := value.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			flattenConfig(joinKey(prefix, name), value.Field(i), out)
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			flattenConfig(joinKey(prefix, fmt.Sprint(key.Interface())), value.MapIndex(key), out)
+		}
+	default:
+		out[prefix] = fmt.Sprint(value.Interface())
+	}
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
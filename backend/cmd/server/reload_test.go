@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestApplication_reload(t *testing.T) {
+	var logs bytes.Buffer
+	cfg, err := loadConfig(nil, envFrom(nil))
+	assert.NilError(t, err)
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.logger = slog.New(slog.NewTextHandler(&logs, nil))
+	app.config = cfg
+	app.ipLimiter = newIPRateLimiter(cfg.Limits.RPS, cfg.Limits.Burst)
+	app.decks, err = newDecks(cfg)
+	assert.NilError(t, err)
+
+	path := writeConfigFile(t, `
+port = 9000
+log_level = "debug"
+
+[limits]
+rps = 30
+message_rps = 20
+
+[cors]
+trusted_origins = ["https://poker.example.com"]
+
+[decks.team]
+label = "Team"
+guesses = "1,2,?"
+descriptions = "S,M,Unsure"
+`)
+	app.loadConfig = func() (config, error) {
+		return loadConfig([]string{"-config", path}, envFrom(nil))
+	}
+
+	err = app.reload()
+
+	assert.NilError(t, err)
+	got := app.currentConfig()
+	assert.Equal(t, got.Port, 8080)
+	assert.Equal(t, got.Limits.RPS, 30.0)
+	assert.Equal(t, got.Limits.MessageRPS, 20.0)
+	assert.DeepEqual(t, got.CORS.TrustedOrigins, []string{"https://poker.example.com"})
+	assert.Equal(t, app.logLevel.Level(), slog.LevelDebug)
+	assert.Equal(t, app.ipLimiter.rps, rate.Limit(30))
+	assert.Equal(t, app.decks["team"].Label, "Team")
+	assert.StringContains(t, logs.String(), `level=WARN msg="config change requires a restart" key=port`)
+	assert.StringContains(t, logs.String(), `msg="config changed" key=limits.rps old=10 new=30`)
+	assert.StringContains(t, logs.String(), `msg="config changed" key=decks.team.label old="" new=Team`)
+}
+
+func TestApplication_reloadKeepsPreviousConfigOnError(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.config.Limits.RPS = 2
+	app.loadConfig = func() (config, error) {
+		return config{}, errors.New("broken")
+	}
+
+	err := app.reload()
+
+	assert.Equal(t, err.Error(), "reload config: broken")
+	assert.Equal(t, app.currentConfig().Limits.RPS, 2.0)
+	assert.Equal(t, app.decks[defaultDeckName].Name, defaultDeckName)
+}
+
+func TestDiffConfig(t *testing.T) {
+	before := defaultConfig()
+	after := defaultConfig()
+	after.Limits.Burst = 8
+	after.Auth.AdminToken = "secret"
+	after.CORS.TrustedOrigins = []string{"https://a.example.com"}
+	delete(after.Decks, "hours")
+
+	got := diffConfig(before, after)
+
+	assert.DeepEqual(t, got, []configChange{
+		{key: "auth.admin_token", old: "[redacted]", new: "[redacted]"},
+		{key: "cors.trusted_origins", old: "[]", new: "[https://a.example.com]"},
+		{key: "decks.hours.descriptions", old: "1h,2h,4h,1 day,2 days,3 days,1 week", new: ""},
+		{key: "decks.hours.guesses", old: "1,2,4,8,16,24,40", new: ""},
+		{key: "decks.hours.label", old: "Hours", new: ""},
+		{key: "limits.burst", old: "20", new: "8"},
+	})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ambiguous characters like I, O, 0 and 1 are left out on purpose
+	roomCodeLetters  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	roomCodeDigits   = "23456789"
+	roomCodeAttempts = 10
+)
+
+var (
+	validRoomCode = regexp.MustCompile(`^[a-z]{4}-[0-9]{4}$`)
+	validSlug     = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,38}[a-z0-9]$`)
+
+	errSlugTaken = errors.New("slug is already taken")
+)
+
+// roomAliases maps join codes and vanity slugs to room ids. Aliases are
+// stored lower case, so they can be typed in any case. It has its own lock
+// since ids are resolved with and without app.mu held.
+type roomAliases struct {
+	mu      sync.RWMutex
+	ids     map[string]uuid.UUID
+	aliases map[uuid.UUID][]string
+}
+
+func (aliases *roomAliases) resolve(alias string) (uuid.UUID, bool) {
+	aliases.mu.RLock()
+	defer aliases.mu.RUnlock()
+	id, ok := aliases.ids[strings.ToLower(alias)]
+	return id, ok
+}
+
+// add registers alias for id and reports false if it is taken already.
+func (aliases *roomAliases) add(alias string, id uuid.UUID) bool {
+	aliases.mu.Lock()
+	defer aliases.mu.Unlock()
+
+	if aliases.ids == nil {
+		aliases.ids = make(map[string]uuid.UUID)
+		aliases.aliases = make(map[uuid.UUID][]string)
+	}
+	alias = strings.ToLower(alias)
+	if _, ok := aliases.ids[alias]; ok {
+		return false
+	}
+	aliases.ids[alias] = id
+	aliases.aliases[id] = append(aliases.aliases[id], alias)
+	return true
+}
+
+// removeRoom frees every alias of the room with id.
+func (aliases *roomAliases) removeRoom(id uuid.UUID) {
+	aliases.mu.Lock()
+	defer aliases.mu.Unlock()
+
+	for _, alias := range aliases.aliases[id] {
+		delete(aliases.ids, alias)
+	}
+	delete(aliases.aliases, id)
+}
+
+// newRoomCode registers a random join code like ABCD-2345 for id.
+func (aliases *roomAliases) newRoomCode(id uuid.UUID) (string, error) {
+	for range roomCodeAttempts {
+		code, err := randomRoomCode()
+		if err != nil {
+			return "", err
+		}
+		if aliases.add(code, id) {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("could not find a free room code after %d attempts", roomCodeAttempts)
+}
+
+func randomRoomCode() (string, error) {
+	var code strings.Builder
+	for i := range 9 {
+		if i == 4 {
+			code.WriteByte('-')
+			continue
+		}
+		alphabet := roomCodeLetters
+		if i > 4 {
+			alphabet = roomCodeDigits
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(alphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+func validateSlug(slug string) error {
+	if !validSlug.MatchString(slug) || validRoomCode.MatchString(slug) {
+		return fmt.Errorf("slug must be 3 to 40 lowercase letters, digits or dashes and must not look like a room code")
+	}
+	if _, err := uuid.Parse(slug); err == nil {
+		return errors.New("slug must not be a uuid")
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestRandomRoomCode(t *testing.T) {
+	format := regexp.MustCompile(`^[A-HJ-NP-Z]{4}-[2-9]{4}$`)
+
+	for range 100 {
+		code, err := randomRoomCode()
+
+		assert.NilError(t, err)
+		assert.True(t, format.MatchString(code))
+	}
+}
+
+func TestRoomAliases(t *testing.T) {
+	var aliases roomAliases
+	first, second := uuid.New(), uuid.New()
+
+	assert.True(t, aliases.add("ABCD-2345", first))
+	assert.True(t, aliases.add("sprint-42", first))
+	assert.False(t, aliases.add("abcd-2345", second))
+
+	got, ok := aliases.resolve("abcd-2345")
+	assert.True(t, ok)
+	assert.Equal(t, got, first)
+	got, ok = aliases.resolve("Sprint-42")
+	assert.True(t, ok)
+	assert.Equal(t, got, first)
+
+	aliases.removeRoom(first)
+
+	_, ok = aliases.resolve("ABCD-2345")
+	assert.False(t, ok)
+	_, ok = aliases.resolve("sprint-42")
+	assert.False(t, ok)
+	assert.True(t, aliases.add("sprint-42", second))
+}
+
+func TestValidateSlug(t *testing.T) {
+	tests := []struct {
+		slug    string
+		wantErr bool
+	}{
+		{slug: "sprint-42"},
+		{slug: "abc"},
+		{slug: "ab", wantErr: true},
+		{slug: "-sprint", wantErr: true},
+		{slug: "Sprint", wantErr: true},
+		{slug: "sprint_42", wantErr: true},
+		{slug: "abcd-1234", wantErr: true},
+		{slug: "9c874aaa-c628-4688-a72d-0b1afc708a7d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.slug, func(t *testing.T) {
+			err := validateSlug(tt.slug)
+
+			assert.Equal(t, err != nil, tt.wantErr)
+		})
+	}
+}
+
+func TestApplication_createNewRoomWithSlug(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	response := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester", "slug": "team-rocket"})
+	var created map[string]string
+	json.Unmarshal(response.body, &created)
+
+	assert.Equal(t, response.status, http.StatusCreated)
+	assert.Equal(t, created["slug"], "team-rocket")
+	assert.True(t, regexp.MustCompile(`^[A-Z]{4}-[0-9]{4}$`).MatchString(created["code"]))
+
+	for _, alias := range []string{created["id"], created["code"], created["slug"], "TEAM-ROCKET"} {
+		t.Run("resolves "+alias, func(t *testing.T) {
+			metadata := ts.get(t, "/v1/room/"+alias+"/metadata")
+
+			var got envelope
+			json.Unmarshal(metadata.body, &got)
+			assert.DeepEqual(t, got, envelope{"exists": true, "isLocked": false, "visibility": "public"})
+		})
+	}
+
+	t.Run("slug is taken", func(t *testing.T) {
+		response := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester", "slug": "team-rocket"})
+
+		var got envelope
+		json.Unmarshal(response.body, &got)
+		assert.Equal(t, response.status, http.StatusConflict)
+		assert.DeepEqual(t, got, envelope{"error": "slug is already taken", "requestId": testRequestId})
+	})
+
+	t.Run("invalid slug", func(t *testing.T) {
+		response := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester", "slug": "Team Rocket"})
+
+		assert.Equal(t, response.status, http.StatusBadRequest)
+	})
+
+	t.Run("aliases are freed with the room", func(t *testing.T) {
+		id := uuid.MustParse(created["id"])
+		app.mu.Lock()
+		delete(app.rooms, id)
+		app.mu.Unlock()
+		app.aliases.removeRoom(id)
+
+		metadata := ts.get(t, "/v1/room/"+created["code"]+"/metadata")
+
+		assert.Equal(t, metadata.status, http.StatusBadRequest)
+	})
+}
@@ -4,20 +4,61 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
 )
 
 func (app *application) routes() http.Handler {
 	router := httprouter.New()
-	router.HandlerFunc(http.MethodPost, "/v1/room", app.createNewRoom)
-	router.HandlerFunc(http.MethodPost, "/v1/room/:id/connection-state", app.handleConnectionState)
+	router.NotFound = http.HandlerFunc(app.notFoundResponse)
+	handle := func(method, path string, handler http.HandlerFunc) {
+		router.HandlerFunc(method, path, app.withRoute(path, handler))
+	}
+
+	handle(http.MethodPost, "/v1/room", app.requireScope(internal.ScopeCreateRooms, app.createNewRoom))
+	handle(http.MethodPost, "/v1/room/:id/connection-state", app.handleConnectionState)
+	handle(http.MethodPost, "/v1/room/:id/invites", app.handleCreateInvite)
+	handle(http.MethodPost, "/v1/room/:id/owner-token", app.handleRotateOwnerToken)
+	handle(http.MethodPost, "/v1/room/:id/issues", app.handleImportIssues)
+	handle(http.MethodPatch, "/v1/room/:id", app.handleUpdateRoomDetails)
+
+	handle(http.MethodGet, "/v1/room/:id/product-owner", app.withRequiredQueryParam("name", app.handleWs))
+	handle(http.MethodGet, "/v1/rooms", app.handleFetchActiveRooms)
+	handle(http.MethodGet, "/v1/decks", app.handleFetchDecks)
+	handle(http.MethodGet, "/v1/teams", app.handleFetchTeams)
+	handle(http.MethodGet, "/v1/teams/:team", app.handleFetchTeam)
+	handle(http.MethodPost, "/v1/teams", app.handleCreateTeam)
+	handle(http.MethodPut, "/v1/teams/:team", app.handleUpdateTeam)
+	handle(http.MethodDelete, "/v1/teams/:team", app.handleDeleteTeam)
+	handle(http.MethodGet, "/v1/room/:id/metadata", app.handleFetchRoomMetadata)
+	handle(http.MethodGet, "/v1/room/:id/developer", app.withRequiredQueryParam("name", app.handleWs))
+	handle(http.MethodGet, "/v1/room/:id/state", app.handleFetchRoomState)
+	handle(http.MethodGet, "/v1/room/:id/audit", app.handleFetchRoomAudit)
+	handle(http.MethodGet, "/v1/room/:id/export", app.handleExportRoom)
+	handle(http.MethodGet, "/v1/asyncapi.json", app.handleFetchAsyncAPI)
+	handle(http.MethodGet, "/v1/openapi.json", app.handleFetchOpenAPI)
+
+	if app.oidc != nil {
+		handle(http.MethodGet, "/v1/auth/login", app.handleLogin)
+		handle(http.MethodGet, "/v1/auth/callback", app.handleLoginCallback)
+		handle(http.MethodGet, "/v1/auth/me", app.handleCurrentUser)
+		handle(http.MethodPost, "/v1/auth/logout", app.handleLogout)
+	}
+
+	handle(http.MethodGet, "/v1/health", app.healthcheckHandler)
+	handle(http.MethodGet, "/v1/health/live", app.livenessHandler)
+	handle(http.MethodGet, "/v1/health/ready", app.readinessHandler)
+	handle(http.MethodGet, "/v1/health/details", app.requireAdmin(app.healthDetailsHandler))
 
-	router.HandlerFunc(http.MethodGet, "/v1/room/:id/product-owner", app.withRequiredQueryParam("name", app.handleWs))
-	router.HandlerFunc(http.MethodGet, "/v1/rooms", app.handleFetchActiveRooms)
-	router.HandlerFunc(http.MethodGet, "/v1/room/:id/metadata", app.handleFetchRoomMetadata)
-	router.HandlerFunc(http.MethodGet, "/v1/room/:id/developer", app.withRequiredQueryParam("name", app.handleWs))
-	router.HandlerFunc(http.MethodGet, "/v1/room/:id/state", app.handleFetchRoomState)
+	handle(http.MethodGet, "/v1/api-keys", app.requireAdmin(app.handleFetchAPIKeys))
+	handle(http.MethodPost, "/v1/api-keys", app.requireAdmin(app.handleCreateAPIKey))
+	handle(http.MethodDelete, "/v1/api-keys/:key", app.requireAdmin(app.handleDeleteAPIKey))
 
-	router.HandlerFunc(http.MethodGet, "/v1/health", app.healthcheckHandler)
+	handle(http.MethodGet, "/v1/admin/rooms", app.requireAdmin(app.handleAdminRooms))
+	handle(http.MethodDelete, "/v1/admin/rooms/:id", app.requireAdmin(app.handleAdminCloseRoom))
+	handle(http.MethodDelete, "/v1/admin/rooms/:id/clients/:name", app.requireAdmin(app.handleAdminDisconnect))
+	handle(http.MethodPost, "/v1/admin/broadcast", app.requireAdmin(app.handleAdminBroadcast))
+	handle(http.MethodPut, "/v1/admin/read-only", app.requireAdmin(app.handleSetReadOnly))
 
-	return app.recoverPanic(router)
+	return app.logRequest(app.traceRequest(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(router))))))
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+	"github.com/Hydoc/estimation-poker/backend/pkg/client"
+)
+
+func receive[T any](t *testing.T, events chan T) T {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return *new(T)
+	}
+}
+
+// TestClientSDK plays a round with the public client package, which must
+// stay in line with the messages of the server.
+func TestClientSDK(t *testing.T) {
+	deck, err := internal.NewGuessConfig("1,2,3,5", "1,2,3,5")
+	assert.NilError(t, err)
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.bus = internal.CreateBus()
+	app.destroyRoom = make(chan uuid.UUID, 1)
+	app.decks[defaultDeckName] = deck
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+	ctx := context.Background()
+	api := client.New(ts.URL)
+
+	room, err := api.CreateRoom(ctx, client.CreateRoomRequest{Creator: "Alice", RoomDetails: client.RoomDetails{Title: "Sprint 12"}})
+	assert.NilError(t, err)
+
+	var (
+		permissions = make(chan client.Permissions, 4)
+		reveals     = make(chan []client.Guess, 1)
+		statistics  = make(chan client.Statistics, 1)
+		estimates   = make(chan string, 1)
+		guesses     = make(chan string, 1)
+	)
+	owner, err := client.Dial(ctx, ts.URL, client.DialOptions{Room: room.Code, Name: "Alice", Role: client.RoleProductOwner}, client.Handlers{
+		Permissions: func(got client.Permissions) { permissions <- got },
+		Reveal:      func(got []client.Guess) { reveals <- got },
+		Statistics:  func(got client.Statistics) { statistics <- got },
+	})
+	assert.NilError(t, err)
+	go owner.Run(ctx)
+	defer owner.Close()
+	assert.True(t, receive(t, permissions).CanLockRoom)
+
+	developer, err := client.Dial(ctx, ts.URL, client.DialOptions{Room: room.Id.String(), Name: "Bob"}, client.Handlers{
+		Estimate:   func(ticket string) { estimates <- ticket },
+		YouGuessed: func(cardId string) { guesses <- cardId },
+	})
+	assert.NilError(t, err)
+	go developer.Run(ctx)
+	defer developer.Close()
+
+	state, err := api.RoomState(ctx, room.Code)
+	assert.NilError(t, err)
+	assert.Equal(t, state.Title, "Sprint 12")
+	assert.Equal(t, len(state.PossibleGuesses), 4)
+
+	assert.NilError(t, owner.Estimate(ctx, "PROJ-1"))
+	assert.Equal(t, receive(t, estimates), "PROJ-1")
+	assert.NilError(t, developer.Guess(ctx, state.PossibleGuesses[2].Id))
+	assert.Equal(t, receive(t, guesses), state.PossibleGuesses[2].Id)
+	assert.True(t, errors.Is(developer.Reveal(ctx), client.ErrRejected))
+	assert.NilError(t, owner.Reveal(ctx))
+
+	revealed := receive(t, reveals)
+	assert.Equal(t, len(revealed), 1)
+	assert.Equal(t, revealed[0].Name, "Bob")
+	assert.Equal(t, revealed[0].Guess, 3)
+	assert.Equal(t, revealed[0].Card.Id, state.PossibleGuesses[2].Id)
+	stats := receive(t, statistics)
+	assert.Equal(t, stats.Votes, 1)
+	assert.Equal(t, *stats.Average, 3.0)
+
+	trail, err := api.WithToken(owner.OwnerToken()).AuditTrail(ctx, room.Code)
+	assert.NilError(t, err)
+	var actions []string
+	for _, entry := range trail {
+		actions = append(actions, entry.Action)
+	}
+	assert.DeepEqual(t, actions, []string{internal.AuditDetails, internal.AuditJoin, internal.AuditJoin, internal.AuditEstimate, internal.AuditReveal})
+}
@@ -14,11 +14,11 @@ import (
 
 func (app *application) serve() error {
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", app.config.port),
+		Addr:         fmt.Sprintf(":%d", app.config.Port),
 		Handler:      app.routes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  app.config.Timeouts.Idle,
+		ReadTimeout:  app.config.Timeouts.Read,
+		WriteTimeout: app.config.Timeouts.Write,
 		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
 	}
 
@@ -26,10 +26,27 @@ func (app *application) serve() error {
 
 	go func() {
 		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
-		s := <-quit
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+
+		var s os.Signal
+		for s == nil {
+			select {
+			case <-reload:
+				if err := app.reload(); err != nil {
+					app.logger.Error("keeping previous config", "error", err)
+				}
+			case s = <-quit:
+			}
+		}
 		app.logger.Info("shutting down server", "signal", s.String())
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		app.draining.Store(true)
+		select {
+		case <-time.After(app.config.Timeouts.Drain):
+		case <-quit:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), app.config.Timeouts.Shutdown)
 		defer cancel()
 
 		shutdownError <- srv.Shutdown(ctx)
@@ -38,8 +55,12 @@ func (app *application) serve() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	go app.listenForRoomDestroy(ctx)
+	go app.runJanitor(ctx)
+	if app.ipLimiter != nil {
+		go app.ipLimiter.cleanup(ctx)
+	}
 
-	app.logger.Info("listening", "port", app.config.port)
+	app.logger.Info("listening", "port", app.config.Port)
 
 	err := srv.ListenAndServe()
 	if !errors.Is(err, http.ErrServerClosed) {
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+)
+
+type teamInput struct {
+	Name     string                `json:"name"`
+	Deck     string                `json:"deck"`
+	Members  []internal.TeamMember `json:"members"`
+	Settings internal.TeamSettings `json:"settings"`
+}
+
+type createTeamInput struct {
+	Id string `json:"id"`
+	teamInput
+}
+
+// team validates the input as the team with id. Decks are checked against
+// the decks the server currently offers.
+func (app *application) team(id string, input teamInput) (internal.Team, error) {
+	team := internal.Team{
+		Id:       id,
+		Name:     input.Name,
+		Deck:     input.Deck,
+		Members:  input.Members,
+		Settings: input.Settings,
+	}.Normalize()
+
+	errs := []error{team.Validate()}
+	if team.Deck != "" {
+		app.mu.RLock()
+		_, ok := app.decks[team.Deck]
+		app.mu.RUnlock()
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown deck %q", team.Deck))
+		}
+	}
+	if team.Settings.AllowedDomain != "" && !validDomain.MatchString(team.Settings.AllowedDomain) {
+		errs = append(errs, fmt.Errorf("allowedDomain %q is not a valid domain", team.Settings.AllowedDomain))
+	}
+	return team, errors.Join(errs...)
+}
+
+func (app *application) handleFetchTeams(writer http.ResponseWriter, request *http.Request) {
+	teams, err := app.teams.Teams()
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+		return
+	}
+
+	err = app.writeJSON(writer, http.StatusOK, envelope{"teams": teams}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleFetchTeam(writer http.ResponseWriter, request *http.Request) {
+	team, ok := app.readTeam(writer, request)
+	if !ok {
+		return
+	}
+
+	err := app.writeJSON(writer, http.StatusOK, envelope{"team": team}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleCreateTeam(writer http.ResponseWriter, request *http.Request) {
+	var input createTeamInput
+	err := app.readJSON(writer, request, &input)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	if !validSlug.MatchString(input.Id) {
+		app.badRequestResponse(writer, request, errors.New("id must be 3 to 40 lowercase letters, digits or dashes"))
+		return
+	}
+	team, err := app.team(input.Id, input.teamInput)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	team.Created = time.Now().UTC()
+	team.Updated = team.Created
+	err = app.teams.CreateTeam(team)
+	switch {
+	case errors.Is(err, internal.ErrTeamExists):
+		app.conflictResponse(writer, request, err)
+		return
+	case err != nil:
+		app.serverErrorResponse(writer, request, err)
+		return
+	}
+
+	err = app.writeJSON(writer, http.StatusCreated, envelope{"team": team}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleUpdateTeam(writer http.ResponseWriter, request *http.Request) {
+	existing, ok := app.readTeam(writer, request)
+	if !ok {
+		return
+	}
+
+	var input teamInput
+	err := app.readJSON(writer, request, &input)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	team, err := app.team(existing.Id, input)
+	if err != nil {
+		app.badRequestResponse(writer, request, err)
+		return
+	}
+
+	team.Created = existing.Created
+	team.Updated = time.Now().UTC()
+	err = app.teams.UpdateTeam(team)
+	switch {
+	case errors.Is(err, internal.ErrTeamNotFound):
+		app.notFoundResponse(writer, request)
+		return
+	case err != nil:
+		app.serverErrorResponse(writer, request, err)
+		return
+	}
+
+	err = app.writeJSON(writer, http.StatusOK, envelope{"team": team}, nil)
+	if err != nil {
+		app.serverErrorResponse(writer, request, err)
+	}
+}
+
+func (app *application) handleDeleteTeam(writer http.ResponseWriter, request *http.Request) {
+	err := app.teams.DeleteTeam(httprouter.ParamsFromContext(request.Context()).ByName("team"))
+	switch {
+	case errors.Is(err, internal.ErrTeamNotFound):
+		app.notFoundResponse(writer, request)
+	case err != nil:
+		app.serverErrorResponse(writer, request, err)
+	default:
+		writer.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// readTeam looks up the team named in the path and responds with 404 if
+// there is none.
+func (app *application) readTeam(writer http.ResponseWriter, request *http.Request) (internal.Team, bool) {
+	team, err := app.teams.Team(httprouter.ParamsFromContext(request.Context()).ByName("team"))
+	switch {
+	case errors.Is(err, internal.ErrTeamNotFound):
+		app.notFoundResponse(writer, request)
+		return internal.Team{}, false
+	case err != nil:
+		app.serverErrorResponse(writer, request, err)
+		return internal.Team{}, false
+	}
+	return team, true
+}
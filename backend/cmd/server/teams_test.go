@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestApplication_teams(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	platform := map[string]any{
+		"id":      "platform",
+		"name":    "Platform",
+		"members": []map[string]string{{"name": "Alice"}, {"name": "Paula", "role": "product-owner"}},
+	}
+
+	created := ts.postJSON(t, "/v1/teams", platform)
+	assert.Equal(t, created.status, http.StatusCreated)
+	var got struct {
+		Team internal.Team `json:"team"`
+	}
+	json.Unmarshal(created.body, &got)
+	assert.DeepEqual(t, got.Team.Members, []internal.TeamMember{{Name: "Alice", Role: internal.Developer}, {Name: "Paula", Role: internal.ProductOwner}})
+
+	t.Run("create errors", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			body       map[string]any
+			wantStatus int
+			wantError  string
+		}{
+			{name: "taken id", body: platform, wantStatus: http.StatusConflict, wantError: "a team with this id already exists"},
+			{name: "invalid id", body: map[string]any{"id": "Platform!", "name": "Platform"}, wantStatus: http.StatusBadRequest, wantError: "id must be 3 to 40 lowercase letters, digits or dashes"},
+			{name: "unknown deck", body: map[string]any{"id": "mobile", "name": "Mobile", "deck": "tshirt"}, wantStatus: http.StatusBadRequest, wantError: `unknown deck "tshirt"`},
+			{name: "invalid domain", body: map[string]any{"id": "mobile", "name": "Mobile", "settings": map[string]string{"allowedDomain": "localhost"}}, wantStatus: http.StatusBadRequest, wantError: `allowedDomain "localhost" is not a valid domain`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				response := ts.postJSON(t, "/v1/teams", tt.body)
+
+				var got struct {
+					Error string `json:"error"`
+				}
+				json.Unmarshal(response.body, &got)
+				assert.Equal(t, response.status, tt.wantStatus)
+				assert.Equal(t, got.Error, tt.wantError)
+			})
+		}
+	})
+
+	updated := ts.doJSON(t, http.MethodPut, "/v1/teams/platform", map[string]any{"name": "Platform Team", "members": []map[string]string{{"name": "Bob"}}}, nil)
+	assert.Equal(t, updated.status, http.StatusOK)
+	var afterUpdate struct {
+		Team internal.Team `json:"team"`
+	}
+	json.Unmarshal(updated.body, &afterUpdate)
+	assert.Equal(t, afterUpdate.Team.Name, "Platform Team")
+	assert.True(t, afterUpdate.Team.Created.Equal(got.Team.Created))
+
+	var listed struct {
+		Teams []internal.Team `json:"teams"`
+	}
+	json.Unmarshal(ts.get(t, "/v1/teams").body, &listed)
+	assert.Equal(t, len(listed.Teams), 1)
+	assert.Equal(t, listed.Teams[0].Name, "Platform Team")
+
+	assert.Equal(t, ts.doJSON(t, http.MethodDelete, "/v1/teams/platform", nil, nil).status, http.StatusNoContent)
+	assert.Equal(t, ts.get(t, "/v1/teams/platform").status, http.StatusNotFound)
+	assert.Equal(t, ts.doJSON(t, http.MethodPut, "/v1/teams/platform", map[string]any{"name": "Platform"}, nil).status, http.StatusNotFound)
+	assert.Equal(t, ts.doJSON(t, http.MethodDelete, "/v1/teams/platform", nil, nil).status, http.StatusNotFound)
+}
+
+func TestApplication_roomForTeam(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	app.decks["fibonacci"] = &internal.GuessConfig{Name: "fibonacci", Guesses: []internal.GuessConfigEntry{}}
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	team := ts.postJSON(t, "/v1/teams", map[string]any{
+		"id":       "platform",
+		"name":     "Platform",
+		"deck":     "fibonacci",
+		"members":  []map[string]string{{"name": "Alice"}, {"name": "Bob"}, {"name": "Paula", "role": "product-owner"}},
+		"settings": map[string]any{"visibility": "unlisted", "tags": []string{"backend"}},
+	})
+	assert.Equal(t, team.status, http.StatusCreated)
+
+	unknown := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Paula", "teamId": "mobile"})
+	assert.Equal(t, unknown.status, http.StatusBadRequest)
+
+	created := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Paula", "teamId": "platform", "title": "Sprint 42"})
+	assert.Equal(t, created.status, http.StatusCreated)
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+
+	owner, _ := ts.dialOwner(t, room["id"], "Paula")
+	defer owner.CloseNow()
+
+	t.Run("team defaults and roster", func(t *testing.T) {
+		var state internal.State
+		json.Unmarshal(ts.get(t, "/v1/room/"+room["id"]+"/state").body, &state)
+
+		assert.Equal(t, state.Deck, "fibonacci")
+		assert.Equal(t, state.Visibility, internal.VisibilityUnlisted)
+		assert.Equal(t, state.TeamId, "platform")
+		assert.DeepEqual(t, state.RoomDetails, internal.RoomDetails{Title: "Sprint 42", Team: "Platform", Tags: []string{"backend"}})
+		assert.DeepEqual(t, state.Roster, []internal.RosterEntry{
+			{Name: "Alice", Role: internal.Developer},
+			{Name: "Bob", Role: internal.Developer},
+			{Name: "Paula", Role: internal.ProductOwner, Present: true},
+		})
+	})
+
+	t.Run("members join in their assigned role", func(t *testing.T) {
+		url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/v1/room/" + room["id"]
+
+		_, response, err := websocket.Dial(context.Background(), url+"/product-owner?name=Alice", nil)
+		assert.True(t, err != nil)
+		assert.Equal(t, response.StatusCode, http.StatusForbidden)
+
+		connection, _, err := websocket.Dial(context.Background(), url+"/developer?name=Alice", nil)
+		assert.NilError(t, err)
+		defer connection.CloseNow()
+
+		guest, _, err := websocket.Dial(context.Background(), url+"/product-owner?name=Guest", nil)
+		assert.NilError(t, err)
+		defer guest.CloseNow()
+	})
+}
@@ -2,25 +2,50 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
 	"github.com/google/uuid"
 
 	"github.com/Hydoc/estimation-poker/backend/internal"
 )
 
+// testRequestId is sent with every request of the test server, so error
+// envelopes can be compared including their correlation id.
+const testRequestId = "test-request-id"
+
 func newTestApplication(t *testing.T, rooms map[uuid.UUID]*internal.Room) *application {
+	tokens, err := internal.NewTokenSigner([]byte("test-token-secret-of-32-characters"))
+	if err != nil {
+		t.Fatal(err)
+	}
 	return &application{
-		logger: slog.New(slog.DiscardHandler),
-		rooms:  rooms,
+		logger:   slog.New(slog.DiscardHandler),
+		logLevel: new(slog.LevelVar),
+		rooms:    rooms,
 		config: config{
-			env: "dev",
+			Env: "dev",
+			Limits: limitsConfig{
+				MaxNameLength: 15,
+			},
+		},
+		decks: map[string]*internal.GuessConfig{
+			defaultDeckName: {Name: defaultDeckName, Guesses: []internal.GuessConfigEntry{}},
 		},
+		started:         time.Now(),
+		readinessChecks: make(map[string]readinessCheck),
+		teams:           internal.NewMemoryTeamStore(),
+		apiKeys:         internal.NewMemoryAPIKeyStore(),
+		tokens:          tokens,
 	}
 }
 
@@ -36,15 +61,23 @@ type testResponse struct {
 }
 
 func newTestServer(t *testing.T, h http.Handler) *testServer {
-	ts := httptest.NewServer(h)
+	ts := httptest.NewServer(conformingTo(t, h))
 	return &testServer{ts}
 }
 
 func (ts *testServer) get(t *testing.T, urlPath string) testResponse {
+	return ts.getWithHeaders(t, urlPath, nil)
+}
+
+func (ts *testServer) getWithHeaders(t *testing.T, urlPath string, headers http.Header) testResponse {
 	req, err := http.NewRequest(http.MethodGet, ts.URL+urlPath, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.Header.Set(requestIdHeader, testRequestId)
+	for key, values := range headers {
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
 
 	res, err := ts.Client().Do(req)
 	if err != nil {
@@ -66,16 +99,24 @@ func (ts *testServer) get(t *testing.T, urlPath string) testResponse {
 }
 
 func (ts *testServer) postJSON(t *testing.T, urlPath string, body any) testResponse {
+	return ts.doJSON(t, http.MethodPost, urlPath, body, nil)
+}
+
+func (ts *testServer) doJSON(t *testing.T, method, urlPath string, body any, headers http.Header) testResponse {
 	encoded, err := json.Marshal(body)
 	if err != nil {
 		t.Fatal(err)
 	}
-	req, err := http.NewRequest(http.MethodPost, ts.URL+urlPath, bytes.NewReader(encoded))
+	req, err := http.NewRequest(method, ts.URL+urlPath, bytes.NewReader(encoded))
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestIdHeader, testRequestId)
+	for key, values := range headers {
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
 
 	res, err := ts.Client().Do(req)
 	if err != nil {
@@ -95,3 +136,21 @@ func (ts *testServer) postJSON(t *testing.T, urlPath string, body any) testRespo
 		body:    b,
 	}
 }
+
+// dialOwner joins room as its product owner and returns the connection
+// together with the owner token from the first permissions message.
+func (ts *testServer) dialOwner(t *testing.T, room, name string) (*websocket.Conn, string) {
+	t.Helper()
+
+	connection, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http")+"/v1/room/"+room+"/product-owner?name="+name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var permissions struct {
+		Data internal.Permissions `json:"data"`
+	}
+	if err := wsjson.Read(context.Background(), connection, &permissions); err != nil {
+		t.Fatal(err)
+	}
+	return connection, permissions.Data.OwnerToken
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestApplication_traceRequest(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.get(t, "/v1/room/9c874aaa-c628-4688-a72d-0b1afc708a7d/state")
+
+	var got sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "GET /v1/room/:id/state" {
+			got = span
+		}
+	}
+	if got == nil {
+		t.Fatal("expected span for route")
+	}
+
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, kv := range got.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	assert.Equal(t, attributes["http.route"].AsString(), "/v1/room/:id/state")
+	assert.Equal(t, attributes["http.response.status_code"].AsInt64(), int64(404))
+	assert.Equal(t, attributes[internal.RoomIdKey].AsString(), "9c874aaa-c628-4688-a72d-0b1afc708a7d")
+	assert.Equal(t, attributes["request.id"].AsString(), testRequestId)
+}
+
+func TestSetupTracing_DisabledWithoutEndpoint(t *testing.T) {
+	shutdown, err := setupTracing(context.Background(), config{})
+
+	assert.NilError(t, err)
+	assert.NilError(t, shutdown(context.Background()))
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal"
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestApplication_roomVisibility(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ids := map[string]string{}
+	for _, visibility := range []string{"public", "unlisted", "private"} {
+		created := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester", "title": visibility, "visibility": visibility})
+		assert.Equal(t, created.status, http.StatusCreated)
+		var room map[string]string
+		json.Unmarshal(created.body, &room)
+		ids[visibility] = room["id"]
+	}
+
+	t.Run("only public rooms are listed", func(t *testing.T) {
+		response := ts.get(t, "/v1/rooms")
+
+		var got struct {
+			Rooms []internal.Overview `json:"rooms"`
+		}
+		json.Unmarshal(response.body, &got)
+		assert.Equal(t, len(got.Rooms), 1)
+		assert.Equal(t, got.Rooms[0].Title, "public")
+	})
+
+	tests := []struct {
+		visibility string
+		username   string
+		want       internal.ConnectionState
+	}{
+		{visibility: "unlisted", username: "Dev", want: internal.ConnectionState{CanConnect: true}},
+		{visibility: "private", username: "Dev", want: internal.ConnectionState{Reason: "room is private"}},
+		{visibility: "private", username: "Tester", want: internal.ConnectionState{CanConnect: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.visibility+" connection state for "+tt.username, func(t *testing.T) {
+			response := ts.postJSON(t, "/v1/room/"+ids[tt.visibility]+"/connection-state", map[string]string{"username": tt.username})
+
+			var got internal.ConnectionState
+			json.Unmarshal(response.body, &got)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+
+	t.Run("private rooms refuse uninvited websockets", func(t *testing.T) {
+		_, response, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http")+"/v1/room/"+ids["private"]+"/developer?name=Dev", nil)
+
+		assert.True(t, err != nil)
+		assert.Equal(t, response.StatusCode, http.StatusForbidden)
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		response := ts.get(t, "/v1/room/"+ids["unlisted"]+"/metadata")
+
+		var got envelope
+		json.Unmarshal(response.body, &got)
+		assert.Equal(t, got["visibility"], any("unlisted"))
+	})
+
+	t.Run("invalid visibility", func(t *testing.T) {
+		response := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester", "visibility": "secret"})
+
+		assert.Equal(t, response.status, http.StatusBadRequest)
+		assert.StringContains(t, string(response.body), `visibility must be one of public, unlisted, private, got \"secret\"`)
+	})
+}
+
+func TestApplication_privateRoomDetails(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	created := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester", "title": "Secret sprint", "visibility": "private"})
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+	connection, ownerToken := ts.dialOwner(t, room["id"], "Tester")
+	defer connection.CloseNow()
+	minted := ts.doJSON(t, http.MethodPost, "/v1/room/"+room["id"]+"/invites", map[string]any{"role": "developer"}, http.Header{"Authorization": {"Bearer " + ownerToken}})
+	var invite map[string]any
+	json.Unmarshal(minted.body, &invite)
+
+	tests := []struct {
+		name      string
+		query     string
+		headers   http.Header
+		wantTitle bool
+	}{
+		{name: "anonymous"},
+		{name: "foreign owner token", headers: http.Header{"Authorization": {"Bearer not-the-token"}}},
+		{name: "owner token", headers: http.Header{"Authorization": {"Bearer " + ownerToken}}, wantTitle: true},
+		{name: "invite", query: "?invite=" + invite["token"].(string), wantTitle: true},
+	}
+
+	for _, tt := range tests {
+		for _, resource := range []string{"metadata", "state"} {
+			t.Run(tt.name+" "+resource, func(t *testing.T) {
+				response := ts.getWithHeaders(t, "/v1/room/"+room["id"]+"/"+resource+tt.query, tt.headers)
+
+				var got envelope
+				json.Unmarshal(response.body, &got)
+				_, hasTitle := got["title"]
+				assert.Equal(t, hasTitle, tt.wantTitle)
+				switch {
+				case tt.wantTitle:
+					assert.Equal(t, response.status, http.StatusOK)
+				case resource == "metadata":
+					assert.Equal(t, response.status, http.StatusOK)
+					assert.DeepEqual(t, got, envelope{"exists": true, "isLocked": false})
+				default:
+					assert.Equal(t, response.status, http.StatusForbidden)
+				}
+			})
+		}
+	}
+}
+
+func TestApplication_handleUpdateRoomVisibility(t *testing.T) {
+	app := newTestApplication(t, make(map[uuid.UUID]*internal.Room))
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	created := ts.postJSON(t, "/v1/room", map[string]any{"creator": "Tester", "title": "Sprint 42"})
+	var room map[string]string
+	json.Unmarshal(created.body, &room)
+	connection, ownerToken := ts.dialOwner(t, room["id"], "Tester")
+	defer connection.CloseNow()
+
+	tests := []struct {
+		name       string
+		key        string
+		visibility string
+		wantStatus int
+		wantListed int
+	}{
+		{name: "missing owner token", visibility: "private", wantStatus: http.StatusUnauthorized, wantListed: 1},
+		{name: "invalid visibility", key: ownerToken, visibility: "hidden", wantStatus: http.StatusBadRequest, wantListed: 1},
+		{name: "hide the room", key: ownerToken, visibility: "private", wantStatus: http.StatusOK, wantListed: 0},
+		{name: "list it again", key: ownerToken, visibility: "public", wantStatus: http.StatusOK, wantListed: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := ts.doJSON(t, http.MethodPatch, "/v1/room/"+room["id"], map[string]string{"visibility": tt.visibility}, http.Header{"Authorization": {"Bearer " + tt.key}})
+
+			assert.Equal(t, response.status, tt.wantStatus)
+			var listed struct {
+				Rooms []internal.Overview `json:"rooms"`
+			}
+			json.Unmarshal(ts.get(t, "/v1/rooms").body, &listed)
+			assert.Equal(t, len(listed.Rooms), tt.wantListed)
+			if tt.wantStatus == http.StatusOK {
+				var got envelope
+				json.Unmarshal(response.body, &got)
+				assert.Equal(t, got["visibility"], any(tt.visibility))
+			}
+		})
+	}
+
+	// changing the visibility leaves the details alone
+	var got internal.State
+	json.Unmarshal(ts.get(t, "/v1/room/"+room["id"]+"/state").body, &got)
+	assert.Equal(t, got.Title, "Sprint 42")
+}
@@ -8,6 +8,8 @@ import (
 	"unicode/utf8"
 
 	"github.com/coder/websocket"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 
 	"github.com/Hydoc/estimation-poker/backend/internal"
 )
@@ -22,10 +24,15 @@ func (app *application) handleWs(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
+	cfg := app.currentConfig()
 	name := request.URL.Query().Get("name")
+	user := app.readSession(request)
+	if user != nil {
+		name = app.displayName(user)
+	}
 
-	if utf8.RuneCountInString(name) > 15 {
-		app.badRequestResponse(writer, request, errors.New("name must be smaller or equal to 15"))
+	if utf8.RuneCountInString(name) > cfg.Limits.MaxNameLength {
+		app.badRequestResponse(writer, request, fmt.Errorf("name must be smaller or equal to %d", cfg.Limits.MaxNameLength))
 		return
 	}
 
@@ -35,19 +42,73 @@ func (app *application) handleWs(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
-	connection, err := websocket.Accept(writer, request, nil)
+	clientRole := internal.Developer
+	if strings.Contains(request.URL.Path, "product-owner") {
+		clientRole = internal.ProductOwner
+	}
+
+	if err := clientRoom.AdmitsUser(user); err != nil {
+		app.forbiddenResponse(writer, request, err)
+		return
+	}
+
+	query := request.URL.Query()
+	ownerToken := query.Get("ownerToken")
+	if ownerToken != "" && !clientRoom.IsOwnerToken(ownerToken) {
+		app.unauthorizedResponse(writer, request)
+		return
+	}
+
+	if role, ok := clientRoom.AssignedRole(name, user); ok && role != clientRole && ownerToken == "" {
+		app.forbiddenResponse(writer, request, fmt.Errorf("your team assigns you the %s role", role))
+		return
+	}
+
+	invite, err := app.readInvite(query.Get("invite"))
+	if err != nil {
+		app.forbiddenResponse(writer, request, err)
+		return
+	}
+	if invite != nil && invite.Role != clientRole {
+		app.forbiddenResponse(writer, request, fmt.Errorf("invite is for the %s role", invite.Role))
+		return
+	}
+
+	// the owner may always come back, everybody else passes the same checks
+	// as when asking for the connection state
+	if ownerToken == "" {
+		state := clientRoom.ConnectionState(name, query.Get("password"), invite, user)
+		if !state.CanConnect {
+			app.forbiddenResponse(writer, request, errors.New(state.Reason))
+			return
+		}
+	}
+	if invite != nil {
+		if err := clientRoom.RedeemInvite(*invite); err != nil {
+			app.forbiddenResponse(writer, request, err)
+			return
+		}
+	}
+
+	connection, err := websocket.Accept(writer, request, &websocket.AcceptOptions{
+		OriginPatterns: cfg.CORS.originPatterns(),
+		Subprotocols:   internal.Subprotocols,
+	})
 	if err != nil {
 		app.logger.Info(fmt.Sprintf("upgrade: %s", err))
 		return
 	}
 
-	clientRole := internal.Developer
-	if strings.Contains(request.URL.Path, "product-owner") {
-		clientRole = internal.ProductOwner
+	trace.SpanFromContext(request.Context()).SetAttributes(internal.ClientRoleKey.String(clientRole))
+	logger := app.logger.With("requestId", app.contextGetRequestInfo(request).id, "room", roomId)
+	var limiter *rate.Limiter
+	if cfg.Limits.Enabled {
+		limiter = rate.NewLimiter(rate.Limit(cfg.Limits.MessageRPS), cfg.Limits.MessageBurst)
 	}
-	client := internal.NewClient(name, clientRole, clientRoom, connection, app.bus, app.logger)
+	client := internal.NewClient(name, clientRole, clientRoom, connection, app.bus, logger, limiter)
+	client.User = user
 
 	go client.WebsocketReader()
 	go client.WebsocketWriter()
-	clientRoom.Join(client)
+	clientRoom.Join(client, ownerToken)
 }
@@ -3,17 +3,22 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"strings"
 	"testing"
 
 	"github.com/coder/websocket"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 
 	"github.com/Hydoc/estimation-poker/backend/internal"
 	"github.com/Hydoc/estimation-poker/backend/internal/assert"
 )
 
 func TestApplication_handleWs(t *testing.T) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.NilError(t, err)
+
 	tests := []struct {
 		name           string
 		url            string
@@ -54,7 +59,8 @@ func TestApplication_handleWs(t *testing.T) {
 			url:   "/v1/room/ffb25a3d-a5db-42b7-9733-345f61167077/product-owner?name=whateverthisisitiswaytoooooooooooolong",
 			rooms: make(map[uuid.UUID]*internal.Room),
 			expectedError: map[string]string{
-				"error": "name must be smaller or equal to 15",
+				"error":     "name must be smaller or equal to 15",
+				"requestId": testRequestId,
 			},
 			expectedStatus: 400,
 		},
@@ -63,7 +69,8 @@ func TestApplication_handleWs(t *testing.T) {
 			url:   "/v1/room/ffb25a3d-a5db-42b7-9733-345f61167077/product-owner?name=",
 			rooms: make(map[uuid.UUID]*internal.Room),
 			expectedError: map[string]string{
-				"message": "name is missing in query",
+				"error":     "name is missing in query",
+				"requestId": testRequestId,
 			},
 			expectedStatus: 400,
 			expectedRoomId: "ffb25a3d-a5db-42b7-9733-345f61167077",
@@ -74,16 +81,59 @@ func TestApplication_handleWs(t *testing.T) {
 			url:   "/v1/room/invalid/product-owner?name=test",
 			rooms: make(map[uuid.UUID]*internal.Room),
 			expectedError: map[string]string{
-				"error": "invalid id parameter",
+				"error":     "invalid id parameter",
+				"requestId": testRequestId,
 			},
 			expectedStatus: 400,
 		},
+		{
+			name: "connect to a locked room with the password",
+			url:  "/v1/room/ffb25a3d-a5db-42b7-9733-345f61167077/developer?name=Test&password=secret",
+			rooms: map[uuid.UUID]*internal.Room{
+				uuid.MustParse("ffb25a3d-a5db-42b7-9733-345f61167077"): {
+					Id:             uuid.MustParse("ffb25a3d-a5db-42b7-9733-345f61167077"),
+					HashedPassword: hashedPassword,
+				},
+			},
+			expectedStatus: 101,
+		},
+		{
+			name: "not connecting to a locked room without the password",
+			url:  "/v1/room/ffb25a3d-a5db-42b7-9733-345f61167077/developer?name=Test",
+			rooms: map[uuid.UUID]*internal.Room{
+				uuid.MustParse("ffb25a3d-a5db-42b7-9733-345f61167077"): {
+					Id:             uuid.MustParse("ffb25a3d-a5db-42b7-9733-345f61167077"),
+					HashedPassword: hashedPassword,
+				},
+			},
+			expectedError: map[string]string{
+				"error":     "wrong password",
+				"requestId": testRequestId,
+			},
+			expectedStatus: 403,
+		},
+		{
+			name: "not connecting because the name is taken",
+			url:  "/v1/room/ffb25a3d-a5db-42b7-9733-345f61167077/developer?name=Test",
+			rooms: map[uuid.UUID]*internal.Room{
+				uuid.MustParse("ffb25a3d-a5db-42b7-9733-345f61167077"): {
+					Id:      uuid.MustParse("ffb25a3d-a5db-42b7-9733-345f61167077"),
+					Clients: map[*internal.Client]bool{{Name: "Test"}: true},
+				},
+			},
+			expectedError: map[string]string{
+				"error":     "username already taken",
+				"requestId": testRequestId,
+			},
+			expectedStatus: 403,
+		},
 		{
 			name:  "not connecting because room not found",
 			url:   "/v1/room/ffb25a3d-a5db-42b7-9733-345f61167077/product-owner?name=test",
 			rooms: make(map[uuid.UUID]*internal.Room),
 			expectedError: map[string]string{
-				"error": "the requested resource could not be found",
+				"error":     "the requested resource could not be found",
+				"requestId": testRequestId,
 			},
 			expectedStatus: 404,
 		},
@@ -97,7 +147,9 @@ func TestApplication_handleWs(t *testing.T) {
 			defer ts.Close()
 
 			url := "ws" + strings.TrimPrefix(ts.URL, "http") + tt.url
-			_, response, _ := websocket.Dial(context.Background(), url, nil)
+			_, response, _ := websocket.Dial(context.Background(), url, &websocket.DialOptions{
+				HTTPHeader: http.Header{requestIdHeader: {testRequestId}},
+			})
 
 			assert.Equal(t, response.StatusCode, tt.expectedStatus)
 
@@ -110,3 +162,50 @@ func TestApplication_handleWs(t *testing.T) {
 		})
 	}
 }
+
+func TestApplication_handleWs_OriginCheck(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedOrigins []string
+		origin         string
+		wantStatus     int
+	}{
+		{
+			name:           "rejects foreign origin by default",
+			trustedOrigins: nil,
+			origin:         "https://poker.example.com",
+			wantStatus:     http.StatusForbidden,
+		},
+		{
+			name:           "accepts trusted origin",
+			trustedOrigins: []string{"https://poker.example.com"},
+			origin:         "https://poker.example.com",
+			wantStatus:     http.StatusSwitchingProtocols,
+		},
+		{
+			name:           "rejects untrusted origin",
+			trustedOrigins: []string{"https://poker.example.com"},
+			origin:         "https://evil.example.org",
+			wantStatus:     http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roomId := uuid.MustParse("ffb25a3d-a5db-42b7-9733-345f61167077")
+			app := newTestApplication(t, map[uuid.UUID]*internal.Room{
+				roomId: {Id: roomId},
+			})
+			app.config.CORS.TrustedOrigins = tt.trustedOrigins
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/v1/room/" + roomId.String() + "/developer?name=Test"
+			_, response, _ := websocket.Dial(context.Background(), url, &websocket.DialOptions{
+				HTTPHeader: http.Header{"Origin": {tt.origin}},
+			})
+
+			assert.Equal(t, response.StatusCode, tt.wantStatus)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	PhaseWaiting      = "waiting"
+	PhaseEstimating   = "estimating"
+	PhaseEveryoneDone = "everyone-done"
+
+	// ClosedByOperator is the reason of rooms closed through the admin api.
+	ClosedByOperator = "operator"
+	operatorName     = "operator"
+)
+
+// RoomStatus describes a room for operators, regardless of its visibility.
+type RoomStatus struct {
+	Overview
+	IsLocked     bool           `json:"isLocked"`
+	Visibility   string         `json:"visibility"`
+	Phase        string         `json:"phase"`
+	Clients      []ClientStatus `json:"clients"`
+	Created      time.Time      `json:"created"`
+	LastActivity time.Time      `json:"lastActivity"`
+}
+
+type ClientStatus struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Verified bool   `json:"verified"`
+}
+
+// Notice is a message of the operators shown in every room.
+type Notice struct {
+	Message string `json:"message"`
+}
+
+// Phase tells whether the room waits for an estimation to start, is
+// estimating or waits for the product owner to reveal the guesses.
+func (room *Room) Phase() string {
+	if !room.IsInProgress() {
+		return PhaseWaiting
+	}
+	if done, _ := room.everyDevIsDone(); done {
+		return PhaseEveryoneDone
+	}
+	return PhaseEstimating
+}
+
+func (room *Room) Status() RoomStatus {
+	room.clientMu.RLock()
+	clients := make([]ClientStatus, 0, len(room.Clients))
+	for client := range room.Clients {
+		clients = append(clients, ClientStatus{Name: client.Name, Role: client.Role, Verified: client.User != nil})
+	}
+	room.clientMu.RUnlock()
+	slices.SortFunc(clients, func(a, b ClientStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return RoomStatus{
+		Overview:     room.AsOverview(),
+		IsLocked:     room.IsLocked(),
+		Visibility:   room.Visibility(),
+		Phase:        room.Phase(),
+		Clients:      clients,
+		Created:      room.Created,
+		LastActivity: room.LastActivity(),
+	}
+}
+
+// Disconnect closes the connection of the client called name after telling
+// it why. It reports false if nobody of that name is in the room.
+func (room *Room) Disconnect(name, reason string) bool {
+	room.clientMu.RLock()
+	var target *Client
+	for client := range room.Clients {
+		if client.Name == name {
+			target = client
+			break
+		}
+	}
+	room.clientMu.RUnlock()
+	if target == nil {
+		return false
+	}
+
+	room.record(&Client{Name: operatorName}, AuditDisconnect, name)
+	select {
+	case target.send <- newOutgoingWebsocketMessage(disconnected, reason):
+	case <-room.done:
+	case <-time.After(closeTimeout):
+		room.logger.Warn("client did not receive disconnect message", "room", room.Id, "client", name)
+	}
+	return true
+}
+
+// Notify shows notice to every client in the room.
+func (room *Room) Notify(notice Notice) {
+	room.enqueue(newOutgoingWebsocketMessage(maintenance, notice))
+}
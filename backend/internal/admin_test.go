@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestRoom_Phase(t *testing.T) {
+	tests := []struct {
+		name       string
+		inProgress bool
+		guess      string
+		want       string
+	}{
+		{name: "waiting", want: PhaseWaiting},
+		{name: "estimating", inProgress: true, want: PhaseEstimating},
+		{name: "everyone done", inProgress: true, guess: "3", want: PhaseEveryoneDone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+			room.inProgress = tt.inProgress
+			room.Clients[&Client{Name: "Dev", Role: Developer, guess: tt.guess}] = true
+
+			assert.Equal(t, room.Phase(), tt.want)
+		})
+	}
+}
+
+func TestRoom_Status(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	room.HashedPassword = []byte("hashed")
+	room.visibility = VisibilityPrivate
+	room.Clients[&Client{Name: "Tester", Role: ProductOwner, User: &User{Subject: "tester"}}] = true
+	room.Clients[&Client{Name: "Dev", Role: Developer}] = true
+
+	status := room.Status()
+
+	assert.True(t, status.IsLocked)
+	assert.Equal(t, status.Visibility, VisibilityPrivate)
+	assert.Equal(t, status.Phase, PhaseWaiting)
+	assert.Equal(t, status.PlayerCount, 2)
+	assert.DeepEqual(t, status.Clients, []ClientStatus{
+		{Name: "Dev", Role: Developer},
+		{Name: "Tester", Role: ProductOwner, Verified: true},
+	})
+}
+
+func TestRoom_Disconnect(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	client := &Client{Name: "Dev", Role: Developer, send: make(chan *OutgoingWebsocketMessage, 1)}
+	room.Clients[client] = true
+
+	assert.False(t, room.Disconnect("Nobody", "bye"))
+	assert.True(t, room.Disconnect("Dev", "bye"))
+
+	assert.DeepEqual(t, <-client.send, newOutgoingWebsocketMessage(disconnected, "bye"))
+	trail := room.AuditTrail()
+	assert.Equal(t, len(trail), 1)
+	assert.Equal(t, trail[0].Actor, "operator")
+	assert.Equal(t, trail[0].Action, AuditDisconnect)
+	assert.Equal(t, trail[0].Payload, any("Dev"))
+}
+
+func TestRoom_Notify(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	client := &Client{Name: "Tester", Role: ProductOwner, send: make(chan *OutgoingWebsocketMessage, 1)}
+	go room.Run()
+	room.join <- client
+
+	room.Notify(Notice{Message: "restart at 18:00"})
+
+	assert.DeepEqual(t, <-client.send, newOutgoingWebsocketMessage(maintenance, Notice{Message: "restart at 18:00"}))
+}
@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScopeCreateRooms  = "rooms:create"
+	ScopeImportIssues = "issues:import"
+	ScopeReadExports  = "exports:read"
+	// ScopeAdmin grants every other scope as well.
+	ScopeAdmin = "admin"
+
+	// APIKeyPrefix tells api keys apart from the other bearer tokens.
+	APIKeyPrefix = "ep_"
+)
+
+var (
+	Scopes            = []string{ScopeCreateRooms, ScopeImportIssues, ScopeReadExports, ScopeAdmin}
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyExpired  = errors.New("api key has expired")
+)
+
+// APIKey authenticates an automation client. Only the hash of the secret is
+// kept, the secret itself is shown once when the key is created.
+type APIKey struct {
+	Id        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	Hash      string    `json:"-"`
+	Created   time.Time `json:"created"`
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+}
+
+// NewAPIKey returns a key valid for ttl, or forever if ttl is 0, together
+// with its secret.
+func NewAPIKey(name string, scopes []string, ttl time.Duration, now time.Time) (APIKey, string) {
+	secret := APIKeyPrefix + rand.Text()
+	key := APIKey{
+		Id:      uuid.New(),
+		Name:    name,
+		Scopes:  slices.Clone(scopes),
+		Hash:    HashAPIKey(secret),
+		Created: now,
+	}
+	if ttl > 0 {
+		key.ExpiresAt = now.Add(ttl)
+	}
+	return key, secret
+}
+
+// HashAPIKey hashes the secret of a key. The secrets are random enough to not
+// need a slow hash.
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return errors.New("an api key needs at least one scope")
+	}
+	var errs []error
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			errs = append(errs, fmt.Errorf("scope must be one of %s, got %q", strings.Join(Scopes, ", "), scope))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (key APIKey) HasScope(scope string) bool {
+	return slices.Contains(key.Scopes, scope) || slices.Contains(key.Scopes, ScopeAdmin)
+}
+
+func (key APIKey) Expired(now time.Time) bool {
+	return !key.ExpiresAt.IsZero() && now.After(key.ExpiresAt)
+}
+
+// APIKeyStore keeps api keys across restarts. Implementations must be safe
+// for concurrent use.
+type APIKeyStore interface {
+	APIKeys() ([]APIKey, error)
+	// Authenticate returns the key of secret or ErrAPIKeyNotFound.
+	Authenticate(secret string) (APIKey, error)
+	CreateAPIKey(key APIKey) error
+	DeleteAPIKey(id uuid.UUID) error
+}
+
+// MemoryAPIKeyStore forgets its keys when the server stops.
+type MemoryAPIKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]APIKey
+}
+
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{keys: make(map[string]APIKey)}
+}
+
+func (store *MemoryAPIKeyStore) APIKeys() ([]APIKey, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	keys := make([]APIKey, 0, len(store.keys))
+	for _, key := range store.keys {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b APIKey) int {
+		return a.Created.Compare(b.Created)
+	})
+	return keys, nil
+}
+
+func (store *MemoryAPIKeyStore) Authenticate(secret string) (APIKey, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	key, ok := store.keys[HashAPIKey(secret)]
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (store *MemoryAPIKeyStore) CreateAPIKey(key APIKey) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.keys[key.Hash] = key
+	return nil
+}
+
+func (store *MemoryAPIKeyStore) DeleteAPIKey(id uuid.UUID) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for hash, key := range store.keys {
+		if key.Id == id {
+			delete(store.keys, hash)
+			return nil
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+// apiKeyRecord is how a key is saved, the only place its hash is written.
+type apiKeyRecord struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+// FileAPIKeyStore keeps every key in a single JSON file which is rewritten
+// on every change.
+type FileAPIKeyStore struct {
+	MemoryAPIKeyStore
+	saveMu sync.Mutex
+	path   string
+}
+
+// OpenFileAPIKeyStore loads the keys saved at path. A missing file is
+// created with the first key.
+func OpenFileAPIKeyStore(path string) (*FileAPIKeyStore, error) {
+	store := &FileAPIKeyStore{MemoryAPIKeyStore: MemoryAPIKeyStore{keys: make(map[string]APIKey)}, path: path}
+	var records []apiKeyRecord
+	if err := readJSONFile(path, &records); err != nil {
+		return nil, fmt.Errorf("can not read api keys from %s: %w", path, err)
+	}
+	for _, record := range records {
+		record.APIKey.Hash = record.Hash
+		store.keys[record.Hash] = record.APIKey
+	}
+	return store, nil
+}
+
+func (store *FileAPIKeyStore) CreateAPIKey(key APIKey) error {
+	return store.changeAndSave(func() error {
+		return store.MemoryAPIKeyStore.CreateAPIKey(key)
+	})
+}
+
+func (store *FileAPIKeyStore) DeleteAPIKey(id uuid.UUID) error {
+	return store.changeAndSave(func() error {
+		return store.MemoryAPIKeyStore.DeleteAPIKey(id)
+	})
+}
+
+// Check reports whether changed keys can be saved.
+func (store *FileAPIKeyStore) Check(ctx context.Context) error {
+	return checkWritable(ctx, store.path)
+}
+
+func (store *FileAPIKeyStore) changeAndSave(change func() error) error {
+	store.saveMu.Lock()
+	defer store.saveMu.Unlock()
+	if err := change(); err != nil {
+		return err
+	}
+	keys, _ := store.APIKeys()
+	records := make([]apiKeyRecord, 0, len(keys))
+	for _, key := range keys {
+		records = append(records, apiKeyRecord{APIKey: key, Hash: key.Hash})
+	}
+	return writeJSONFile(store.path, records)
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestAPIKey(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	key, secret := NewAPIKey("ci", []string{ScopeCreateRooms}, time.Hour, now)
+	forever, _ := NewAPIKey("ops", []string{ScopeAdmin}, 0, now)
+
+	assert.True(t, strings.HasPrefix(secret, APIKeyPrefix))
+	assert.Equal(t, key.Hash, HashAPIKey(secret))
+	assert.True(t, key.HasScope(ScopeCreateRooms))
+	assert.False(t, key.HasScope(ScopeReadExports))
+	assert.True(t, forever.HasScope(ScopeReadExports))
+	assert.False(t, key.Expired(now.Add(time.Hour)))
+	assert.True(t, key.Expired(now.Add(time.Hour+time.Second)))
+	assert.False(t, forever.Expired(now.Add(100*365*24*time.Hour)))
+}
+
+func TestValidateScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		wantErr string
+	}{
+		{name: "valid", scopes: []string{ScopeImportIssues, ScopeReadExports}},
+		{name: "none", scopes: nil, wantErr: "an api key needs at least one scope"},
+		{name: "unknown", scopes: []string{"rooms:delete"}, wantErr: `scope must be one of rooms:create, issues:import, exports:read, admin, got "rooms:delete"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateScopes(tt.scopes)
+
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Equal(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestFileAPIKeyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	store, err := OpenFileAPIKeyStore(path)
+	assert.NilError(t, err)
+	key, secret := NewAPIKey("ci", []string{ScopeCreateRooms}, 0, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	revoked, revokedSecret := NewAPIKey("old", []string{ScopeCreateRooms}, 0, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	assert.NilError(t, store.CreateAPIKey(key))
+	assert.NilError(t, store.CreateAPIKey(revoked))
+	assert.NilError(t, store.DeleteAPIKey(revoked.Id))
+	assert.True(t, errors.Is(store.DeleteAPIKey(revoked.Id), ErrAPIKeyNotFound))
+
+	reopened, err := OpenFileAPIKeyStore(path)
+	assert.NilError(t, err)
+	got, err := reopened.Authenticate(secret)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, key)
+	_, err = reopened.Authenticate(revokedSecret)
+	assert.True(t, errors.Is(err, ErrAPIKeyNotFound))
+
+	content, _ := os.ReadFile(path)
+	assert.False(t, strings.Contains(string(content), secret))
+	assert.StringContains(t, string(content), key.Hash)
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ExpiryIdle   = "idle"
+	ExpiryMaxAge = "max-age"
+)
+
+type Expiry struct {
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type RoomSnapshot struct {
+	Id            uuid.UUID    `json:"id"`
+	NameOfCreator string       `json:"nameOfCreator"`
+	Deck          string       `json:"deck"`
+	Created       time.Time    `json:"created"`
+	Closed        time.Time    `json:"closed,omitzero"`
+	Reason        string       `json:"reason,omitempty"`
+	Issues        []*Issue     `json:"issues"`
+	AuditTrail    []AuditEntry `json:"auditTrail"`
+}
+
+// RoomArchive keeps rooms after they expired. Implementations must be safe
+// for concurrent use.
+type RoomArchive interface {
+	Archive(snapshot RoomSnapshot) error
+}
+
+// JSONRoomArchive writes every snapshot as a single JSON line.
+type JSONRoomArchive struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewJSONRoomArchive(writer io.Writer) *JSONRoomArchive {
+	return &JSONRoomArchive{
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+func (archive *JSONRoomArchive) Archive(snapshot RoomSnapshot) error {
+	archive.mu.Lock()
+	defer archive.mu.Unlock()
+	return archive.encoder.Encode(snapshot)
+}
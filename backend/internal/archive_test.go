@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestJSONRoomArchive_Archive(t *testing.T) {
+	var out bytes.Buffer
+	archive := NewJSONRoomArchive(&out)
+	snapshot := RoomSnapshot{
+		Id:            uuid.MustParse("67ddc335-0aa0-41f9-8289-2649da77aee7"),
+		NameOfCreator: "Tester",
+		Created:       time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		Closed:        time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Reason:        ExpiryIdle,
+		Issues:        []*Issue{},
+		AuditTrail:    []AuditEntry{},
+	}
+
+	err := archive.Archive(snapshot)
+	assert.NilError(t, err)
+
+	var got RoomSnapshot
+	assert.NilError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.DeepEqual(t, got, snapshot)
+}
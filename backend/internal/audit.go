@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxAuditTrail is the number of entries a room keeps in memory. Older
+// entries are dropped once a room exceeds it.
+const maxAuditTrail = 1000
+
+const (
+	AuditJoin             = "join"
+	AuditLeave            = "leave"
+	AuditLock             = "lock-room"
+	AuditOpen             = "open-room"
+	AuditEstimate         = "estimate"
+	AuditReveal           = "reveal"
+	AuditNewRound         = "new-round"
+	AuditAddIssue         = "add-issue"
+	AuditDetails          = "details"
+	AuditVisibility       = "visibility"
+	AuditInvite           = "invite"
+	AuditRotateOwnerToken = "rotate-owner-token"
+	AuditImportIssues     = "import-issues"
+	AuditDisconnect       = "disconnect"
+)
+
+type AuditEntry struct {
+	RoomId uuid.UUID `json:"roomId"`
+	// Seq numbers the entries of a room from 1, so followers can ask for the
+	// entries after the last one they saw.
+	Seq     int       `json:"seq,omitempty"`
+	Time    time.Time `json:"time"`
+	Actor   string    `json:"actor"`
+	Role    string    `json:"role"`
+	Action  string    `json:"action"`
+	Payload any       `json:"payload,omitempty"`
+}
+
+// AuditSink receives every audit entry of every room. Implementations must be
+// safe for concurrent use since rooms write from their own goroutines.
+type AuditSink interface {
+	Write(entry AuditEntry) error
+}
+
+type JSONAuditSink struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewJSONAuditSink(writer io.Writer) *JSONAuditSink {
+	return &JSONAuditSink{
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+func (sink *JSONAuditSink) Write(entry AuditEntry) error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	return sink.encoder.Encode(entry)
+}
+
+// RotatingFileAuditSink appends JSON lines to path and rotates the file to
+// path.1 ... path.<maxBackups> once it would grow beyond maxBytes.
+type RotatingFileAuditSink struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func NewRotatingFileAuditSink(path string, maxBytes int64, maxBackups int) (*RotatingFileAuditSink, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("audit file max size must be greater than 0, got %d", maxBytes)
+	}
+
+	sink := &RotatingFileAuditSink{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+func (sink *RotatingFileAuditSink) Write(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if sink.size > 0 && sink.size+int64(len(line)) > sink.maxBytes {
+		if err := sink.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := sink.file.Write(line)
+	sink.size += int64(n)
+	return err
+}
+
+func (sink *RotatingFileAuditSink) Close() error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	return sink.file.Close()
+}
+
+// Check reports whether the audit file can be rotated.
+func (sink *RotatingFileAuditSink) Check(ctx context.Context) error {
+	return checkWritable(ctx, sink.path)
+}
+
+func (sink *RotatingFileAuditSink) open() error {
+	file, err := os.OpenFile(sink.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("can not open audit file %s: %w", sink.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	sink.file = file
+	sink.size = info.Size()
+	return nil
+}
+
+func (sink *RotatingFileAuditSink) rotate() error {
+	if err := sink.file.Close(); err != nil {
+		return err
+	}
+
+	if sink.maxBackups < 1 {
+		if err := os.Remove(sink.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return sink.open()
+	}
+
+	for i := sink.maxBackups - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", sink.path, i), fmt.Sprintf("%s.%d", sink.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(sink.path, sink.path+".1"); err != nil {
+		return err
+	}
+	return sink.open()
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestJSONAuditSink_Write(t *testing.T) {
+	var buffer bytes.Buffer
+	sink := NewJSONAuditSink(&buffer)
+	entry := AuditEntry{
+		RoomId:  uuid.MustParse("cdf64eb1-48d5-4ef5-b0c5-887b893c85dd"),
+		Time:    time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		Actor:   "Tester",
+		Role:    ProductOwner,
+		Action:  AuditEstimate,
+		Payload: "CS-123",
+	}
+
+	err := sink.Write(entry)
+
+	assert.NilError(t, err)
+	assert.Equal(t, buffer.String(), `{"roomId":"cdf64eb1-48d5-4ef5-b0c5-887b893c85dd","time":"2026-01-01T12:00:00Z","actor":"Tester","role":"product-owner","action":"estimate","payload":"CS-123"}`+"\n")
+}
+
+func TestRotatingFileAuditSink_Write(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	entry := AuditEntry{Actor: "Tester", Role: Developer, Action: AuditJoin}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// room for exactly two entries per file
+	sink, err := NewRotatingFileAuditSink(path, int64(2*(len(line)+1)), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	for range 7 {
+		assert.NilError(t, sink.Write(entry))
+	}
+
+	current, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	first, err := os.ReadFile(path + ".1")
+	assert.NilError(t, err)
+	second, err := os.ReadFile(path + ".2")
+	assert.NilError(t, err)
+	_, err = os.Stat(path + ".3")
+
+	assert.Equal(t, bytes.Count(current, []byte("\n")), 1)
+	assert.Equal(t, bytes.Count(first, []byte("\n")), 2)
+	assert.Equal(t, bytes.Count(second, []byte("\n")), 2)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewRotatingFileAuditSink_WhenMaxSizeIsInvalid(t *testing.T) {
+	_, err := NewRotatingFileAuditSink(filepath.Join(t.TempDir(), "audit.log"), 0, 1)
+
+	assert.Equal(t, err.Error(), "audit file max size must be greater than 0, got 0")
+}
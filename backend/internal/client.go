@@ -3,6 +3,8 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
 	"sync"
@@ -11,17 +13,30 @@ import (
 	"github.com/Hydoc/go-message"
 	"github.com/coder/websocket"
 	"github.com/coder/websocket/wsjson"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 const (
 	ProductOwner = "product-owner"
 	Developer    = "developer"
 	PingInterval = time.Second * 20
+	closeTimeout = time.Second
 )
 
+var (
+	ErrRateLimited = errors.New("rate limit exceeded, message was dropped")
+	// ErrNotPermitted rejects commands the role or ownership of the client
+	// does not allow.
+	ErrNotPermitted = errors.New("command is not permitted")
+)
+
+// Permissions tell a client whether it owns the room. OwnerToken is empty
+// for everybody but the owner.
 type Permissions struct {
 	CanLockRoom bool   `json:"canLockRoom"`
-	Key         string `json:"key"`
+	OwnerToken  string `json:"ownerToken"`
 }
 
 type Client struct {
@@ -32,42 +47,55 @@ type Client struct {
 	room       *Room
 	Name       string
 	Role       string
-	guess      int
+	User       *User
+	guess      string
 	doSkip     bool
 	send       chan *OutgoingWebsocketMessage
 	bus        message.Bus
+	limiter    *rate.Limiter
+	// protocol is the negotiated subprotocol, empty for legacy clients.
+	protocol string
+}
+
+// Participant is how clients are listed in the users message. Only
+// developers have isDone.
+type Participant struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	IsDone   *bool  `json:"isDone,omitempty"`
+	Verified bool   `json:"verified,omitempty"`
+	Avatar   string `json:"avatar,omitempty"`
 }
 
 func (client *Client) MarshalJSON() ([]byte, error) {
-	if client.Role == ProductOwner {
-		out := struct {
-			Name string `json:"name"`
-			Role string `json:"role"`
-		}{
-			Name: client.Name,
-			Role: client.Role,
-		}
-		return json.Marshal(out)
+	out := Participant{
+		Name:     client.Name,
+		Role:     client.Role,
+		Verified: client.User != nil,
 	}
-	out := struct {
-		Name   string `json:"name"`
-		Role   string `json:"role"`
-		IsDone bool   `json:"isDone"`
-	}{
-		Name:   client.Name,
-		Role:   client.Role,
-		IsDone: client.Guess() > 0 || client.doSkip,
+	if client.User != nil {
+		out.Avatar = client.User.Picture
+	}
+	if client.Role == Developer {
+		isDone := client.Guess() != "" || client.doSkip
+		out.IsDone = &isDone
 	}
 	return json.Marshal(out)
 }
 
-func (client *Client) Guess() int {
+// Guess returns the id of the card the client picked in the current round or
+// an empty string if it hasn't voted yet.
+func (client *Client) Guess() string {
 	client.mu.Lock()
 	defer client.mu.Unlock()
 	return client.guess
 }
 
-func NewClient(name, role string, room *Room, connection *websocket.Conn, bus message.Bus, logger *slog.Logger) *Client {
+func NewClient(name, role string, room *Room, connection *websocket.Conn, bus message.Bus, logger *slog.Logger, limiter *rate.Limiter) *Client {
+	var protocol string
+	if connection != nil {
+		protocol = connection.Subprotocol()
+	}
 	return &Client{
 		room:       room,
 		Name:       name,
@@ -76,91 +104,153 @@ func NewClient(name, role string, room *Room, connection *websocket.Conn, bus me
 		send:       make(chan *OutgoingWebsocketMessage),
 		bus:        bus,
 		logger:     logger,
+		limiter:    limiter,
+		protocol:   protocol,
 	}
 }
 
 func handleGuess(msg message.Message) (*message.Message, error) {
 	payload, ok := msg.Payload.(GuessPayload)
-	if ok && payload.client.Role == Developer {
-		payload.client.mu.Lock()
-		payload.client.guess = payload.guess
-		payload.client.doSkip = false
-		payload.client.mu.Unlock()
-		payload.client.room.broadcast <- newOutgoingWebsocketMessage(developerAction, nil)
-		payload.client.room.broadcast <- newUsers(payload.client.room.Clients)
-		payload.client.send <- newOutgoingWebsocketMessage(youGuessed, payload.guess)
+	if !ok {
+		return nil, nil
+	}
+	if payload.client.Role != Developer {
+		return nil, ErrNotPermitted
 	}
+
+	card := payload.client.room.GuessConfig.Card(payload.cardId)
+	if card == nil {
+		return nil, fmt.Errorf("client: %s guessed unknown card %q in room %s", payload.client.Name, payload.cardId, payload.client.room.Id)
+	}
+
+	payload.client.mu.Lock()
+	payload.client.guess = card.Id
+	payload.client.doSkip = false
+	payload.client.mu.Unlock()
+	payload.client.room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(developerAction, nil))
+	payload.client.room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(users, nil))
+	if card.Id == CoffeeCard {
+		payload.client.room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(breakRequested, payload.client.Name))
+	}
+	payload.client.send <- newOutgoingWebsocketMessage(youGuessed, card.Id)
 	return nil, nil
 }
 
 func handleSkipRound(msg message.Message) (*message.Message, error) {
 	payload, ok := msg.Payload.(SkipRoundPayload)
-	if ok && payload.client.Role == Developer {
-		payload.client.mu.Lock()
-		payload.client.doSkip = true
-		payload.client.guess = 0
-		payload.client.mu.Unlock()
-		payload.client.room.broadcast <- newOutgoingWebsocketMessage(developerAction, nil)
-		payload.client.room.broadcast <- newUsers(payload.client.room.Clients)
-		payload.client.send <- newOutgoingWebsocketMessage(youSkipped, nil)
+	if !ok {
+		return nil, nil
+	}
+	if payload.client.Role != Developer {
+		return nil, ErrNotPermitted
 	}
+	payload.client.mu.Lock()
+	payload.client.doSkip = true
+	payload.client.guess = ""
+	payload.client.mu.Unlock()
+	payload.client.room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(developerAction, nil))
+	payload.client.room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(users, nil))
+	payload.client.send <- newOutgoingWebsocketMessage(youSkipped, nil)
 	return nil, nil
 }
 
 func handleNewRound(msg message.Message) (*message.Message, error) {
 	payload, ok := msg.Payload.(NewRoundPayload)
-	if ok && payload.client.Role == ProductOwner {
-		payload.client.room.broadcast <- newOutgoingWebsocketMessage(newRound, nil)
+	if !ok {
+		return nil, nil
+	}
+	if payload.client.Role != ProductOwner {
+		return nil, ErrNotPermitted
 	}
+	payload.client.room.record(payload.client, AuditNewRound, nil)
+	payload.client.room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(newRound, nil))
 	return nil, nil
 }
 
 func handleLockRoom(msg message.Message) (*message.Message, error) {
 	payload, ok := msg.Payload.(LockRoomPayload)
-	if ok && payload.client.room.lock(payload.client.Name, payload.password, payload.key) {
-		payload.client.room.broadcast <- newOutgoingWebsocketMessage(roomLocked, nil)
+	if !ok {
+		return nil, nil
 	}
+	if !payload.client.room.lock(payload.client, payload.password) {
+		return nil, ErrNotPermitted
+	}
+	payload.client.room.record(payload.client, AuditLock, nil)
+	payload.client.room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(roomLocked, nil))
 	return nil, nil
 }
 
 func handleOpenRoom(msg message.Message) (*message.Message, error) {
 	payload, ok := msg.Payload.(OpenRoomPayload)
-	if ok && payload.client.room.open(payload.client.Name, payload.key) {
-		payload.client.room.broadcast <- newOutgoingWebsocketMessage(roomOpened, nil)
+	if !ok {
+		return nil, nil
+	}
+	if !payload.client.room.open(payload.client) {
+		return nil, ErrNotPermitted
+	}
+	payload.client.room.record(payload.client, AuditOpen, nil)
+	payload.client.room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(roomOpened, nil))
+	return nil, nil
+}
+
+func handleRotateOwner(msg message.Message) (*message.Message, error) {
+	payload, ok := msg.Payload.(RotateOwnerPayload)
+	if !ok {
+		return nil, nil
 	}
+	if !payload.client.room.isOwner(payload.client) {
+		return nil, ErrNotPermitted
+	}
+	payload.client.room.RotateOwnerToken()
 	return nil, nil
 }
 
 func handleEstimate(msg message.Message) (*message.Message, error) {
 	payload, ok := msg.Payload.(EstimatePayload)
-	if ok && payload.client.Role == ProductOwner {
-		payload.client.room.broadcast <- newOutgoingWebsocketMessage(estimate, payload.ticket)
+	if !ok {
+		return nil, nil
+	}
+	if payload.client.Role != ProductOwner {
+		return nil, ErrNotPermitted
 	}
+	payload.client.room.record(payload.client, AuditEstimate, payload.ticket)
+	payload.client.room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(estimate, payload.ticket))
 	return nil, nil
 }
 
 func handleReveal(msg message.Message) (*message.Message, error) {
 	payload, ok := msg.Payload.(RevealPayload)
-	if ok && payload.client.Role == ProductOwner {
-		payload.client.room.broadcast <- newReveal(payload.client.room.Clients)
+	if !ok {
+		return nil, nil
+	}
+	if payload.client.Role != ProductOwner {
+		return nil, ErrNotPermitted
 	}
+	room := payload.client.room
+	revealMessage := newReveal(room.Clients, room.GuessConfig)
+	room.record(payload.client, AuditReveal, revealMessage.Data)
+	room.broadcastTraced(payload.ctx, revealMessage)
+	room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(statistics, newStatistics(room.Clients, room.GuessConfig)))
 	return nil, nil
 }
 
 func handleAddIssue(msg message.Message) (*message.Message, error) {
 	payload, ok := msg.Payload.(AddIssuePayload)
-	if ok && payload.client.Role == ProductOwner {
-		payload.client.room.addIssue(payload.issue)
-		payload.client.room.broadcast <- newOutgoingWebsocketMessage(issues, nil)
+	if !ok {
+		return nil, nil
 	}
+	if payload.client.Role != ProductOwner {
+		return nil, ErrNotPermitted
+	}
+	payload.client.room.addIssue(payload.issue)
+	payload.client.room.record(payload.client, AuditAddIssue, payload.issue)
+	payload.client.room.broadcastTraced(payload.ctx, newOutgoingWebsocketMessage(issues, nil))
 	return nil, nil
 }
 
 func (client *Client) WebsocketReader() {
 	defer func() {
-		client.room.leave <- client
-		client.room.broadcast <- newOutgoingWebsocketMessage(leave, client.Name)
-		client.room.broadcast <- newUsers(client.room.Clients)
+		client.room.remove(client)
 		client.connection.Close(websocket.StatusNormalClosure, "")
 	}()
 	for {
@@ -177,26 +267,77 @@ func (client *Client) WebsocketReader() {
 			}
 		}
 
-		cmd, err := fabricate(incMessage, client)
-		if err != nil {
-			client.logger.Error(err.Error())
+		if client.limiter != nil && !client.limiter.Allow() {
+			client.logger.Warn("dropping message of rate limited client", "client", client.Name, "type", incMessage.Type)
+			client.send <- client.reply(incMessage, errorOccurred, ErrRateLimited.Error())
 			continue
 		}
 
+		client.handleIncoming(incMessage)
+	}
+}
+
+func (client *Client) handleIncoming(incMessage *IncomingWebsocketMessage) {
+	client.room.touch()
+	ctx, span := tracer().Start(context.Background(), "websocket "+incMessage.Type,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			RoomIdKey.String(client.room.Id.String()),
+			ClientRoleKey.String(client.Role),
+			MessageTypeKey.String(incMessage.Type),
+		),
+	)
+	defer span.End()
+
+	_, fabricateSpan := tracer().Start(ctx, "fabricate")
+	cmd, err := fabricate(ctx, incMessage, client)
+	endSpan(fabricateSpan, err)
+	if err == nil {
+		_, dispatchSpan := tracer().Start(ctx, "bus.dispatch")
 		err = client.bus.Dispatch(cmd)
-		if err != nil {
-			client.logger.Error(err.Error())
-		}
+		endSpan(dispatchSpan, err)
+	}
+
+	switch {
+	case errors.Is(err, ErrNotPermitted):
+		span.SetStatus(codes.Error, err.Error())
+		client.logger.Warn("rejected command", "client", client.Name, "type", incMessage.Type)
+	case err != nil:
+		span.SetStatus(codes.Error, err.Error())
+		client.logger.Error(err.Error())
+	}
+	client.acknowledge(incMessage, err)
+}
+
+// acknowledge answers commands with an id of clients speaking ProtocolV1
+// with an ack or, if err is not nil, an error. Effects of the command, like
+// broadcasts, may arrive after the ack.
+func (client *Client) acknowledge(incMessage *IncomingWebsocketMessage, err error) {
+	if client.protocol != ProtocolV1 || incMessage.Id == "" {
+		return
+	}
+	if err != nil {
+		client.send <- client.reply(incMessage, errorOccurred, err.Error())
+		return
+	}
+	client.send <- client.reply(incMessage, ack, nil)
+}
+
+// reply carries the id of incMessage for clients speaking ProtocolV1, legacy
+// clients never receive ids.
+func (client *Client) reply(incMessage *IncomingWebsocketMessage, msgType string, data any) *OutgoingWebsocketMessage {
+	msg := newOutgoingWebsocketMessage(msgType, data)
+	if client.protocol == ProtocolV1 {
+		msg.Id = incMessage.Id
 	}
+	return msg
 }
 
 func (client *Client) WebsocketWriter() {
 	ticker := time.NewTicker(PingInterval)
 
 	defer func() {
-		client.room.leave <- client
-		client.room.broadcast <- newOutgoingWebsocketMessage(leave, client.Name)
-		client.room.broadcast <- newUsers(client.room.Clients)
+		client.room.remove(client)
 		client.connection.Close(websocket.StatusNormalClosure, "")
 	}()
 	for {
@@ -207,6 +348,9 @@ func (client *Client) WebsocketWriter() {
 				client.logger.Error("error writing to client:", "error", err)
 				return
 			}
+			if msg.Type == roomClosed || msg.Type == disconnected {
+				return
+			}
 		case <-ticker.C:
 			ctx, cancel := context.WithTimeout(context.Background(), PingInterval)
 			err := client.connection.Ping(ctx)
@@ -222,16 +366,30 @@ func (client *Client) WebsocketWriter() {
 
 func (client *Client) newRound() {
 	client.mu.Lock()
-	client.guess = 0
+	client.guess = ""
 	client.doSkip = false
 	client.mu.Unlock()
 }
 
-func (client *Client) asReveal() map[string]any {
-	return map[string]any{
-		"name":   client.Name,
-		"role":   client.Role,
-		"guess":  client.guess,
-		"doSkip": client.doSkip,
+// RevealedGuess is the vote of a developer in the reveal message. Card is
+// nil if the developer skipped or didn't vote.
+type RevealedGuess struct {
+	Name   string            `json:"name"`
+	Role   string            `json:"role"`
+	Guess  float64           `json:"guess"`
+	Card   *GuessConfigEntry `json:"card"`
+	DoSkip bool              `json:"doSkip"`
+}
+
+func (client *Client) asReveal(guessConfig *GuessConfig) RevealedGuess {
+	out := RevealedGuess{
+		Name:   client.Name,
+		Role:   client.Role,
+		DoSkip: client.doSkip,
+	}
+	if card := guessConfig.Card(client.guess); card != nil {
+		out.Guess = card.Guess
+		out.Card = card
 	}
+	return out
 }
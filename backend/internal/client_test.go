@@ -4,17 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/coder/websocket"
 	"github.com/coder/websocket/wsjson"
-	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/time/rate"
 
 	"github.com/Hydoc/go-message"
 
@@ -24,7 +26,7 @@ import (
 func TestClient_NewProductOwner(t *testing.T) {
 	expectedName := "Test Person"
 	expectedRole := ProductOwner
-	client := NewClient(expectedName, expectedRole, &Room{}, &websocket.Conn{}, message.NewBus(), slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	client := NewClient(expectedName, expectedRole, &Room{}, &websocket.Conn{}, message.NewBus(), slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), nil)
 
 	assert.Equal(t, client.Name, expectedName)
 	assert.Equal(t, client.Role, expectedRole)
@@ -43,8 +45,8 @@ func TestClient_NewProductOwner(t *testing.T) {
 func TestClient_NewClient(t *testing.T) {
 	expectedName := "Test Person"
 	expectedRole := Developer
-	expectedGuess := 0
-	client := NewClient(expectedName, expectedRole, &Room{}, &websocket.Conn{}, message.NewBus(), slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	expectedGuess := ""
+	client := NewClient(expectedName, expectedRole, &Room{}, &websocket.Conn{}, message.NewBus(), slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), nil)
 
 	assert.Equal(t, client.Name, expectedName)
 	assert.Equal(t, client.Role, expectedRole)
@@ -67,20 +69,22 @@ func TestClient_NewClient(t *testing.T) {
 }
 
 func TestClient_Reset(t *testing.T) {
-	client := NewClient("Any", Developer, &Room{}, &websocket.Conn{}, message.NewBus(), slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
-	client.guess = 2
+	client := NewClient("Any", Developer, &Room{}, &websocket.Conn{}, message.NewBus(), slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), nil)
+	client.guess = "2"
 	client.newRound()
 
-	assert.Equal(t, client.Guess(), 0)
+	assert.Equal(t, client.Guess(), "")
 }
 
 func TestClient_WebsocketReaderWhenGuessMessageOccurredWithClientDeveloper(t *testing.T) {
 	broadcastChannel := make(chan *OutgoingWebsocketMessage)
+	guessConfig, _ := NewGuessConfig("1,2,?", "One,Two,Unsure")
 	room := &Room{
-		broadcast: broadcastChannel,
-		join:      make(chan *Client),
-		leave:     make(chan *Client),
-		Clients:   make(map[*Client]bool),
+		broadcast:   broadcastChannel,
+		join:        make(chan *Client),
+		leave:       make(chan *Client),
+		Clients:     make(map[*Client]bool),
+		GuessConfig: guessConfig,
 	}
 	server := httptest.NewServer(http.HandlerFunc(echo))
 	defer server.Close()
@@ -113,13 +117,119 @@ func TestClient_WebsocketReaderWhenGuessMessageOccurredWithClientDeveloper(t *te
 	firstBroadcastMsg := <-broadcastChannel
 	secondBroadcastMsg := <-broadcastChannel
 
-	expectedClientMsg := newOutgoingWebsocketMessage(youGuessed, 2)
+	expectedClientMsg := newOutgoingWebsocketMessage(youGuessed, "2")
 	gotClientMsg := <-clientChannel
 
 	assert.DeepEqual(t, firstBroadcastMsg, newOutgoingWebsocketMessage(developerAction, nil))
-	assert.DeepEqual(t, secondBroadcastMsg, newUsers(room.Clients))
+	assert.DeepEqual(t, secondBroadcastMsg, newOutgoingWebsocketMessage(users, nil))
 	assert.DeepEqual(t, gotClientMsg, expectedClientMsg)
-	assert.Equal(t, client.Guess(), 2)
+	assert.Equal(t, client.Guess(), "2")
+}
+
+func TestHandleGuess(t *testing.T) {
+	guessConfig, _ := NewGuessConfig("0.5,1,?,coffee", "Half,One,Unsure,Break")
+	tests := []struct {
+		name              string
+		cardId            string
+		expectedGuess     string
+		expectedBroadcast []*OutgoingWebsocketMessage
+		expectedErr       string
+	}{
+		{
+			name:          "fractional card",
+			cardId:        "0.5",
+			expectedGuess: "0.5",
+			expectedBroadcast: []*OutgoingWebsocketMessage{
+				newOutgoingWebsocketMessage(developerAction, nil),
+				newOutgoingWebsocketMessage(users, nil),
+			},
+		},
+		{
+			name:          "special card",
+			cardId:        "?",
+			expectedGuess: "?",
+			expectedBroadcast: []*OutgoingWebsocketMessage{
+				newOutgoingWebsocketMessage(developerAction, nil),
+				newOutgoingWebsocketMessage(users, nil),
+			},
+		},
+		{
+			name:          "coffee card requests a break",
+			cardId:        CoffeeCard,
+			expectedGuess: CoffeeCard,
+			expectedBroadcast: []*OutgoingWebsocketMessage{
+				newOutgoingWebsocketMessage(developerAction, nil),
+				newOutgoingWebsocketMessage(users, nil),
+				newOutgoingWebsocketMessage(breakRequested, "Test"),
+			},
+		},
+		{
+			name:        "unknown card",
+			cardId:      "13",
+			expectedErr: `client: Test guessed unknown card "13" in room 00000000-0000-0000-0000-000000000000`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broadcastChannel := make(chan *OutgoingWebsocketMessage, len(tt.expectedBroadcast))
+			room := &Room{
+				broadcast:   broadcastChannel,
+				Clients:     make(map[*Client]bool),
+				GuessConfig: guessConfig,
+			}
+			client := &Client{
+				Name: "Test",
+				Role: Developer,
+				room: room,
+				send: make(chan *OutgoingWebsocketMessage, 1),
+			}
+
+			_, err := handleGuess(message.New(guess, GuessPayload{ctx: context.Background(), client: client, cardId: tt.cardId}))
+
+			if tt.expectedErr != "" {
+				assert.Equal(t, err.Error(), tt.expectedErr)
+				assert.Equal(t, client.Guess(), "")
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, client.Guess(), tt.expectedGuess)
+			for _, want := range tt.expectedBroadcast {
+				assert.DeepEqual(t, <-broadcastChannel, want)
+			}
+			assert.DeepEqual(t, <-client.send, newOutgoingWebsocketMessage(youGuessed, tt.expectedGuess))
+		})
+	}
+}
+
+func TestHandlers_rejectCommandsNotPermitted(t *testing.T) {
+	room := &Room{Clients: make(map[*Client]bool)}
+	developer := &Client{Name: "Dev", Role: Developer, room: room}
+	productOwner := &Client{Name: "PO", Role: ProductOwner, room: room}
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		handler message.Handler
+		msg     message.Message
+	}{
+		{name: "guess", handler: handleGuess, msg: message.New(guess, GuessPayload{ctx: ctx, client: productOwner, cardId: "1"})},
+		{name: "skip", handler: handleSkipRound, msg: message.New(skipRound, SkipRoundPayload{ctx: ctx, client: productOwner})},
+		{name: "estimate", handler: handleEstimate, msg: message.New(estimate, EstimatePayload{ctx: ctx, client: developer, ticket: "PROJ-1"})},
+		{name: "new round", handler: handleNewRound, msg: message.New(newRound, NewRoundPayload{ctx: ctx, client: developer})},
+		{name: "reveal", handler: handleReveal, msg: message.New(reveal, RevealPayload{ctx: ctx, client: developer})},
+		{name: "add issue", handler: handleAddIssue, msg: message.New(addIssue, AddIssuePayload{ctx: ctx, client: developer, issue: "Login"})},
+		{name: "lock room without owning it", handler: handleLockRoom, msg: message.New(lockRoom, LockRoomPayload{ctx: ctx, client: productOwner, password: "secret"})},
+		{name: "open room without owning it", handler: handleOpenRoom, msg: message.New(openRoom, OpenRoomPayload{ctx: ctx, client: productOwner})},
+		{name: "rotate owner token without owning the room", handler: handleRotateOwner, msg: message.New(rotateOwner, RotateOwnerPayload{ctx: ctx, client: productOwner})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.handler(tt.msg)
+
+			assert.True(t, errors.Is(err, ErrNotPermitted))
+		})
+	}
 }
 
 func TestClient_websocketReaderRevealMessage(t *testing.T) {
@@ -143,14 +253,14 @@ func TestClient_websocketReaderRevealMessage(t *testing.T) {
 
 	bus := message.NewBus()
 	bus.Register(reveal, handleReveal)
-	client := NewClient("Test", ProductOwner, room, connection, bus, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	client := NewClient("Test", ProductOwner, room, connection, bus, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), nil)
 	go client.WebsocketReader()
 	go client.WebsocketWriter()
 	expectedMessage := &OutgoingWebsocketMessage{
 		Type: reveal,
-		Data: []map[string]any{},
+		Data: []RevealedGuess{},
 	}
-	client.send <- newReveal(room.Clients)
+	client.send <- newReveal(room.Clients, new(GuessConfig))
 	got := <-broadcastChannel
 
 	assert.DeepEqual(t, got, expectedMessage)
@@ -181,7 +291,7 @@ func TestClient_WebsocketReaderAddIssueMessage(t *testing.T) {
 
 	bus := message.NewBus()
 	bus.Register(addIssue, handleAddIssue)
-	client := NewClient("Test", ProductOwner, room, connection, bus, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	client := NewClient("Test", ProductOwner, room, connection, bus, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), nil)
 	go client.WebsocketReader()
 	go client.WebsocketWriter()
 	expectedMessage := &OutgoingWebsocketMessage{
@@ -222,7 +332,7 @@ func TestClient_WebsocketReaderWhenNewRoundMessageOccurredWithClientProductOwner
 
 	bus := message.NewBus()
 	bus.Register(newRound, handleNewRound)
-	client := NewClient("Test", ProductOwner, room, connection, bus, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	client := NewClient("Test", ProductOwner, room, connection, bus, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), nil)
 	go client.WebsocketReader()
 
 	expectedMsg := newOutgoingWebsocketMessage(newRound, nil)
@@ -269,7 +379,7 @@ func TestClient_WebsocketReader_WhenSkipRoundMessageOccurredWithClientDeveloper(
 	})
 
 	expectedMsg := newOutgoingWebsocketMessage(developerAction, nil)
-	secondExpectedMsg := newUsers(room.Clients)
+	secondExpectedMsg := newOutgoingWebsocketMessage(users, nil)
 	expectedClientMsg := newOutgoingWebsocketMessage(youSkipped, nil)
 	firstBroadcast := <-broadcastChannel
 	secondBroadcast := <-broadcastChannel
@@ -280,9 +390,8 @@ func TestClient_WebsocketReader_WhenSkipRoundMessageOccurredWithClientDeveloper(
 	assert.DeepEqual(t, gotClientMessage, expectedClientMsg)
 }
 
-func TestClient_WebsocketReader_WhenLockRoomMessageOccurredAnyClientCanLock(t *testing.T) {
+func TestClient_WebsocketReader_WhenLockRoomMessageOccurredTheOwnerCanLock(t *testing.T) {
 	broadcastChannel := make(chan *OutgoingWebsocketMessage)
-	id := uuid.New()
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("my cool pw"), bcrypt.DefaultCost)
 	nameOfCreator := "Test"
 	room := &Room{
@@ -291,7 +400,6 @@ func TestClient_WebsocketReader_WhenLockRoomMessageOccurredAnyClientCanLock(t *t
 		leave:          make(chan *Client),
 		Clients:        make(map[*Client]bool),
 		NameOfCreator:  nameOfCreator,
-		key:            id,
 		HashedPassword: hashedPassword,
 	}
 	server := httptest.NewServer(http.HandlerFunc(echo))
@@ -314,13 +422,13 @@ func TestClient_WebsocketReader_WhenLockRoomMessageOccurredAnyClientCanLock(t *t
 		send:       nil,
 		bus:        bus,
 	}
+	room.owner = client
 	go client.WebsocketReader()
 
 	wsjson.Write(context.Background(), connection, OutgoingWebsocketMessage{
 		Type: lockRoom,
 		Data: map[string]any{
 			"password": "my cool pw",
-			"key":      id.String(),
 		},
 	})
 
@@ -332,7 +440,6 @@ func TestClient_WebsocketReader_WhenLockRoomMessageOccurredAnyClientCanLock(t *t
 
 func TestClient_WebsocketReader_WhenOpenRoomMessageOccurred(t *testing.T) {
 	broadcastChannel := make(chan *OutgoingWebsocketMessage)
-	id := uuid.New()
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("my cool pw"), bcrypt.DefaultCost)
 	room := &Room{
 		broadcast:      broadcastChannel,
@@ -340,7 +447,6 @@ func TestClient_WebsocketReader_WhenOpenRoomMessageOccurred(t *testing.T) {
 		leave:          make(chan *Client),
 		Clients:        make(map[*Client]bool),
 		NameOfCreator:  "Test",
-		key:            id,
 		HashedPassword: hashedPassword,
 	}
 	server := httptest.NewServer(http.HandlerFunc(echo))
@@ -363,13 +469,12 @@ func TestClient_WebsocketReader_WhenOpenRoomMessageOccurred(t *testing.T) {
 		send:       nil,
 		bus:        bus,
 	}
+	room.owner = client
 	go client.WebsocketReader()
 
 	wsjson.Write(context.Background(), connection, OutgoingWebsocketMessage{
 		Type: openRoom,
-		Data: map[string]any{
-			"key": id.String(),
-		},
+		Data: nil,
 	})
 
 	expectedMsg := newOutgoingWebsocketMessage(roomOpened, nil)
@@ -422,6 +527,50 @@ func TestClient_WebsocketWriter(t *testing.T) {
 	assert.DeepEqual(t, got, expectedMsg)
 }
 
+func TestClient_WebsocketReader_WhenRateLimited(t *testing.T) {
+	broadcastChannel := make(chan *OutgoingWebsocketMessage)
+	room := &Room{
+		broadcast: broadcastChannel,
+		join:      make(chan *Client),
+		leave:     make(chan *Client),
+		Clients:   make(map[*Client]bool),
+	}
+	server := httptest.NewServer(http.HandlerFunc(echo))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	connection, _, err := websocket.Dial(context.Background(), url, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	bus := message.NewBus()
+	bus.Register(newRound, handleNewRound)
+	clientChannel := make(chan *OutgoingWebsocketMessage)
+	client := &Client{
+		connection: connection,
+		room:       room,
+		Name:       "Test",
+		Role:       ProductOwner,
+		send:       clientChannel,
+		bus:        bus,
+		logger:     slog.New(slog.DiscardHandler),
+		limiter:    rate.NewLimiter(rate.Every(time.Hour), 1),
+	}
+	go client.WebsocketReader()
+
+	msg := newOutgoingWebsocketMessage(newRound, nil)
+	wsjson.Write(context.Background(), connection, msg)
+	gotBroadcast := <-broadcastChannel
+
+	wsjson.Write(context.Background(), connection, msg)
+	gotClientMessage := <-clientChannel
+
+	assert.DeepEqual(t, gotBroadcast, msg)
+	assert.DeepEqual(t, gotClientMessage, newOutgoingWebsocketMessage(errorOccurred, ErrRateLimited.Error()))
+}
+
 func echo(w http.ResponseWriter, r *http.Request) {
 	conn, err := websocket.Accept(w, r, nil)
 	if err != nil {
@@ -2,17 +2,34 @@ package internal
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
 
+const (
+	UnsureCard   = "?"
+	CoffeeCard   = "coffee"
+	InfinityCard = "infinity"
+)
+
 type GuessConfig struct {
-	Guesses []GuessConfigEntry
+	Name    string             `json:"name"`
+	Label   string             `json:"label"`
+	Guesses []GuessConfigEntry `json:"guesses"`
 }
 
+// GuessConfigEntry is a single card of a deck. Numeric cards carry a Value and
+// count towards the round statistics, special cards like "?" or "coffee" don't.
+// Guess and Description mirror Value and Label for clients that only know
+// numeric cards.
 type GuessConfigEntry struct {
-	Guess       int    `json:"guess"`
-	Description string `json:"description"`
+	Id          string   `json:"id"`
+	Label       string   `json:"label"`
+	Value       *float64 `json:"value"`
+	Special     bool     `json:"special"`
+	Guess       float64  `json:"guess"`
+	Description string   `json:"description"`
 }
 
 func NewGuessConfig(possibleGuesses, possibleGuessesDescription string) (*GuessConfig, error) {
@@ -25,19 +42,56 @@ func NewGuessConfig(possibleGuesses, possibleGuessesDescription string) (*GuessC
 		return nil, fmt.Errorf("error length for guesses and guesses desc is differrent (guesses = %d, guesses desc = %d)", len(splitPossibleGuesses), len(splitGuessesDesc))
 	}
 
+	seen := make(map[string]bool, len(splitPossibleGuesses))
 	for i, guessStr := range splitPossibleGuesses {
-		value, err := strconv.Atoi(guessStr)
+		entry, err := newGuessConfigEntry(strings.TrimSpace(guessStr), splitGuessesDesc[i])
 		if err != nil {
-			return nil, fmt.Errorf("error can not convert guess %s to int", guessStr)
+			return nil, err
 		}
+		if seen[entry.Id] {
+			return nil, fmt.Errorf("error guess %s is defined more than once", entry.Id)
+		}
+		seen[entry.Id] = true
 
-		guesses = append(guesses, GuessConfigEntry{
-			Guess:       value,
-			Description: splitGuessesDesc[i],
-		})
+		guesses = append(guesses, entry)
 	}
 
 	return &GuessConfig{
 		Guesses: guesses,
 	}, nil
 }
+
+func newGuessConfigEntry(guessStr, description string) (GuessConfigEntry, error) {
+	switch guessStr {
+	case UnsureCard, CoffeeCard, InfinityCard:
+		return GuessConfigEntry{
+			Id:          guessStr,
+			Label:       description,
+			Special:     true,
+			Description: description,
+		}, nil
+	}
+
+	value, err := strconv.ParseFloat(guessStr, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return GuessConfigEntry{}, fmt.Errorf("error can not convert guess %s to a non-negative number or special card (%s, %s, %s)", guessStr, UnsureCard, CoffeeCard, InfinityCard)
+	}
+
+	return GuessConfigEntry{
+		Id:          strconv.FormatFloat(value, 'f', -1, 64),
+		Label:       description,
+		Value:       &value,
+		Guess:       value,
+		Description: description,
+	}, nil
+}
+
+// Card returns the entry with the given id, or nil if the deck doesn't contain it.
+func (guessConfig *GuessConfig) Card(id string) *GuessConfigEntry {
+	for i := range guessConfig.Guesses {
+		if guessConfig.Guesses[i].Id == id {
+			return &guessConfig.Guesses[i]
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/Hydoc/estimation-poker/backend/internal/assert"
@@ -11,26 +12,11 @@ func TestNewGuessConfig(t *testing.T) {
 	possibleGuessesDesc := "A,B,C,D,E"
 	want := &GuessConfig{
 		Guesses: []GuessConfigEntry{
-			{
-				Guess:       1,
-				Description: "A",
-			},
-			{
-				Guess:       2,
-				Description: "B",
-			},
-			{
-				Guess:       3,
-				Description: "C",
-			},
-			{
-				Guess:       4,
-				Description: "D",
-			},
-			{
-				Guess:       5,
-				Description: "E",
-			},
+			numericEntry(1, "A"),
+			numericEntry(2, "B"),
+			numericEntry(3, "C"),
+			numericEntry(4, "D"),
+			numericEntry(5, "E"),
 		},
 	}
 
@@ -74,8 +60,43 @@ func TestNewGuessConfig_WhenGuessIsNotNumeric(t *testing.T) {
 	possibleGuesses := "1,2,P,4,5"
 	possibleGuessesDesc := "A,B,C,D,E"
 
-	expectedErr := "error can not convert guess P to int"
+	expectedErr := "error can not convert guess P to a non-negative number or special card (?, coffee, infinity)"
 
 	_, err := NewGuessConfig(possibleGuesses, possibleGuessesDesc)
 	assert.DeepEqual(t, err.Error(), expectedErr)
 }
+
+func TestNewGuessConfig_WithSpecialCards(t *testing.T) {
+	want := &GuessConfig{
+		Guesses: []GuessConfigEntry{
+			numericEntry(0, "Nothing"),
+			numericEntry(0.5, "Half"),
+			{Id: UnsureCard, Label: "Unsure", Special: true, Description: "Unsure"},
+			{Id: CoffeeCard, Label: "Break", Special: true, Description: "Break"},
+			{Id: InfinityCard, Label: "Too big", Special: true, Description: "Too big"},
+		},
+	}
+
+	got, err := NewGuessConfig("0,0.5,?,coffee,infinity", "Nothing,Half,Unsure,Break,Too big")
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, want)
+	assert.DeepEqual(t, got.Card("0.5"), &got.Guesses[1])
+	assert.True(t, got.Card("13") == nil)
+}
+
+func TestNewGuessConfig_WhenGuessIsDuplicated(t *testing.T) {
+	_, err := NewGuessConfig("1,2,1.0", "A,B,C")
+
+	assert.Equal(t, err.Error(), "error guess 1 is defined more than once")
+}
+
+func numericEntry(value float64, description string) GuessConfigEntry {
+	return GuessConfigEntry{
+		Id:          strconv.FormatFloat(value, 'f', -1, 64),
+		Label:       description,
+		Value:       &value,
+		Guess:       value,
+		Description: description,
+	}
+}
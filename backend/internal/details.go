@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxTitleLength       = 100
+	maxDescriptionLength = 1000
+	maxTeamLength        = 100
+	maxTags              = 10
+)
+
+var validTag = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,29}$`)
+
+// RoomDetails describe a room in the lobby. They are chosen at creation and
+// can be changed by the owner of the room afterwards.
+type RoomDetails struct {
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Team        string   `json:"team,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+func (details RoomDetails) IsZero() bool {
+	return details.Title == "" && details.Description == "" && details.Team == "" && len(details.Tags) == 0
+}
+
+// Normalize trims every field and lower cases and deduplicates the tags.
+func (details RoomDetails) Normalize() RoomDetails {
+	out := RoomDetails{
+		Title:       strings.TrimSpace(details.Title),
+		Description: strings.TrimSpace(details.Description),
+		Team:        strings.TrimSpace(details.Team),
+	}
+	for _, tag := range details.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(out.Tags, tag) {
+			out.Tags = append(out.Tags, tag)
+		}
+	}
+	return out
+}
+
+func (details RoomDetails) Validate() error {
+	var errs []error
+	if utf8.RuneCountInString(details.Title) > maxTitleLength {
+		errs = append(errs, fmt.Errorf("title must not be longer than %d characters", maxTitleLength))
+	}
+	if utf8.RuneCountInString(details.Description) > maxDescriptionLength {
+		errs = append(errs, fmt.Errorf("description must not be longer than %d characters", maxDescriptionLength))
+	}
+	if utf8.RuneCountInString(details.Team) > maxTeamLength {
+		errs = append(errs, fmt.Errorf("team must not be longer than %d characters", maxTeamLength))
+	}
+	if len(details.Tags) > maxTags {
+		errs = append(errs, fmt.Errorf("a room must not have more than %d tags", maxTags))
+	}
+	for _, tag := range details.Tags {
+		if !validTag.MatchString(tag) {
+			errs = append(errs, fmt.Errorf("tag %q must be up to 30 lowercase letters, digits or dashes", tag))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Matches reports whether the details carry tag and contain query in any of
+// their texts, ignoring case. Empty arguments match everything.
+func (details RoomDetails) Matches(tag, query string) bool {
+	if tag != "" && !slices.Contains(details.Tags, strings.ToLower(tag)) {
+		return false
+	}
+	if query == "" {
+		return true
+	}
+
+	query = strings.ToLower(query)
+	for _, text := range append([]string{details.Title, details.Description, details.Team}, details.Tags...) {
+		if strings.Contains(strings.ToLower(text), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func (room *Room) Details() RoomDetails {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return room.details
+}
+
+// SetDetails replaces the details of the room and tells the connected clients
+// about it.
+func (room *Room) SetDetails(details RoomDetails) {
+	room.mu.Lock()
+	room.details = details
+	room.mu.Unlock()
+
+	room.record(&Client{Name: room.NameOfCreator, Role: ProductOwner}, AuditDetails, details)
+	room.enqueue(newOutgoingWebsocketMessage(roomDetails, details))
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestRoomDetails_Normalize(t *testing.T) {
+	details := RoomDetails{
+		Title:       "  Sprint 42 ",
+		Description: "Planning\n",
+		Team:        " Rocket",
+		Tags:        []string{"Backend", "backend", " ", "q3 "},
+	}
+
+	got := details.Normalize()
+
+	assert.DeepEqual(t, got, RoomDetails{
+		Title:       "Sprint 42",
+		Description: "Planning",
+		Team:        "Rocket",
+		Tags:        []string{"backend", "q3"},
+	})
+}
+
+func TestRoomDetails_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		details RoomDetails
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			details: RoomDetails{Title: "Sprint 42", Tags: []string{"backend"}},
+		},
+		{
+			name:    "title too long",
+			details: RoomDetails{Title: string(make([]rune, maxTitleLength+1))},
+			wantErr: "title must not be longer than 100 characters",
+		},
+		{
+			name:    "invalid tag",
+			details: RoomDetails{Tags: []string{"no spaces"}},
+			wantErr: `tag "no spaces" must be up to 30 lowercase letters, digits or dashes`,
+		},
+		{
+			name:    "too many tags",
+			details: RoomDetails{Tags: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}},
+			wantErr: "a room must not have more than 10 tags",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.details.Validate()
+
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Equal(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRoomDetails_Matches(t *testing.T) {
+	details := RoomDetails{Title: "Sprint 42", Description: "Planning", Team: "Rocket", Tags: []string{"backend"}}
+	tests := []struct {
+		name  string
+		tag   string
+		query string
+		want  bool
+	}{
+		{name: "no filter", want: true},
+		{name: "tag", tag: "Backend", want: true},
+		{name: "other tag", tag: "frontend", want: false},
+		{name: "query in title", query: "sprint", want: true},
+		{name: "query in team", query: "ROCK", want: true},
+		{name: "query in tags", query: "back", want: true},
+		{name: "query not found", query: "retro", want: false},
+		{name: "tag and query", tag: "backend", query: "retro", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, details.Matches(tt.tag, tt.query), tt.want)
+		})
+	}
+}
+
+func TestRoom_SetDetails(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	client := &Client{Name: "Dev", Role: Developer, send: make(chan *OutgoingWebsocketMessage, 1)}
+	go room.Run()
+	room.join <- client
+	details := RoomDetails{Title: "Sprint 42"}
+
+	room.SetDetails(details)
+
+	assert.DeepEqual(t, <-client.send, newOutgoingWebsocketMessage(roomDetails, details))
+	assert.DeepEqual(t, room.Details(), details)
+	assert.Equal(t, room.State().Title, "Sprint 42")
+	trail := room.AuditTrail()
+	assert.Equal(t, trail[len(trail)-1].Action, AuditDetails)
+	assert.Equal(t, trail[len(trail)-1].Actor, "Tester")
+}
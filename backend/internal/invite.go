@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidInvite = errors.New("invite is invalid")
+	ErrInviteExpired = errors.New("invite has expired")
+	ErrInviteUsedUp  = errors.New("invite has been used up")
+)
+
+// Invite lets its holder join a room with a preassigned role, without knowing
+// the room password and even if the room is private.
+type Invite struct {
+	Id        uuid.UUID `json:"id"`
+	RoomId    uuid.UUID `json:"room"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// MaxUses limits how often the invite can be redeemed, 0 means unlimited.
+	MaxUses int `json:"maxUses,omitempty"`
+}
+
+const invitePurpose = "invite"
+
+// SignInvite turns invite into a token which only VerifyInvite accepts.
+func (signer *TokenSigner) SignInvite(invite Invite) string {
+	return signer.ForPurpose(invitePurpose).Sign(invite)
+}
+
+// VerifyInvite returns the invite of token if it was signed by signer and has
+// not expired at now. Whether it was used up is up to the room.
+func (signer *TokenSigner) VerifyInvite(token string, now time.Time) (Invite, error) {
+	var invite Invite
+	if err := signer.ForPurpose(invitePurpose).Verify(token, &invite); err != nil {
+		return Invite{}, ErrInvalidInvite
+	}
+	if !now.Before(invite.ExpiresAt) {
+		return Invite{}, ErrInviteExpired
+	}
+	return invite, nil
+}
+
+// NewInvite registers an invite to the room for role which expires after ttl
+// and can be redeemed maxUses times.
+func (room *Room) NewInvite(role string, ttl time.Duration, maxUses int) Invite {
+	invite := Invite{
+		Id:        uuid.New(),
+		RoomId:    room.Id,
+		Role:      role,
+		ExpiresAt: time.Now().Add(ttl).UTC().Truncate(time.Second),
+		MaxUses:   maxUses,
+	}
+
+	room.mu.Lock()
+	if room.inviteUses == nil {
+		room.inviteUses = make(map[uuid.UUID]int)
+	}
+	room.inviteUses[invite.Id] = 0
+	room.mu.Unlock()
+
+	room.record(&Client{Name: room.NameOfCreator, Role: ProductOwner}, AuditInvite, invite)
+	return invite
+}
+
+// RedeemInvite counts one use of invite, which has to be verified already.
+func (room *Room) RedeemInvite(invite Invite) error {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if err := room.checkInvite(invite); err != nil {
+		return err
+	}
+	room.inviteUses[invite.Id]++
+	return nil
+}
+
+// checkInvite requires room.mu to be held.
+func (room *Room) checkInvite(invite Invite) error {
+	uses, ok := room.inviteUses[invite.Id]
+	switch {
+	case !ok || invite.RoomId != room.Id:
+		return ErrInvalidInvite
+	case invite.MaxUses > 0 && uses >= invite.MaxUses:
+		return ErrInviteUsedUp
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestTokenSigner_VerifyInvite(t *testing.T) {
+	signer, err := NewTokenSigner([]byte("key"))
+	assert.NilError(t, err)
+	other, err := NewTokenSigner(nil)
+	assert.NilError(t, err)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	invite := Invite{Id: uuid.New(), RoomId: uuid.New(), Role: Developer, ExpiresAt: now.Add(time.Hour), MaxUses: 3}
+	token := signer.SignInvite(invite)
+	payload, signature, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		now     time.Time
+		wantErr error
+	}{
+		{name: "valid", token: token, now: now},
+		{name: "expired", token: token, now: now.Add(time.Hour), wantErr: ErrInviteExpired},
+		{name: "signed by another key", token: other.SignInvite(invite), now: now, wantErr: ErrInvalidInvite},
+		{name: "signed for another purpose", token: signer.Sign(invite), now: now, wantErr: ErrInvalidInvite},
+		{name: "tampered payload", token: payload + "x." + signature, now: now, wantErr: ErrInvalidInvite},
+		{name: "no signature", token: payload, now: now, wantErr: ErrInvalidInvite},
+		{name: "garbage", token: "not.a-token", now: now, wantErr: ErrInvalidInvite},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := signer.VerifyInvite(tt.token, tt.now)
+
+			assert.Equal(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, got, invite)
+			}
+		})
+	}
+}
+
+func TestRoom_RedeemInvite(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	otherRoom := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	invite := room.NewInvite(Developer, time.Hour, 2)
+
+	assert.NilError(t, room.RedeemInvite(invite))
+	assert.NilError(t, room.RedeemInvite(invite))
+	assert.Equal(t, room.RedeemInvite(invite), ErrInviteUsedUp)
+	assert.Equal(t, otherRoom.RedeemInvite(invite), ErrInvalidInvite)
+	assert.Equal(t, room.AuditTrail()[0].Action, AuditInvite)
+
+	unlimited := room.NewInvite(ProductOwner, time.Hour, 0)
+	for range 5 {
+		assert.NilError(t, room.RedeemInvite(unlimited))
+	}
+}
+
+func TestRoom_ConnectionStateWithInvite(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	room.HashedPassword = []byte("locked")
+	room.visibility = VisibilityPrivate
+	invite := room.NewInvite(ProductOwner, time.Hour, 1)
+
+	assert.Equal(t, room.ConnectionState("Dev", "", nil, nil), ConnectionState{Reason: ErrPrivateRoom.Error()})
+	assert.Equal(t, room.ConnectionState("Dev", "", &invite, nil), ConnectionState{CanConnect: true, Role: ProductOwner})
+
+	assert.NilError(t, room.RedeemInvite(invite))
+	assert.Equal(t, room.ConnectionState("Dev", "", &invite, nil), ConnectionState{Reason: ErrInviteUsedUp.Error()})
+}
@@ -1,13 +1,14 @@
 package internal
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"sort"
+	"strconv"
 
 	"github.com/Hydoc/go-message"
-	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -29,63 +30,95 @@ const (
 	issues          = "issues"
 	permissions     = "permissions"
 	users           = "users"
+	errorOccurred   = "error"
+	breakRequested  = "break-requested"
+	statistics      = "statistics"
+	roomExpiring    = "room-expiring"
+	roomClosed      = "room-closed"
+	roomDetails     = "room-details"
+	roomVisibility  = "room-visibility"
+	rotateOwner     = "rotate-owner-token"
+	disconnected    = "disconnected"
+	maintenance     = "maintenance"
+	ack             = "ack"
 )
 
 type IncomingWebsocketMessage struct {
+	// Id is chosen by the client, see ProtocolV1.
+	Id   string          `json:"id,omitempty"`
 	Type string          `json:"type"`
 	Data json.RawMessage `json:"data"`
 }
 
 type OutgoingWebsocketMessage struct {
+	// Id is the id of the command an ack or error answers.
+	Id   string `json:"id,omitempty"`
 	Type string `json:"type"`
 	Data any    `json:"data"`
+
+	// queuedBy is the span that handed the message to the room loop, which
+	// delivers it as a child of that span.
+	queuedBy trace.SpanContext
 }
 
 type SkipRoundPayload struct {
+	ctx    context.Context
 	client *Client
 }
 
 type NewRoundPayload struct {
+	ctx    context.Context
 	client *Client
 }
 
 type LockRoomPayload struct {
+	ctx      context.Context
 	client   *Client
-	key      string
 	password string
 }
 
 type OpenRoomPayload struct {
+	ctx    context.Context
+	client *Client
+}
+
+type RotateOwnerPayload struct {
+	ctx    context.Context
 	client *Client
-	key    string
 }
 
 type EstimatePayload struct {
+	ctx    context.Context
 	client *Client
 	ticket string
 }
 
 type AddIssuePayload struct {
+	ctx    context.Context
 	client *Client
 	issue  string
 }
 
 type GuessPayload struct {
+	ctx    context.Context
 	client *Client
-	guess  int
+	cardId string
 }
 
 type RevealPayload struct {
+	ctx    context.Context
 	client *Client
 }
 
-func newPermissions(clientName, roomCreatorName string, key uuid.UUID) *OutgoingWebsocketMessage {
-	if clientName == roomCreatorName {
+// newPermissions tells a client whether it owns the room. Only the owner
+// receives the owner token.
+func newPermissions(isOwner bool, ownerToken string) *OutgoingWebsocketMessage {
+	if isOwner {
 		return &OutgoingWebsocketMessage{
 			Type: permissions,
 			Data: Permissions{
 				CanLockRoom: true,
-				Key:         key.String(),
+				OwnerToken:  ownerToken,
 			},
 		}
 	}
@@ -122,11 +155,11 @@ func newUsers(clients map[*Client]bool) *OutgoingWebsocketMessage {
 	}
 }
 
-func newReveal(clients map[*Client]bool) *OutgoingWebsocketMessage {
-	out := []map[string]any{}
+func newReveal(clients map[*Client]bool, guessConfig *GuessConfig) *OutgoingWebsocketMessage {
+	out := []RevealedGuess{}
 	for client := range clients {
 		if client.Role == Developer {
-			out = append(out, client.asReveal())
+			out = append(out, client.asReveal(guessConfig))
 		}
 	}
 
@@ -136,6 +169,20 @@ func newReveal(clients map[*Client]bool) *OutgoingWebsocketMessage {
 	}
 }
 
+// readCardId accepts the id of a card or, for older clients, its numeric value.
+func readCardId(data json.RawMessage) (string, error) {
+	var cardId string
+	if err := json.Unmarshal(data, &cardId); err == nil && cardId != "" {
+		return cardId, nil
+	}
+
+	var value float64
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", errors.New("guess is invalid")
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64), nil
+}
+
 func CreateBus() message.Bus {
 	bus := message.NewBus()
 	bus.Register(skipRound, handleSkipRound)
@@ -145,16 +192,18 @@ func CreateBus() message.Bus {
 	bus.Register(reveal, handleReveal)
 	bus.Register(lockRoom, handleLockRoom)
 	bus.Register(openRoom, handleOpenRoom)
+	bus.Register(rotateOwner, handleRotateOwner)
 	bus.Register(addIssue, handleAddIssue)
 	return bus
 }
 
-func fabricate(incomingMessage *IncomingWebsocketMessage, client *Client) (message.Message, error) {
+func fabricate(ctx context.Context, incomingMessage *IncomingWebsocketMessage, client *Client) (message.Message, error) {
 	switch incomingMessage.Type {
 	case skipRound:
 		return message.New(
 			skipRound,
 			SkipRoundPayload{
+				ctx:    ctx,
 				client: client,
 			},
 		), nil
@@ -167,57 +216,48 @@ func fabricate(incomingMessage *IncomingWebsocketMessage, client *Client) (messa
 		return message.New(
 			estimate,
 			EstimatePayload{
+				ctx:    ctx,
 				client: client,
 				ticket: ticket,
 			},
 		), nil
 	case guess:
-		var actualGuess int
-		if err := json.Unmarshal(incomingMessage.Data, &actualGuess); err != nil {
-			return message.Message{}, errors.New("guess is invalid")
+		cardId, err := readCardId(incomingMessage.Data)
+		if err != nil {
+			return message.Message{}, err
 		}
 		return message.New(guess, GuessPayload{
+			ctx:    ctx,
 			client: client,
-			guess:  actualGuess,
+			cardId: cardId,
 		}), nil
 	case newRound:
-		return message.New(newRound, NewRoundPayload{client: client}), nil
+		return message.New(newRound, NewRoundPayload{ctx: ctx, client: client}), nil
 	case reveal:
-		return message.New(reveal, RevealPayload{client: client}), nil
+		return message.New(reveal, RevealPayload{ctx: ctx, client: client}), nil
 	case lockRoom:
-		var input struct {
-			Password string `json:"password"`
-			Key      string `json:"key"`
-		}
-
+		var input LockRoomData
 		if err := json.Unmarshal(incomingMessage.Data, &input); err != nil {
 			return message.Message{}, errors.New("lockRoom payload is invalid")
 		}
 
 		return message.New(lockRoom, LockRoomPayload{
+			ctx:      ctx,
 			client:   client,
-			key:      input.Key,
 			password: input.Password,
 		}), nil
 	case openRoom:
-		var input struct {
-			Key string `json:"key"`
-		}
-		if err := json.Unmarshal(incomingMessage.Data, &input); err != nil {
-			return message.Message{}, fmt.Errorf("client: %s tried to open room %s without a key", client.Name, client.room.Id)
-		}
-
-		return message.New(openRoom, OpenRoomPayload{
-			client: client,
-			key:    input.Key,
-		}), nil
+		return message.New(openRoom, OpenRoomPayload{ctx: ctx, client: client}), nil
+	case rotateOwner:
+		return message.New(rotateOwner, RotateOwnerPayload{ctx: ctx, client: client}), nil
 	case addIssue:
 		var issue string
 		if err := json.Unmarshal(incomingMessage.Data, &issue); err != nil {
-			return message.Message{}, fmt.Errorf("client: %s tried to open room %s without a key", client.Name, client.room.Id)
+			return message.Message{}, errors.New("issue is invalid")
 		}
 
 		return message.New(addIssue, AddIssuePayload{
+			ctx:    ctx,
 			client: client,
 			issue:  issue,
 		}), nil
@@ -1,10 +1,9 @@
 package internal
 
 import (
+	"encoding/json"
 	"testing"
 
-	"github.com/google/uuid"
-
 	"github.com/Hydoc/estimation-poker/backend/internal/assert"
 )
 
@@ -47,9 +46,9 @@ func TestMessage(t *testing.T) {
 		},
 		{
 			name:         "newReveal",
-			msg:          newReveal(make(map[*Client]bool)),
+			msg:          newReveal(make(map[*Client]bool), new(GuessConfig)),
 			expectedType: reveal,
-			expectedData: []map[string]any{},
+			expectedData: []RevealedGuess{},
 		},
 		{
 			name:         "youSkipped",
@@ -65,19 +64,19 @@ func TestMessage(t *testing.T) {
 		},
 		{
 			name:         "newPermissions",
-			msg:          newPermissions("Test", "Abc", uuid.New()),
+			msg:          newPermissions(false, ""),
 			expectedType: permissions,
 			expectedData: Permissions{
 				CanLockRoom: false,
 			},
 		},
 		{
-			name:         "newPermissions when client and room creator have same name",
-			msg:          newPermissions("Test", "Test", uuid.MustParse("67ddc335-0aa0-41f9-8289-2649da77aee7")),
+			name:         "newPermissions for the owner",
+			msg:          newPermissions(true, "owner-token"),
 			expectedType: permissions,
 			expectedData: Permissions{
 				CanLockRoom: true,
-				Key:         uuid.MustParse("67ddc335-0aa0-41f9-8289-2649da77aee7").String(),
+				OwnerToken:  "owner-token",
 			},
 		},
 	}
@@ -89,3 +88,58 @@ func TestMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestNewReveal_WithCards(t *testing.T) {
+	guessConfig, _ := NewGuessConfig("0,0.5,1,?", "Zero,Half,One,Unsure")
+	clients := map[*Client]bool{
+		{Name: "A", Role: Developer, guess: "1"}:   true,
+		{Name: "B", Role: Developer, guess: "?"}:   true,
+		{Name: "C", Role: Developer, doSkip: true}: true,
+		{Name: "D", Role: ProductOwner}:            true,
+		{Name: "E", Role: Developer, guess: "0.5"}: true,
+	}
+
+	got := newReveal(clients, guessConfig)
+
+	byName := make(map[string]RevealedGuess)
+	for _, entry := range got.Data.([]RevealedGuess) {
+		byName[entry.Name] = entry
+	}
+	assert.Equal(t, len(byName), 4)
+	assert.Equal(t, byName["A"].Guess, 1)
+	assert.DeepEqual(t, byName["A"].Card, guessConfig.Card("1"))
+	assert.Equal(t, byName["B"].Guess, 0)
+	assert.DeepEqual(t, byName["B"].Card, guessConfig.Card("?"))
+	assert.True(t, byName["C"].Card == nil)
+	assert.Equal(t, byName["C"].DoSkip, true)
+	assert.Equal(t, byName["E"].Guess, 0.5)
+	assert.DeepEqual(t, byName["E"].Card, guessConfig.Card("0.5"))
+}
+
+func TestReadCardId(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		want        string
+		expectedErr string
+	}{
+		{name: "card id", data: `"coffee"`, want: "coffee"},
+		{name: "legacy integer guess", data: `3`, want: "3"},
+		{name: "fractional guess", data: `0.5`, want: "0.5"},
+		{name: "invalid", data: `{"card":1}`, expectedErr: "guess is invalid"},
+		{name: "empty id", data: `""`, expectedErr: "guess is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCardId(json.RawMessage(tt.data))
+
+			if tt.expectedErr != "" {
+				assert.Equal(t, err.Error(), tt.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ownerClaims identify the owner of a room. Rotating the owner token bumps
+// the generation, which invalidates every token handed out before.
+type ownerClaims struct {
+	RoomId     uuid.UUID `json:"room"`
+	Generation int       `json:"gen"`
+}
+
+// claimOwnership binds ownership of the room to the websocket session of
+// client. A room created by an account is claimed by logging in with that
+// account. Otherwise the creator claims an unowned room by joining it for the
+// first time, anyone else, including a returning creator, needs the owner
+// token.
+func (room *Room) claimOwnership(client *Client, token string) bool {
+	valid := token != "" && room.IsOwnerToken(token)
+	if room.OwnerSubject != "" {
+		valid = valid || (client.User != nil && client.User.Subject == room.OwnerSubject)
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	anonymousCreator := room.OwnerSubject == "" && !room.ownerClaimed && client.Name == room.NameOfCreator
+	if !valid && !anonymousCreator {
+		return false
+	}
+	room.owner = client
+	room.ownerClaimed = true
+	return true
+}
+
+// releaseOwnership unbinds ownership once the owner's session has left.
+func (room *Room) releaseOwnership(client *Client) {
+	room.mu.Lock()
+	if room.owner == client {
+		room.owner = nil
+	}
+	room.mu.Unlock()
+}
+
+func (room *Room) isOwner(client *Client) bool {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return room.owner != nil && room.owner == client
+}
+
+// OwnerToken returns the current owner token, which authorizes REST requests
+// on behalf of the owner and lets the owner take over the room with another
+// session.
+func (room *Room) OwnerToken() string {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return room.ownerTokens.Sign(ownerClaims{RoomId: room.Id, Generation: room.ownerGeneration})
+}
+
+func (room *Room) IsOwnerToken(token string) bool {
+	if room.ownerTokens == nil {
+		return false
+	}
+	var claims ownerClaims
+	if err := room.ownerTokens.Verify(token, &claims); err != nil {
+		return false
+	}
+
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return claims.RoomId == room.Id && claims.Generation == room.ownerGeneration
+}
+
+// RotateOwnerToken invalidates every owner token and returns a new one. The
+// owner's session, if connected, receives the new token as permissions.
+func (room *Room) RotateOwnerToken() string {
+	room.mu.Lock()
+	room.ownerGeneration++
+	owner := room.owner
+	room.mu.Unlock()
+
+	token := room.OwnerToken()
+	room.record(&Client{Name: room.NameOfCreator, Role: ProductOwner}, AuditRotateOwnerToken, nil)
+	if owner != nil {
+		select {
+		case owner.send <- newPermissions(true, token):
+		case <-room.done:
+		case <-time.After(closeTimeout):
+			room.logger.Warn("owner did not receive rotated token", "room", room.Id, "client", owner.Name)
+		}
+	}
+	return token
+}
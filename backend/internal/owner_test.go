@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestRoom_claimOwnership(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	token := room.OwnerToken()
+	creator := &Client{Name: "Tester"}
+	impostor := &Client{Name: "Tester"}
+	returning := &Client{Name: "Tester on the phone"}
+
+	assert.False(t, room.claimOwnership(&Client{Name: "Dev"}, ""))
+	assert.True(t, room.claimOwnership(creator, ""))
+	assert.True(t, room.isOwner(creator))
+
+	assert.False(t, room.claimOwnership(impostor, ""))
+	assert.False(t, room.claimOwnership(impostor, "forged"))
+	assert.False(t, room.isOwner(impostor))
+
+	room.releaseOwnership(creator)
+	assert.False(t, room.isOwner(creator))
+	assert.False(t, room.claimOwnership(creator, ""))
+	assert.True(t, room.claimOwnership(returning, token))
+	assert.True(t, room.isOwner(returning))
+}
+
+func TestRoom_claimOwnershipWithAccount(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Alice", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	room.OwnerSubject = "alice"
+
+	assert.False(t, room.claimOwnership(&Client{Name: "Alice"}, ""))
+	assert.False(t, room.claimOwnership(&Client{Name: "Alice", User: &User{Subject: "mallory", Name: "Alice"}}, ""))
+	assert.True(t, room.claimOwnership(&Client{Name: "Alice on the phone", User: &User{Subject: "alice"}}, ""))
+}
+
+func TestRoom_IsOwnerToken(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	otherRoom := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	token := room.OwnerToken()
+
+	assert.True(t, room.IsOwnerToken(token))
+	assert.False(t, otherRoom.IsOwnerToken(token))
+	assert.False(t, room.IsOwnerToken(otherRoom.OwnerToken()))
+	assert.False(t, room.IsOwnerToken(""))
+	assert.False(t, (&Room{}).IsOwnerToken(token))
+}
+
+func TestRoom_RotateOwnerToken(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	owner := &Client{Name: "Tester", send: make(chan *OutgoingWebsocketMessage, 1)}
+	room.claimOwnership(owner, "")
+	old := room.OwnerToken()
+
+	rotated := room.RotateOwnerToken()
+
+	assert.False(t, room.IsOwnerToken(old))
+	assert.True(t, room.IsOwnerToken(rotated))
+	assert.DeepEqual(t, <-owner.send, newPermissions(true, rotated))
+	trail := room.AuditTrail()
+	assert.Equal(t, trail[len(trail)-1].Action, AuditRotateOwnerToken)
+	assert.Equal(t, trail[len(trail)-1].Payload, nil)
+}
@@ -0,0 +1,179 @@
+package internal
+
+import "sync"
+
+// ProtocolV1 is the websocket subprotocol of the first versioned protocol.
+// Clients offering it may give their commands an id, which the server
+// answers with an ack or an error carrying the same id. Clients without a
+// subprotocol speak the legacy protocol, which has the same messages but
+// never receives ids.
+const ProtocolV1 = "estimation-poker.v1"
+
+// Subprotocols lists the protocols the server speaks, newest first.
+var Subprotocols = []string{ProtocolV1}
+
+// CardId is the data of guess commands: the id of a card or, for older
+// clients, its numeric value.
+type CardId string
+
+func (CardId) JSONSchema() map[string]any {
+	return map[string]any{"oneOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "number"}}}
+}
+
+type LockRoomData struct {
+	Password string `json:"password"`
+}
+
+// MessageSpec documents a message type. Data is a value of the Go type of
+// the data, nil if the message has none.
+type MessageSpec struct {
+	Type    string
+	Summary string
+	Data    any
+}
+
+// Commands are the messages clients send.
+var Commands = []MessageSpec{
+	{guess, "Votes for a card, developers only.", CardId("")},
+	{skipRound, "Skips the round, developers only.", nil},
+	{estimate, "Starts estimating a ticket, product owners only.", ""},
+	{newRound, "Starts a new round, product owners only.", nil},
+	{reveal, "Reveals the guesses, product owners only.", nil},
+	{lockRoom, "Protects the room with a password, owner only.", LockRoomData{}},
+	{openRoom, "Removes the password, owner only.", nil},
+	{addIssue, "Adds an issue to the backlog of the room, product owners only.", ""},
+	{rotateOwner, "Invalidates the owner token, the new one arrives as permissions. Owner only.", nil},
+}
+
+// Events are the messages the server sends.
+var Events = []MessageSpec{
+	{ack, "Accepts the command with the same id.", nil},
+	{errorOccurred, "Rejects the command with the same id or, without id, tells about a dropped message.", ""},
+	{permissions, "Tells whether the client owns the room.", Permissions{}},
+	{users, "Lists the clients of the room.", []Participant{}},
+	{leave, "Tells the name of a client that left.", ""},
+	{developerAction, "A developer guessed or skipped.", nil},
+	{everyoneDone, "Every developer is done, data lists missing roster members in team rooms.", (*EveryoneDone)(nil)},
+	{youGuessed, "Confirms the card of the own guess.", ""},
+	{youSkipped, "Confirms skipping the round.", nil},
+	{estimate, "Estimation of a ticket started.", ""},
+	{newRound, "A new round started.", nil},
+	{reveal, "The guesses of the developers.", []RevealedGuess{}},
+	{statistics, "Statistics of the revealed guesses.", Statistics{}},
+	{breakRequested, "Tells the name of the developer who played the coffee card.", ""},
+	{roomLocked, "The room was protected with a password.", nil},
+	{roomOpened, "The password of the room was removed.", nil},
+	{issues, "The issues of the room changed.", nil},
+	{roomDetails, "The details of the room changed.", RoomDetails{}},
+	{roomVisibility, "The visibility of the room changed.", ""},
+	{roomExpiring, "The room will be closed soon.", Expiry{}},
+	{roomClosed, "The room was closed, data is the reason.", ""},
+	{disconnected, "An operator disconnected the client, data is the reason.", ""},
+	{maintenance, "A notice of the operators.", Notice{}},
+}
+
+// AsyncAPI returns the AsyncAPI document of ProtocolV1.
+var AsyncAPI = sync.OnceValue(func() map[string]any {
+	generator := NewSchemaGenerator("#/components/schemas/")
+	messages := make(map[string]any)
+	components := make(map[string]any)
+	operations := make(map[string]any)
+	add := func(specs []MessageSpec, suffix, action string) {
+		for _, spec := range specs {
+			name := pascalCase(spec.Type) + suffix
+			properties := map[string]any{
+				"type": map[string]any{"const": spec.Type},
+				"data": generator.Of(spec.Data),
+			}
+			switch {
+			case action == "receive":
+				properties["id"] = map[string]any{"type": "string", "description": "Answered by an ack or error with the same id."}
+			case spec.Type == ack || spec.Type == errorOccurred:
+				properties["id"] = map[string]any{"type": "string", "description": "The id of the command."}
+			}
+			components[name] = map[string]any{
+				"name":    spec.Type,
+				"summary": spec.Summary,
+				"payload": map[string]any{
+					"type":       "object",
+					"properties": properties,
+					"required":   []string{"type"},
+				},
+			}
+			messages[name] = map[string]any{"$ref": "#/components/messages/" + name}
+			operations[action+pascalCase(spec.Type)] = map[string]any{
+				"action":   action,
+				"channel":  map[string]any{"$ref": "#/channels/room"},
+				"messages": []any{map[string]any{"$ref": "#/channels/room/messages/" + name}},
+			}
+		}
+	}
+	add(Commands, "Command", "receive")
+	add(Events, "Event", "send")
+
+	return map[string]any{
+		"asyncapi": "3.0.0",
+		"info": map[string]any{
+			"title":   "Estimation Poker",
+			"version": ProtocolV1,
+			"description": "The websocket protocol of a room. Clients offering the " + ProtocolV1 +
+				" subprotocol receive acks and errors for commands with an id.",
+		},
+		"defaultContentType": "application/json",
+		"channels": map[string]any{
+			"room": map[string]any{
+				"address": "/v1/room/{id}/{role}",
+				"parameters": map[string]any{
+					"id":   map[string]any{"description": "The id, join code or slug of the room."},
+					"role": map[string]any{"enum": []string{Developer, ProductOwner}},
+				},
+				"messages": messages,
+				"bindings": map[string]any{
+					"ws": map[string]any{
+						"method": "GET",
+						"query": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"name":       map[string]any{"type": "string"},
+								"ownerToken": map[string]any{"type": "string"},
+								"invite":     map[string]any{"type": "string"},
+								"password":   map[string]any{"type": "string"},
+							},
+							"required": []string{"name"},
+						},
+						"headers": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"Sec-WebSocket-Protocol": map[string]any{"type": "string", "enum": Subprotocols},
+							},
+						},
+					},
+				},
+			},
+		},
+		"operations": operations,
+		"components": map[string]any{
+			"messages": components,
+			"schemas":  generator.Definitions(),
+		},
+	}
+})
+
+// pascalCase turns message types like room-closed into RoomClosed.
+func pascalCase(kebab string) string {
+	out := make([]byte, 0, len(kebab))
+	upper := true
+	for i := range len(kebab) {
+		switch {
+		case kebab[i] == '-':
+			upper = true
+		case upper && kebab[i] >= 'a' && kebab[i] <= 'z':
+			out = append(out, kebab[i]-'a'+'A')
+			upper = false
+		default:
+			out = append(out, kebab[i])
+			upper = false
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+// TestCommands_matchFabricate keeps the documented commands in line with
+// the messages the server understands.
+func TestCommands_matchFabricate(t *testing.T) {
+	examples := map[string]string{
+		guess:    `"1"`,
+		estimate: `"PROJ-1"`,
+		lockRoom: `{"password":"secret"}`,
+		addIssue: `"Login"`,
+	}
+	for _, spec := range Commands {
+		t.Run(spec.Type, func(t *testing.T) {
+			data, ok := examples[spec.Type]
+			if !ok {
+				data = "null"
+			}
+			_, err := fabricate(context.Background(), &IncomingWebsocketMessage{Type: spec.Type, Data: json.RawMessage(data)}, &Client{})
+
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestAsyncAPI(t *testing.T) {
+	document := AsyncAPI()
+	components := document["components"].(map[string]any)
+	messages := components["messages"].(map[string]any)
+
+	command := messages["GuessCommand"].(map[string]any)
+	assert.Equal(t, command["name"], any(guess))
+	properties := command["payload"].(map[string]any)["properties"].(map[string]any)
+	assert.DeepEqual(t, properties["data"], any(CardId("").JSONSchema()))
+	assert.DeepEqual(t, properties["id"].(map[string]any)["type"], any("string"))
+
+	event := messages["EveryoneDoneEvent"].(map[string]any)
+	properties = event["payload"].(map[string]any)["properties"].(map[string]any)
+	assert.DeepEqual(t, properties["data"], any(map[string]any{"oneOf": []any{
+		map[string]any{"$ref": "#/components/schemas/EveryoneDone"},
+		map[string]any{"type": "null"},
+	}}))
+	_, hasId := properties["id"]
+	assert.False(t, hasId)
+
+	schemas := components["schemas"].(map[string]any)
+	participant := schemas["Participant"].(map[string]any)
+	assert.DeepEqual(t, participant["required"], any([]string{"name", "role"}))
+	_, ok := document["operations"].(map[string]any)["sendRoomClosed"]
+	assert.True(t, ok)
+}
+
+func TestPascalCase(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "guess", want: "Guess"},
+		{in: "room-closed", want: "RoomClosed"},
+		{in: "rotate-owner-token", want: "RotateOwnerToken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, pascalCase(tt.in), tt.want)
+		})
+	}
+}
@@ -1,20 +1,26 @@
 package internal
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/time/rate"
 )
 
 var (
 	ErrUsernameTaken = errors.New("username already taken")
 	ErrRoundStarted  = errors.New("round already started")
 	ErrWrongPassword = errors.New("wrong password")
+	ErrPrivateRoom   = errors.New("room is private")
 )
 
 type Issue struct {
@@ -27,57 +33,111 @@ type Room struct {
 	clientMu sync.RWMutex
 	logger   *slog.Logger
 
-	Id             uuid.UUID
-	inProgress     bool
-	leave          chan *Client
-	join           chan *Client
-	Clients        map[*Client]bool
-	broadcast      chan *OutgoingWebsocketMessage
-	destroy        chan<- uuid.UUID
-	NameOfCreator  string
-	key            uuid.UUID
-	HashedPassword []byte
-	Created        time.Time
-	issues         []*Issue
-	GuessConfig    *GuessConfig
+	Id              uuid.UUID
+	inProgress      bool
+	leave           chan *Client
+	join            chan *Client
+	Clients         map[*Client]bool
+	broadcast       chan *OutgoingWebsocketMessage
+	destroy         chan<- uuid.UUID
+	NameOfCreator   string
+	Code            string
+	Slug            string
+	OwnerSubject    string
+	CreatorKeyId    uuid.UUID
+	AllowedDomain   string
+	TeamId          string
+	Roster          []TeamMember
+	ownerTokens     *TokenSigner
+	ownerGeneration int
+	owner           *Client
+	ownerClaimed    bool
+	HashedPassword  []byte
+	Created         time.Time
+	issues          []*Issue
+	GuessConfig     *GuessConfig
+	details         RoomDetails
+	visibility      string
+	inviteUses      map[uuid.UUID]int
+	lastActivity    time.Time
+	expiryWarned    bool
+	closing         chan string
+	closeReason     string
+	done            chan struct{}
+
+	auditMu    sync.Mutex
+	auditSink  AuditSink
+	auditTrail []AuditEntry
+	auditNext  int
+	auditSeq   int
 }
 
 type ConnectionState struct {
 	CanConnect bool   `json:"canConnect"`
 	Reason     string `json:"reason"`
+	// Role is the role preassigned by an invite.
+	Role string `json:"role,omitempty"`
 }
 
 type State struct {
 	InProgress      bool               `json:"inProgress"`
 	IsLocked        bool               `json:"isLocked"`
 	Issues          []*Issue           `json:"issues"`
+	Deck            string             `json:"deck"`
 	PossibleGuesses []GuessConfigEntry `json:"possibleGuesses"`
+	Visibility      string             `json:"visibility"`
+	AllowedDomain   string             `json:"allowedDomain,omitempty"`
+	TeamId          string             `json:"teamId,omitempty"`
+	Roster          []RosterEntry      `json:"roster,omitempty"`
+	RoomDetails
 }
 
 type Overview struct {
 	Id          uuid.UUID `json:"id"`
+	Code        string    `json:"code,omitempty"`
+	Slug        string    `json:"slug,omitempty"`
 	PlayerCount int       `json:"playerCount"`
 	Created     time.Time `json:"-"`
+	RoomDetails
 }
 
 func (room *Room) State() State {
+	var roster []RosterEntry
+	if len(room.Roster) > 0 {
+		room.clientMu.RLock()
+		roster = room.roster()
+		room.clientMu.RUnlock()
+	}
 	return State{
 		InProgress:      room.inProgress,
 		IsLocked:        room.IsLocked(),
 		Issues:          room.issues,
+		Deck:            room.GuessConfig.Name,
 		PossibleGuesses: room.GuessConfig.Guesses,
+		Visibility:      room.Visibility(),
+		AllowedDomain:   room.AllowedDomain,
+		TeamId:          room.TeamId,
+		Roster:          roster,
+		RoomDetails:     room.Details(),
 	}
 }
 
 func (room *Room) AsOverview() Overview {
+	room.clientMu.RLock()
+	defer room.clientMu.RUnlock()
 	return Overview{
 		Id:          room.Id,
+		Code:        room.Code,
+		Slug:        room.Slug,
 		PlayerCount: len(room.Clients),
 		Created:     room.Created,
+		RoomDetails: room.Details(),
 	}
 }
 
-func NewRoom(id uuid.UUID, destroy chan<- uuid.UUID, nameOfCreator string, logger *slog.Logger, guessConfig *GuessConfig) *Room {
+func NewRoom(id uuid.UUID, destroy chan<- uuid.UUID, nameOfCreator string, logger *slog.Logger, guessConfig *GuessConfig, auditSink AuditSink) *Room {
+	// a random key never fails to generate since go 1.24
+	ownerTokens, _ := NewTokenSigner(nil)
 	return &Room{
 		Id:             id,
 		logger:         logger,
@@ -88,11 +148,17 @@ func NewRoom(id uuid.UUID, destroy chan<- uuid.UUID, nameOfCreator string, logge
 		broadcast:      make(chan *OutgoingWebsocketMessage),
 		destroy:        destroy,
 		NameOfCreator:  nameOfCreator,
-		key:            uuid.New(),
+		ownerTokens:    ownerTokens,
 		HashedPassword: make([]byte, 0),
 		Created:        time.Now(),
+		lastActivity:   time.Now(),
+		closing:        make(chan string, 1),
+		done:           make(chan struct{}),
 		issues:         make([]*Issue, 0),
 		GuessConfig:    guessConfig,
+		visibility:     VisibilityPublic,
+		auditSink:      auditSink,
+		auditTrail:     make([]AuditEntry, 0),
 	}
 }
 
@@ -102,35 +168,85 @@ func (room *Room) IsInProgress() bool {
 	return room.inProgress
 }
 
-func (room *Room) Join(client *Client) {
-	room.join <- client
-	client.send <- newPermissions(client.Name, room.NameOfCreator, room.key)
-	room.broadcast <- newUsers(room.Clients)
+// Join adds client to the room. With a valid ownerToken, or as the creator of
+// a room nobody owns yet, the client becomes the owner of the room.
+// A client joining a closed room only receives the room-closed message.
+func (room *Room) Join(client *Client, ownerToken string) {
+	select {
+	case room.join <- client:
+	case <-room.done:
+		select {
+		case client.send <- newOutgoingWebsocketMessage(roomClosed, room.closeReason):
+		case <-time.After(closeTimeout):
+		}
+		return
+	}
+
+	permissions := newPermissions(false, "")
+	if room.claimOwnership(client, ownerToken) {
+		permissions = newPermissions(true, room.OwnerToken())
+	}
+	select {
+	case client.send <- permissions:
+	case <-room.done:
+		return
+	}
+	room.enqueue(newOutgoingWebsocketMessage(users, nil))
 }
 
-func (room *Room) lock(username, password, key string) bool {
+func (room *Room) lock(client *Client, password string) bool {
+	if !room.isOwner(client) {
+		return false
+	}
 	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		room.logger.Error("failed to hash password")
 		return false
 	}
-	if username == room.NameOfCreator && key == room.key.String() {
-		room.HashedPassword = hashed
-		return true
-	}
-
-	return false
+	room.HashedPassword = hashed
+	return true
 }
 
-func (room *Room) open(username, key string) bool {
-	if username == room.NameOfCreator && key == room.key.String() {
-		room.HashedPassword = make([]byte, 0)
-		return true
+func (room *Room) open(client *Client) bool {
+	if !room.isOwner(client) {
+		return false
 	}
-	return false
+	room.HashedPassword = make([]byte, 0)
+	return true
 }
 
-func (room *Room) ConnectionState(username string, password string) ConnectionState {
+// ConnectionState reports whether username, logged in as user if not nil, may
+// join. A valid invite replaces the password and admits to private rooms, it
+// is not redeemed here.
+func (room *Room) ConnectionState(username string, password string, invite *Invite, user *User) ConnectionState {
+	if err := room.AdmitsUser(user); err != nil {
+		return ConnectionState{
+			CanConnect: false,
+			Reason:     err.Error(),
+		}
+	}
+
+	role := ""
+	if invite != nil {
+		room.mu.RLock()
+		err := room.checkInvite(*invite)
+		room.mu.RUnlock()
+		if err != nil {
+			return ConnectionState{
+				CanConnect: false,
+				Reason:     err.Error(),
+			}
+		}
+		role = invite.Role
+	}
+
+	if invite == nil && !room.Admits(username, user) {
+		return ConnectionState{
+			CanConnect: false,
+			Reason:     ErrPrivateRoom.Error(),
+		}
+	}
+
 	if room.IsInProgress() {
 		return ConnectionState{
 			CanConnect: false,
@@ -138,7 +254,7 @@ func (room *Room) ConnectionState(username string, password string) ConnectionSt
 		}
 	}
 
-	if room.IsLocked() && !room.verify(password) {
+	if invite == nil && room.IsLocked() && !room.verify(password) {
 		return ConnectionState{
 			CanConnect: false,
 			Reason:     ErrWrongPassword.Error(),
@@ -160,6 +276,7 @@ func (room *Room) ConnectionState(username string, password string) ConnectionSt
 	return ConnectionState{
 		CanConnect: true,
 		Reason:     "",
+		Role:       role,
 	}
 }
 
@@ -168,15 +285,23 @@ func (room *Room) verify(password string) bool {
 	return err == nil
 }
 
-func (room *Room) everyDevIsDone() bool {
+// everyDevIsDone reports whether every connected developer guessed or
+// skipped and which developers of the roster are not in the room.
+func (room *Room) everyDevIsDone() (bool, []string) {
 	room.clientMu.Lock()
 	defer room.clientMu.Unlock()
 	for client := range room.Clients {
-		if client.Role == Developer && (client.Guess() == 0 && !client.doSkip) {
-			return false
+		if client.Role == Developer && (client.Guess() == "" && !client.doSkip) {
+			return false, nil
 		}
 	}
-	return true
+	var missing []string
+	for _, entry := range room.roster() {
+		if entry.Role == Developer && !entry.Present {
+			missing = append(missing, entry.Name)
+		}
+	}
+	return true, missing
 }
 
 func (room *Room) newRound() {
@@ -202,6 +327,19 @@ func (room *Room) broadcastToClients(msg *OutgoingWebsocketMessage) {
 	room.clientMu.Unlock()
 }
 
+// SetMessageLimit changes the websocket message rate of every connected client
+// that was rate limited when it joined.
+func (room *Room) SetMessageLimit(limit rate.Limit, burst int) {
+	room.clientMu.RLock()
+	defer room.clientMu.RUnlock()
+	for client := range room.Clients {
+		if client.limiter != nil {
+			client.limiter.SetLimit(limit)
+			client.limiter.SetBurst(burst)
+		}
+	}
+}
+
 func (room *Room) IsLocked() bool {
 	return len(room.HashedPassword) > 0
 }
@@ -213,43 +351,195 @@ func (room *Room) Run() {
 			room.clientMu.Lock()
 			room.Clients[client] = true
 			room.clientMu.Unlock()
+			room.touch()
+			room.record(client, AuditJoin, nil)
 		case client := <-room.leave:
+			room.touch()
 			room.clientMu.Lock()
+			if _, ok := room.Clients[client]; ok {
+				room.record(client, AuditLeave, nil)
+			}
 			delete(room.Clients, client)
-			if len(room.Clients) == 0 {
+			room.releaseOwnership(client)
+			empty := len(room.Clients) == 0
+			room.clientMu.Unlock()
+			// the receiver locks the rooms of the server, whose handlers read
+			// the clients, so the lock must not be held while waiting for it
+			if empty {
 				room.destroy <- room.Id
 			}
-			room.clientMu.Unlock()
+		case reason := <-room.closing:
+			room.shutdown(reason)
+			return
 		case msg := <-room.broadcast:
-			switch msg.Type {
-			case estimate:
-				room.mu.Lock()
-				room.inProgress = true
-				room.broadcastToClients(msg)
-				room.mu.Unlock()
-			case developerAction:
-				if room.everyDevIsDone() {
-					room.broadcastToClients(newOutgoingWebsocketMessage(everyoneDone, nil))
-					continue
-				}
-				room.broadcastToClients(newUsers(room.Clients))
-			case newRound:
-				room.newRound()
-			case leave:
-				if room.IsInProgress() {
-					room.newRound()
-					continue
-				}
-				room.broadcastToClients(msg)
-			case reveal, roomLocked, roomOpened, users:
-				room.broadcastToClients(msg)
-			default:
-				room.logger.Error(fmt.Sprintf("unexpected Message %#v", msg))
+			room.clientMu.RLock()
+			clientCount := len(room.Clients)
+			room.clientMu.RUnlock()
+			ctx := trace.ContextWithSpanContext(context.Background(), msg.queuedBy)
+			_, span := tracer().Start(ctx, "room.deliver", trace.WithAttributes(
+				RoomIdKey.String(room.Id.String()),
+				MessageTypeKey.String(msg.Type),
+				ClientCountKey.Int(clientCount),
+			))
+			room.deliver(msg)
+			span.End()
+		}
+	}
+}
+
+func (room *Room) deliver(msg *OutgoingWebsocketMessage) {
+	switch msg.Type {
+	case estimate:
+		room.mu.Lock()
+		room.inProgress = true
+		room.broadcastToClients(msg)
+		room.mu.Unlock()
+	case developerAction:
+		if done, missing := room.everyDevIsDone(); done {
+			var data any
+			if len(room.Roster) > 0 {
+				data = EveryoneDone{Missing: slices.Concat([]string{}, missing)}
 			}
+			room.broadcastToClients(newOutgoingWebsocketMessage(everyoneDone, data))
+			return
+		}
+		room.broadcastToClients(newUsers(room.Clients))
+	case newRound:
+		room.newRound()
+	case leave:
+		if room.IsInProgress() {
+			room.newRound()
+			return
 		}
+		room.broadcastToClients(msg)
+	case users:
+		// queued without the list, which is only current once this loop
+		// handled the joins and leaves before it
+		room.broadcastToClients(room.users())
+	case reveal, statistics, breakRequested, roomExpiring, roomDetails, roomVisibility, roomLocked, roomOpened, issues, maintenance:
+		room.broadcastToClients(msg)
+	default:
+		room.logger.Error(fmt.Sprintf("unexpected Message %#v", msg))
 	}
 }
 
+// users lists the connected clients. The room loop, which owns the client
+// map, may call newUsers directly instead.
+func (room *Room) users() *OutgoingWebsocketMessage {
+	room.clientMu.RLock()
+	defer room.clientMu.RUnlock()
+	return newUsers(room.Clients)
+}
+
+// touch marks the room as active, which postpones its idle expiry.
+func (room *Room) touch() {
+	room.mu.Lock()
+	room.lastActivity = time.Now()
+	room.expiryWarned = false
+	room.mu.Unlock()
+}
+
+func (room *Room) LastActivity() time.Time {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return room.lastActivity
+}
+
+// WarnExpiry tells the connected clients that the room closes at expiresAt.
+// Clients are warned only once until the room becomes active again.
+func (room *Room) WarnExpiry(expiresAt time.Time, reason string) {
+	room.mu.Lock()
+	if room.expiryWarned {
+		room.mu.Unlock()
+		return
+	}
+	room.expiryWarned = true
+	room.mu.Unlock()
+
+	room.enqueue(newOutgoingWebsocketMessage(roomExpiring, Expiry{Reason: reason, ExpiresAt: expiresAt}))
+}
+
+// Close stops the room. Connected clients receive a room-closed message with
+// reason and are disconnected afterwards. Close does not wait for the room to
+// finish.
+func (room *Room) Close(reason string) {
+	select {
+	case room.closing <- reason:
+	default:
+		// the room is closing already
+	}
+}
+
+func (room *Room) shutdown(reason string) {
+	room.closeReason = reason
+	close(room.done)
+
+	room.clientMu.Lock()
+	defer room.clientMu.Unlock()
+	msg := newOutgoingWebsocketMessage(roomClosed, reason)
+	for client := range room.Clients {
+		select {
+		case client.send <- msg:
+		case <-time.After(closeTimeout):
+			room.logger.Warn("client did not receive room closed message", "room", room.Id, "client", client.Name)
+		}
+	}
+}
+
+// enqueue hands msg to the room loop unless the room is closed already.
+func (room *Room) enqueue(msg *OutgoingWebsocketMessage) {
+	select {
+	case room.broadcast <- msg:
+	case <-room.done:
+	}
+}
+
+// remove unregisters client and tells the remaining clients about it.
+func (room *Room) remove(client *Client) {
+	select {
+	case room.leave <- client:
+	case <-room.done:
+		return
+	}
+	room.enqueue(newOutgoingWebsocketMessage(leave, client.Name))
+	room.enqueue(newOutgoingWebsocketMessage(users, nil))
+}
+
+// Snapshot captures what is worth keeping of a room once it is closed.
+func (room *Room) Snapshot(reason string) RoomSnapshot {
+	room.mu.RLock()
+	issues := slices.Clone(room.issues)
+	room.mu.RUnlock()
+
+	return RoomSnapshot{
+		Id:            room.Id,
+		NameOfCreator: room.NameOfCreator,
+		Deck:          room.GuessConfig.Name,
+		Created:       room.Created,
+		Closed:        time.Now(),
+		Reason:        reason,
+		Issues:        issues,
+		AuditTrail:    room.AuditTrail(),
+	}
+}
+
+// Export is a snapshot of the room while it is still open.
+func (room *Room) Export() RoomSnapshot {
+	snapshot := room.Snapshot("")
+	snapshot.Closed = time.Time{}
+	return snapshot
+}
+
+// ImportIssues appends issues on behalf of actor, who does not need to be
+// connected, and tells the clients to refresh their issue list.
+func (room *Room) ImportIssues(actor string, titles []string) {
+	for _, title := range titles {
+		room.addIssue(title)
+	}
+	room.record(&Client{Name: actor, Role: ProductOwner}, AuditImportIssues, titles)
+	room.enqueue(newOutgoingWebsocketMessage(issues, nil))
+}
+
 func (room *Room) addIssue(issue string) {
 	room.mu.Lock()
 	room.issues = append(room.issues, &Issue{
@@ -258,3 +548,52 @@ func (room *Room) addIssue(issue string) {
 	})
 	room.mu.Unlock()
 }
+
+// record appends an entry to the room's audit trail and forwards it to the
+// configured sink.
+func (room *Room) record(actor *Client, action string, payload any) {
+	entry := AuditEntry{
+		RoomId:  room.Id,
+		Time:    time.Now(),
+		Actor:   actor.Name,
+		Role:    actor.Role,
+		Action:  action,
+		Payload: payload,
+	}
+
+	room.auditMu.Lock()
+	room.auditSeq++
+	entry.Seq = room.auditSeq
+	if len(room.auditTrail) < maxAuditTrail {
+		room.auditTrail = append(room.auditTrail, entry)
+	} else {
+		room.auditTrail[room.auditNext] = entry
+		room.auditNext = (room.auditNext + 1) % maxAuditTrail
+	}
+	room.auditMu.Unlock()
+
+	if room.auditSink == nil {
+		return
+	}
+	if err := room.auditSink.Write(entry); err != nil {
+		room.logger.Error("failed to write audit entry", "room", room.Id, "action", action, "error", err)
+	}
+}
+
+// AuditTrail returns the latest maxAuditTrail entries, oldest first. The
+// configured sink keeps every entry.
+func (room *Room) AuditTrail() []AuditEntry {
+	room.auditMu.Lock()
+	defer room.auditMu.Unlock()
+	return append(slices.Clone(room.auditTrail[room.auditNext:]), room.auditTrail[:room.auditNext]...)
+}
+
+// AuditTrailSince returns the entries kept in memory which were recorded after
+// the one numbered seq, oldest first.
+func (room *Room) AuditTrailSince(seq int) []AuditEntry {
+	trail := room.AuditTrail()
+	start, _ := slices.BinarySearchFunc(trail, seq+1, func(entry AuditEntry, target int) int {
+		return cmp.Compare(entry.Seq, target)
+	})
+	return trail[start:]
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"log/slog"
 	"strings"
 	"testing"
@@ -9,13 +10,14 @@ import (
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/time/rate"
 
 	"github.com/Hydoc/estimation-poker/backend/internal/assert"
 )
 
 func TestNewRoom(t *testing.T) {
 	expectedRoomId := uuid.New()
-	room := NewRoom(expectedRoomId, make(chan<- uuid.UUID), "", slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), new(GuessConfig))
+	room := NewRoom(expectedRoomId, make(chan<- uuid.UUID), "", slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), new(GuessConfig), nil)
 	assert.Equal(t, room.Id, expectedRoomId)
 	assert.False(t, room.inProgress)
 }
@@ -120,7 +122,7 @@ func TestRoom_ConnectionState(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := tt.room().ConnectionState(tt.username, tt.password)
+			got := tt.room().ConnectionState(tt.username, tt.password, nil, nil)
 
 			assert.DeepEqual(t, got, tt.want)
 		})
@@ -129,16 +131,18 @@ func TestRoom_ConnectionState(t *testing.T) {
 
 func TestRoom_everyDevGuessed(t *testing.T) {
 	tests := []struct {
-		name    string
-		want    bool
-		clients map[*Client]bool
+		name        string
+		want        bool
+		wantMissing []string
+		roster      []TeamMember
+		clients     map[*Client]bool
 	}{
 		{
 			name: "everyone guessed",
 			want: true,
 			clients: map[*Client]bool{
 				{
-					guess: 1,
+					guess: "1",
 					Role:  Developer,
 				}: true,
 				{
@@ -151,7 +155,7 @@ func TestRoom_everyDevGuessed(t *testing.T) {
 			want: true,
 			clients: map[*Client]bool{
 				{
-					guess:  0,
+					guess:  "",
 					doSkip: true,
 					Role:   Developer,
 				}: true,
@@ -165,7 +169,7 @@ func TestRoom_everyDevGuessed(t *testing.T) {
 			want: false,
 			clients: map[*Client]bool{
 				{
-					guess:  0,
+					guess:  "",
 					doSkip: false,
 					Role:   Developer,
 				}: true,
@@ -174,21 +178,47 @@ func TestRoom_everyDevGuessed(t *testing.T) {
 				}: true,
 			},
 		},
+		{
+			name: "developers of the roster are missing",
+			want: true,
+			roster: []TeamMember{
+				{Name: "Alice", Role: Developer},
+				{Name: "Bob", Role: Developer},
+				{Name: "Carol", Email: "carol@example.com", Role: Developer},
+				{Name: "Dave", Role: ProductOwner},
+			},
+			wantMissing: []string{"Bob"},
+			clients: map[*Client]bool{
+				{
+					Name:  "alice",
+					guess: "1",
+					Role:  Developer,
+				}: true,
+				{
+					Name:  "Carol on the phone",
+					User:  &User{Subject: "carol", Email: "Carol@example.com"},
+					guess: "1",
+					Role:  Developer,
+				}: true,
+			},
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			room := &Room{
 				Clients: test.clients,
+				Roster:  test.roster,
 			}
-			got := room.everyDevIsDone()
+			got, missing := room.everyDevIsDone()
 			assert.Equal(t, got, test.want)
+			assert.DeepEqual(t, missing, test.wantMissing)
 		})
 	}
 }
 
 func TestRoom_Run_RegisteringAClient(t *testing.T) {
-	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "", slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), new(GuessConfig))
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "", slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)), new(GuessConfig), nil)
 	client := &Client{}
 	go room.Run()
 
@@ -220,6 +250,17 @@ func TestRoom_Run_DeletingAClientAndDestroyingTheRoom(t *testing.T) {
 
 	room.leave <- client
 
+	locked := make(chan struct{})
+	go func() {
+		room.clientMu.RLock()
+		room.clientMu.RUnlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("clients stayed locked while waiting to destroy the room")
+	}
 	gotId := <-destroyChannel
 
 	assert.Equal(t, gotId, roomId)
@@ -266,7 +307,7 @@ func TestRoom_Run_BroadcastDeveloperGuessed_EveryDeveloperGuessed(t *testing.T)
 	client := &Client{
 		send:  clientSendChannel,
 		Role:  Developer,
-		guess: 1,
+		guess: "1",
 	}
 	room := &Room{
 		Id:         uuid.New(),
@@ -287,10 +328,34 @@ func TestRoom_Run_BroadcastDeveloperGuessed_EveryDeveloperGuessed(t *testing.T)
 	assert.DeepEqual(t, gotClientMsg, newOutgoingWebsocketMessage(everyoneDone, nil))
 }
 
+func TestRoom_Run_BroadcastDeveloperGuessed_RosterMissing(t *testing.T) {
+	clientSendChannel := make(chan *OutgoingWebsocketMessage)
+	client := &Client{
+		Name:  "Alice",
+		Role:  Developer,
+		send:  clientSendChannel,
+		guess: "1",
+	}
+	room := &Room{
+		Id: uuid.New(),
+		Clients: map[*Client]bool{
+			client: true,
+		},
+		Roster:    []TeamMember{{Name: "Alice", Role: Developer}, {Name: "Bob", Role: Developer}},
+		broadcast: make(chan *OutgoingWebsocketMessage),
+	}
+	go room.Run()
+	room.broadcast <- newOutgoingWebsocketMessage(developerAction, nil)
+
+	gotClientMsg := <-clientSendChannel
+
+	assert.DeepEqual(t, gotClientMsg, newOutgoingWebsocketMessage(everyoneDone, EveryoneDone{Missing: []string{"Bob"}}))
+}
+
 func TestRoom_Run_BroadcastDeveloperGuessed_NotEveryoneGuessed(t *testing.T) {
 	var logBuffer bytes.Buffer
 	logger := slog.New(slog.NewTextHandler(&logBuffer, nil))
-	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", logger, new(GuessConfig))
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", logger, new(GuessConfig), nil)
 	go room.Run()
 
 	clientSendChannel := make(chan *OutgoingWebsocketMessage)
@@ -303,7 +368,7 @@ func TestRoom_Run_BroadcastDeveloperGuessed_NotEveryoneGuessed(t *testing.T) {
 	room.join <- &Client{
 		Name:  "B",
 		Role:  Developer,
-		guess: 0,
+		guess: "",
 		send:  clientSendChannel,
 	}
 	msg := newOutgoingWebsocketMessage(developerAction, nil)
@@ -325,7 +390,7 @@ func TestRoom_Run_BroadcastNewRound(t *testing.T) {
 		Name:  "reset me",
 		send:  clientSendChannel,
 		Role:  Developer,
-		guess: 2,
+		guess: "2",
 	}
 	room := &Room{
 		Id:         uuid.New(),
@@ -356,7 +421,7 @@ func TestRoom_Run_BroadcastNewRound(t *testing.T) {
 	assert.DeepEqual(t, gotFourthClientMessage, newUsers(room.Clients))
 
 	assert.False(t, room.IsInProgress())
-	assert.Equal(t, developerToReset.Guess(), 0)
+	assert.Equal(t, developerToReset.Guess(), "")
 }
 
 func TestRoom_Run_BroadcastLeaveWhenRoomInProgress(t *testing.T) {
@@ -369,7 +434,7 @@ func TestRoom_Run_BroadcastLeaveWhenRoomInProgress(t *testing.T) {
 	developerToReset := &Client{
 		send:  clientSendChannel,
 		Role:  Developer,
-		guess: 2,
+		guess: "2",
 	}
 	room := &Room{
 		Id:         uuid.New(),
@@ -392,11 +457,11 @@ func TestRoom_Run_BroadcastLeaveWhenRoomInProgress(t *testing.T) {
 
 	assert.DeepEqual(t, gotClientMsg, newOutgoingWebsocketMessage(newRound, nil))
 	assert.False(t, room.inProgress)
-	assert.Equal(t, developerToReset.Guess(), 0)
+	assert.Equal(t, developerToReset.Guess(), "")
 }
 
 func TestRoom_lock(t *testing.T) {
-	key := uuid.New()
+	owner := &Client{Name: "Bla"}
 	room := &Room{
 		Id:             uuid.New(),
 		inProgress:     true,
@@ -406,19 +471,18 @@ func TestRoom_lock(t *testing.T) {
 		broadcast:      make(chan *OutgoingWebsocketMessage),
 		destroy:        nil,
 		NameOfCreator:  "Bla",
-		key:            key,
+		owner:          owner,
 		HashedPassword: make([]byte, 0),
 	}
 
-	got := room.lock("Bla", "top secret", key.String())
+	got := room.lock(owner, "top secret")
 
 	assert.True(t, got)
 	assert.True(t, room.IsLocked())
 	assert.False(t, len(room.HashedPassword) == 0)
 }
 
-func TestRoom_lock_WhenLockingFails(t *testing.T) {
-	key := uuid.New()
+func TestRoom_lock_WhenClientIsNotOwner(t *testing.T) {
 	room := &Room{
 		Id:             uuid.New(),
 		inProgress:     true,
@@ -428,37 +492,41 @@ func TestRoom_lock_WhenLockingFails(t *testing.T) {
 		broadcast:      make(chan *OutgoingWebsocketMessage),
 		destroy:        nil,
 		NameOfCreator:  "Bla",
-		key:            key,
+		owner:          &Client{Name: "Bla"},
 		HashedPassword: make([]byte, 0),
 	}
 
-	got := room.lock("ABC", "top secret", key.String())
+	// another session with the creator's name does not own the room
+	got := room.lock(&Client{Name: "Bla"}, "top secret")
 
 	assert.False(t, got)
 }
 
-func TestRoom_open_WhenUserNotCreator(t *testing.T) {
-	id := uuid.New()
+func TestRoom_open(t *testing.T) {
+	owner := &Client{Name: "some user"}
 	room := &Room{
-		Id:            uuid.New(),
-		inProgress:    false,
-		NameOfCreator: "some user",
-		key:           id,
+		Id:             uuid.New(),
+		inProgress:     false,
+		NameOfCreator:  "some user",
+		owner:          owner,
+		HashedPassword: []byte("hash"),
 	}
-	got := room.open("invalid user", id.String())
 
-	assert.False(t, got)
+	assert.False(t, room.open(&Client{Name: "invalid user"}))
+	assert.True(t, room.IsLocked())
+	assert.True(t, room.open(owner))
+	assert.False(t, room.IsLocked())
 }
 
-func TestRoom_open_WhenKeyIsWrong(t *testing.T) {
+func TestRoom_open_WithoutOwner(t *testing.T) {
 	room := &Room{
-		Id:            uuid.New(),
-		inProgress:    false,
-		NameOfCreator: "some user",
-		key:           uuid.New(),
+		Id:             uuid.New(),
+		inProgress:     false,
+		NameOfCreator:  "some user",
+		HashedPassword: []byte("hash"),
 	}
 
-	got := room.open("some user", "incorrect Key")
+	got := room.open(&Client{Name: "some user"})
 
 	assert.False(t, got)
 }
@@ -467,7 +535,7 @@ func TestRoom_lock_WhenLockingFailsDueToHashingFails(t *testing.T) {
 	var logBuffer bytes.Buffer
 	logger := slog.New(slog.NewTextHandler(&logBuffer, nil))
 
-	key := uuid.New()
+	owner := &Client{Name: "Bla"}
 	room := &Room{
 		Id:             uuid.New(),
 		inProgress:     true,
@@ -477,12 +545,12 @@ func TestRoom_lock_WhenLockingFailsDueToHashingFails(t *testing.T) {
 		broadcast:      make(chan *OutgoingWebsocketMessage),
 		destroy:        nil,
 		NameOfCreator:  "Bla",
-		key:            key,
+		owner:          owner,
 		HashedPassword: make([]byte, 0),
 		logger:         logger,
 	}
 
-	got := room.lock("ABC", strings.Repeat("bla", 90), key.String())
+	got := room.lock(owner, strings.Repeat("bla", 90))
 	wantedLog := "failed to hash password"
 
 	assert.False(t, got)
@@ -551,6 +619,7 @@ func TestRoom_State(t *testing.T) {
 			want: State{
 				InProgress: false,
 				IsLocked:   false,
+				Visibility: VisibilityPublic,
 				Issues:     make([]*Issue, 0),
 				PossibleGuesses: []GuessConfigEntry{
 					{
@@ -610,3 +679,160 @@ func TestRoom_addIssue(t *testing.T) {
 		})
 	}
 }
+
+type recordingAuditSink struct {
+	entries []AuditEntry
+}
+
+func (sink *recordingAuditSink) Write(entry AuditEntry) error {
+	sink.entries = append(sink.entries, entry)
+	return nil
+}
+
+func TestRoom_record(t *testing.T) {
+	sink := &recordingAuditSink{}
+	roomId := uuid.New()
+	room := NewRoom(roomId, make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), sink)
+
+	room.record(&Client{Name: "Tester", Role: ProductOwner}, AuditEstimate, "CS-1")
+	room.record(&Client{Name: "Dev", Role: Developer}, AuditJoin, nil)
+
+	got := room.AuditTrail()
+
+	assert.Equal(t, len(got), 2)
+	assert.Equal(t, got[0].RoomId, roomId)
+	assert.Equal(t, got[0].Actor, "Tester")
+	assert.Equal(t, got[0].Action, AuditEstimate)
+	assert.Equal(t, got[0].Payload, any("CS-1"))
+	assert.Equal(t, got[1].Role, Developer)
+	assert.Equal(t, got[1].Action, AuditJoin)
+	assert.DeepEqual(t, sink.entries, got)
+}
+
+func TestRoom_record_DropsOldestEntries(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+
+	for i := range maxAuditTrail + 5 {
+		room.record(&Client{Name: "Tester", Role: ProductOwner}, AuditAddIssue, i)
+	}
+
+	got := room.AuditTrail()
+
+	assert.Equal(t, len(got), maxAuditTrail)
+	assert.Equal(t, got[0].Payload, any(5))
+	assert.Equal(t, got[maxAuditTrail-1].Payload, any(maxAuditTrail+4))
+}
+
+func TestRoom_AuditTrailSince(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	for i := range maxAuditTrail + 5 {
+		room.record(&Client{Name: "Tester", Role: ProductOwner}, AuditAddIssue, i)
+	}
+
+	tests := []struct {
+		name      string
+		since     int
+		wantFirst int
+		wantLen   int
+	}{
+		{name: "everything kept", since: 0, wantFirst: 6, wantLen: maxAuditTrail},
+		{name: "dropped meanwhile", since: 3, wantFirst: 6, wantLen: maxAuditTrail},
+		{name: "past the cap", since: maxAuditTrail + 2, wantFirst: maxAuditTrail + 3, wantLen: 3},
+		{name: "nothing new", since: maxAuditTrail + 5, wantLen: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := room.AuditTrailSince(tt.since)
+
+			assert.Equal(t, len(got), tt.wantLen)
+			if tt.wantLen > 0 {
+				assert.Equal(t, got[0].Seq, tt.wantFirst)
+				assert.Equal(t, got[len(got)-1].Seq, maxAuditTrail+5)
+			}
+		})
+	}
+}
+
+func TestRoom_SetMessageLimit(t *testing.T) {
+	limited := &Client{limiter: rate.NewLimiter(1, 1)}
+	unlimited := &Client{}
+	room := &Room{
+		Clients: map[*Client]bool{
+			limited:   true,
+			unlimited: true,
+		},
+	}
+
+	room.SetMessageLimit(5, 10)
+
+	assert.Equal(t, limited.limiter.Limit(), rate.Limit(5))
+	assert.Equal(t, limited.limiter.Burst(), 10)
+	assert.True(t, unlimited.limiter == nil)
+}
+
+func TestRoom_Close(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	client := &Client{Name: "Tester", Role: ProductOwner, send: make(chan *OutgoingWebsocketMessage)}
+	stopped := make(chan struct{})
+	go func() {
+		room.Run()
+		close(stopped)
+	}()
+	room.join <- client
+
+	room.Close(ExpiryIdle)
+
+	assert.DeepEqual(t, <-client.send, newOutgoingWebsocketMessage(roomClosed, ExpiryIdle))
+	<-stopped
+	// leaving a closed room must not block
+	room.remove(client)
+	room.Close(ExpiryIdle)
+}
+
+func TestRoom_Join_WhenClosed(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	go room.Run()
+	room.Close(ExpiryMaxAge)
+	<-room.done
+	client := &Client{Name: "Tester", Role: ProductOwner, send: make(chan *OutgoingWebsocketMessage, 1)}
+
+	room.Join(client, "")
+	room.broadcastTraced(context.Background(), newOutgoingWebsocketMessage(roomLocked, nil))
+
+	assert.DeepEqual(t, <-client.send, newOutgoingWebsocketMessage(roomClosed, ExpiryMaxAge))
+	assert.Equal(t, len(room.Clients), 0)
+}
+
+func TestRoom_WarnExpiry(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	client := &Client{Name: "Tester", Role: ProductOwner, send: make(chan *OutgoingWebsocketMessage, 2)}
+	go room.Run()
+	room.join <- client
+	expiresAt := time.Now().Add(time.Minute)
+	want := newOutgoingWebsocketMessage(roomExpiring, Expiry{Reason: ExpiryIdle, ExpiresAt: expiresAt})
+
+	room.WarnExpiry(expiresAt, ExpiryIdle)
+	room.WarnExpiry(expiresAt, ExpiryIdle)
+	assert.DeepEqual(t, <-client.send, want)
+
+	room.touch()
+	room.WarnExpiry(expiresAt, ExpiryIdle)
+	assert.DeepEqual(t, <-client.send, want)
+	assert.Equal(t, len(client.send), 0)
+}
+
+func TestRoom_Snapshot(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), &GuessConfig{Name: "fibonacci"}, nil)
+	room.addIssue("JIRA-1")
+	room.record(&Client{Name: "Tester", Role: ProductOwner}, AuditAddIssue, "JIRA-1")
+
+	got := room.Snapshot(ExpiryMaxAge)
+
+	assert.Equal(t, got.Id, room.Id)
+	assert.Equal(t, got.NameOfCreator, "Tester")
+	assert.Equal(t, got.Deck, "fibonacci")
+	assert.Equal(t, got.Reason, ExpiryMaxAge)
+	assert.DeepEqual(t, got.Issues, []*Issue{{Title: "JIRA-1", Guess: -1}})
+	assert.Equal(t, len(got.AuditTrail), 1)
+}
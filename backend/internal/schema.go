@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// schemaProvider is implemented by types whose JSON does not follow from
+// their Go type, e.g. because it accepts several shapes.
+type schemaProvider interface {
+	JSONSchema() map[string]any
+}
+
+var (
+	schemaProviderType = reflect.TypeFor[schemaProvider]()
+	jsonMarshalerType  = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType  = reflect.TypeFor[encoding.TextMarshaler]()
+	timeType           = reflect.TypeFor[time.Time]()
+)
+
+// SchemaGenerator derives JSON Schemas from Go types the way encoding/json
+// marshals them. Named structs end up in the definitions and are referenced
+// from refPrefix.
+type SchemaGenerator struct {
+	refPrefix   string
+	definitions map[string]any
+	types       map[string]reflect.Type
+}
+
+func NewSchemaGenerator(refPrefix string) *SchemaGenerator {
+	return &SchemaGenerator{
+		refPrefix:   refPrefix,
+		definitions: make(map[string]any),
+		types:       make(map[string]reflect.Type),
+	}
+}
+
+// Definitions returns the schemas of the named structs seen so far.
+func (generator *SchemaGenerator) Definitions() map[string]any {
+	return generator.definitions
+}
+
+// Of returns the schema of the type of value, nil meaning JSON null.
+func (generator *SchemaGenerator) Of(value any) map[string]any {
+	if value == nil {
+		return map[string]any{"type": "null"}
+	}
+	return generator.schema(reflect.TypeOf(value))
+}
+
+func (generator *SchemaGenerator) schema(t reflect.Type) map[string]any {
+	if t.Implements(schemaProviderType) {
+		return reflect.Zero(t).Interface().(schemaProvider).JSONSchema()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() != reflect.Pointer && t.Implements(jsonMarshalerType):
+		return map[string]any{}
+	case t.Kind() != reflect.Pointer && t.Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return map[string]any{"oneOf": []any{generator.schema(t.Elem()), map[string]any{"type": "null"}}}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": generator.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": generator.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return generator.object(t)
+		}
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		switch known, ok := generator.types[name]; {
+		case !ok:
+			// register the type first, the struct may refer to itself
+			generator.types[name] = t
+			generator.definitions[name] = generator.object(t)
+		case known != t:
+			panic(fmt.Sprintf("schema: %s and %s share the name %s", known, t, name))
+		}
+		return map[string]any{"$ref": generator.refPrefix + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+// object describes the fields of struct t, including those of embedded
+// structs. encoding/json never adds other fields.
+func (generator *SchemaGenerator) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	generator.fields(t, properties, &required)
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func (generator *SchemaGenerator) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			generator.fields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = generator.schema(field.Type)
+		if !strings.Contains(options, "omitempty") && !strings.Contains(options, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+type schemaTitle struct {
+	Title string `json:"title"`
+}
+
+type schemaNode struct {
+	Name     string        `json:"name"`
+	Parent   *schemaNode   `json:"parent"`
+	Children []*schemaNode `json:"children,omitempty"`
+	Created  time.Time     `json:"created,omitzero"`
+	hidden   bool
+	Skipped  string `json:"-"`
+	schemaTitle
+}
+
+func TestSchemaGenerator(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  map[string]any
+	}{
+		{name: "null", value: nil, want: map[string]any{"type": "null"}},
+		{name: "string", value: "", want: map[string]any{"type": "string"}},
+		{name: "integer", value: 0, want: map[string]any{"type": "integer"}},
+		{name: "number", value: 0.5, want: map[string]any{"type": "number"}},
+		{name: "boolean", value: false, want: map[string]any{"type": "boolean"}},
+		{name: "time", value: time.Time{}, want: map[string]any{"type": "string", "format": "date-time"}},
+		{name: "text marshaler", value: uuid.UUID{}, want: map[string]any{"type": "string"}},
+		{name: "schema provider", value: CardId(""), want: CardId("").JSONSchema()},
+		{name: "array", value: []string{}, want: map[string]any{"type": "array", "items": map[string]any{"type": "string"}}},
+		{name: "map", value: map[string]int{}, want: map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "integer"}}},
+		{
+			name:  "pointer",
+			value: new(int),
+			want:  map[string]any{"oneOf": []any{map[string]any{"type": "integer"}, map[string]any{"type": "null"}}},
+		},
+		{
+			name:  "anonymous struct",
+			value: struct{ Id string }{},
+			want: map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"Id": map[string]any{"type": "string"}},
+				"required":             []string{"Id"},
+				"additionalProperties": false,
+			},
+		},
+		{name: "named struct", value: schemaNode{}, want: map[string]any{"$ref": "#/defs/SchemaNode"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewSchemaGenerator("#/defs/").Of(tt.value)
+
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestSchemaGenerator_definitions(t *testing.T) {
+	generator := NewSchemaGenerator("#/defs/")
+
+	generator.Of(schemaNode{})
+
+	ref := map[string]any{"$ref": "#/defs/SchemaNode"}
+	assert.DeepEqual(t, generator.Definitions(), map[string]any{
+		"SchemaNode": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name":     map[string]any{"type": "string"},
+				"parent":   map[string]any{"oneOf": []any{ref, map[string]any{"type": "null"}}},
+				"children": map[string]any{"type": "array", "items": map[string]any{"oneOf": []any{ref, map[string]any{"type": "null"}}}},
+				"created":  map[string]any{"type": "string", "format": "date-time"},
+				"title":    map[string]any{"type": "string"},
+			},
+			"required":             []string{"name", "parent", "title"},
+			"additionalProperties": false,
+		},
+	})
+}
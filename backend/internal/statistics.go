@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"slices"
+)
+
+// Statistics summarises the revealed guesses of a round. Only numeric cards
+// are taken into account, special cards are counted as ignored. The values are
+// nil if nobody picked a numeric card.
+type Statistics struct {
+	Votes   int      `json:"votes"`
+	Ignored int      `json:"ignored"`
+	Skipped int      `json:"skipped"`
+	Average *float64 `json:"average"`
+	Median  *float64 `json:"median"`
+	Min     *float64 `json:"min"`
+	Max     *float64 `json:"max"`
+}
+
+func newStatistics(clients map[*Client]bool, guessConfig *GuessConfig) Statistics {
+	var (
+		out    Statistics
+		values []float64
+	)
+	for client := range clients {
+		if client.Role != Developer {
+			continue
+		}
+
+		card := guessConfig.Card(client.Guess())
+		switch {
+		case card == nil:
+			out.Skipped++
+		case card.Value == nil:
+			out.Ignored++
+		default:
+			values = append(values, *card.Value)
+		}
+	}
+
+	out.Votes = len(values)
+	if len(values) == 0 {
+		return out
+	}
+
+	slices.Sort(values)
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	average := sum / float64(len(values))
+	median := values[len(values)/2]
+	if len(values)%2 == 0 {
+		median = (values[len(values)/2-1] + values[len(values)/2]) / 2
+	}
+
+	out.Average = &average
+	out.Median = &median
+	out.Min = &values[0]
+	out.Max = &values[len(values)-1]
+	return out
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestNewStatistics(t *testing.T) {
+	guessConfig, _ := NewGuessConfig("0.5,1,2,3,?,coffee", "A,B,C,D,E,F")
+	float := func(value float64) *float64 {
+		return &value
+	}
+	tests := []struct {
+		name    string
+		clients map[*Client]bool
+		want    Statistics
+	}{
+		{
+			name: "ignores special cards, skips and product owners",
+			clients: map[*Client]bool{
+				{Role: Developer, guess: "0.5"}:    true,
+				{Role: Developer, guess: "3"}:      true,
+				{Role: Developer, guess: "?"}:      true,
+				{Role: Developer, guess: "coffee"}: true,
+				{Role: Developer, doSkip: true}:    true,
+				{Role: ProductOwner}:               true,
+			},
+			want: Statistics{
+				Votes:   2,
+				Ignored: 2,
+				Skipped: 1,
+				Average: float(1.75),
+				Median:  float(1.75),
+				Min:     float(0.5),
+				Max:     float(3),
+			},
+		},
+		{
+			name: "odd number of votes",
+			clients: map[*Client]bool{
+				{Role: Developer, guess: "1"}: true,
+				{Role: Developer, guess: "2"}: true,
+				{Role: Developer, guess: "3"}: true,
+			},
+			want: Statistics{
+				Votes:   3,
+				Average: float(2),
+				Median:  float(2),
+				Min:     float(1),
+				Max:     float(3),
+			},
+		},
+		{
+			name: "no numeric votes",
+			clients: map[*Client]bool{
+				{Role: Developer, guess: "?"}: true,
+			},
+			want: Statistics{
+				Ignored: 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newStatistics(tt.clients, guessConfig)
+
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const maxTeamMembers = 100
+
+var (
+	ErrTeamNotFound = errors.New("team not found")
+	ErrTeamExists   = errors.New("a team with this id already exists")
+)
+
+// TeamMember is an expected participant of the rooms of a team. Members are
+// recognised by their verified email if they log in, by name otherwise.
+type TeamMember struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Role  string `json:"role"`
+}
+
+// TeamSettings are applied to every room created for the team unless the
+// creator overrides them.
+type TeamSettings struct {
+	Visibility    string   `json:"visibility,omitempty"`
+	AllowedDomain string   `json:"allowedDomain,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+type Team struct {
+	Id       string       `json:"id"`
+	Name     string       `json:"name"`
+	Deck     string       `json:"deck,omitempty"`
+	Members  []TeamMember `json:"members"`
+	Settings TeamSettings `json:"settings"`
+	Created  time.Time    `json:"created"`
+	Updated  time.Time    `json:"updated"`
+}
+
+// Normalize trims the texts of the team, lower cases emails and tags and
+// defaults the role of members to developer.
+func (team Team) Normalize() Team {
+	team.Name = strings.TrimSpace(team.Name)
+	team.Deck = strings.TrimSpace(team.Deck)
+	members := make([]TeamMember, 0, len(team.Members))
+	for _, member := range team.Members {
+		member.Name = strings.TrimSpace(member.Name)
+		member.Email = strings.ToLower(strings.TrimSpace(member.Email))
+		member.Role = cmp.Or(member.Role, Developer)
+		members = append(members, member)
+	}
+	team.Members = members
+	team.Settings.AllowedDomain = strings.ToLower(strings.TrimSpace(team.Settings.AllowedDomain))
+	team.Settings.Tags = RoomDetails{Tags: team.Settings.Tags}.Normalize().Tags
+	return team
+}
+
+func (team Team) Validate() error {
+	var errs []error
+	if team.Name == "" {
+		errs = append(errs, errors.New("team name must be provided"))
+	}
+	if utf8.RuneCountInString(team.Name) > maxTeamLength {
+		errs = append(errs, fmt.Errorf("team name must not be longer than %d characters", maxTeamLength))
+	}
+	if len(team.Members) > maxTeamMembers {
+		errs = append(errs, fmt.Errorf("a team must not have more than %d members", maxTeamMembers))
+	}
+	var names []string
+	for _, member := range team.Members {
+		switch {
+		case member.Name == "":
+			errs = append(errs, errors.New("every member needs a name"))
+		case slices.Contains(names, strings.ToLower(member.Name)):
+			errs = append(errs, fmt.Errorf("member %q is listed twice", member.Name))
+		}
+		names = append(names, strings.ToLower(member.Name))
+		if member.Role != Developer && member.Role != ProductOwner {
+			errs = append(errs, fmt.Errorf("role of member %q must be one of %s, %s, got %q", member.Name, Developer, ProductOwner, member.Role))
+		}
+		if member.Email != "" && !strings.Contains(member.Email, "@") {
+			errs = append(errs, fmt.Errorf("email of member %q is invalid", member.Name))
+		}
+	}
+	if team.Settings.Visibility != "" {
+		if err := ValidateVisibility(team.Settings.Visibility); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := (RoomDetails{Tags: team.Settings.Tags}).Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// matches reports whether client is the member, preferring the verified
+// email of logged in clients over their name.
+func (member TeamMember) matches(client *Client) bool {
+	if member.Email != "" && client.User != nil && client.User.Email != "" {
+		return strings.EqualFold(member.Email, client.User.Email)
+	}
+	return strings.EqualFold(member.Name, client.Name)
+}
+
+// TeamStore keeps teams across restarts. Implementations must be safe for
+// concurrent use.
+type TeamStore interface {
+	Teams() ([]Team, error)
+	Team(id string) (Team, error)
+	// CreateTeam fails with ErrTeamExists if the id is taken.
+	CreateTeam(team Team) error
+	// UpdateTeam fails with ErrTeamNotFound if there is nothing to replace.
+	UpdateTeam(team Team) error
+	DeleteTeam(id string) error
+}
+
+// MemoryTeamStore forgets its teams when the server stops.
+type MemoryTeamStore struct {
+	mu    sync.RWMutex
+	teams map[string]Team
+}
+
+func NewMemoryTeamStore() *MemoryTeamStore {
+	return &MemoryTeamStore{teams: make(map[string]Team)}
+}
+
+func (store *MemoryTeamStore) Teams() ([]Team, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	teams := make([]Team, 0, len(store.teams))
+	for _, team := range store.teams {
+		teams = append(teams, team)
+	}
+	slices.SortFunc(teams, func(a, b Team) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return teams, nil
+}
+
+func (store *MemoryTeamStore) Team(id string) (Team, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	team, ok := store.teams[id]
+	if !ok {
+		return Team{}, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+func (store *MemoryTeamStore) CreateTeam(team Team) error {
+	return store.change(func(teams map[string]Team) error {
+		if _, ok := teams[team.Id]; ok {
+			return ErrTeamExists
+		}
+		teams[team.Id] = team
+		return nil
+	})
+}
+
+func (store *MemoryTeamStore) UpdateTeam(team Team) error {
+	return store.change(func(teams map[string]Team) error {
+		if _, ok := teams[team.Id]; !ok {
+			return ErrTeamNotFound
+		}
+		teams[team.Id] = team
+		return nil
+	})
+}
+
+func (store *MemoryTeamStore) DeleteTeam(id string) error {
+	return store.change(func(teams map[string]Team) error {
+		if _, ok := teams[id]; !ok {
+			return ErrTeamNotFound
+		}
+		delete(teams, id)
+		return nil
+	})
+}
+
+func (store *MemoryTeamStore) change(apply func(teams map[string]Team) error) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return apply(store.teams)
+}
+
+// FileTeamStore keeps every team in a single JSON file which is rewritten on
+// every change.
+type FileTeamStore struct {
+	MemoryTeamStore
+	saveMu sync.Mutex
+	path   string
+}
+
+// OpenFileTeamStore loads the teams saved at path. A missing file is created
+// with the first team.
+func OpenFileTeamStore(path string) (*FileTeamStore, error) {
+	store := &FileTeamStore{MemoryTeamStore: MemoryTeamStore{teams: make(map[string]Team)}, path: path}
+	var teams []Team
+	if err := readJSONFile(path, &teams); err != nil {
+		return nil, fmt.Errorf("can not read teams from %s: %w", path, err)
+	}
+	for _, team := range teams {
+		store.teams[team.Id] = team
+	}
+	return store, nil
+}
+
+func (store *FileTeamStore) CreateTeam(team Team) error {
+	return store.changeAndSave(func() error {
+		return store.MemoryTeamStore.CreateTeam(team)
+	})
+}
+
+func (store *FileTeamStore) UpdateTeam(team Team) error {
+	return store.changeAndSave(func() error {
+		return store.MemoryTeamStore.UpdateTeam(team)
+	})
+}
+
+func (store *FileTeamStore) DeleteTeam(id string) error {
+	return store.changeAndSave(func() error {
+		return store.MemoryTeamStore.DeleteTeam(id)
+	})
+}
+
+// Check reports whether changed teams can be saved.
+func (store *FileTeamStore) Check(ctx context.Context) error {
+	return checkWritable(ctx, store.path)
+}
+
+func (store *FileTeamStore) changeAndSave(change func() error) error {
+	store.saveMu.Lock()
+	defer store.saveMu.Unlock()
+	if err := change(); err != nil {
+		return err
+	}
+	teams, _ := store.Teams()
+	return writeJSONFile(store.path, teams)
+}
+
+// RosterEntry tells whether an expected participant is in the room.
+type RosterEntry struct {
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Present bool   `json:"present"`
+}
+
+// EveryoneDone is sent once every connected developer guessed or skipped. It
+// names the developers of the team who never showed up.
+type EveryoneDone struct {
+	Missing []string `json:"missing"`
+}
+
+// AssignedRole returns the role the roster assigns to the name or account
+// of a joining client.
+func (room *Room) AssignedRole(name string, user *User) (string, bool) {
+	client := &Client{Name: name, User: user}
+	for _, member := range room.Roster {
+		if member.matches(client) {
+			return member.Role, true
+		}
+	}
+	return "", false
+}
+
+// roster lists the expected participants of the room. The caller must hold
+// clientMu.
+func (room *Room) roster() []RosterEntry {
+	entries := make([]RosterEntry, 0, len(room.Roster))
+	for _, member := range room.Roster {
+		entry := RosterEntry{Name: member.Name, Role: member.Role}
+		for client := range room.Clients {
+			if member.matches(client) {
+				entry.Present = true
+				break
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
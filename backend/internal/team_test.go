@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestTeam_NormalizeAndValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		team    Team
+		want    Team
+		wantErr string
+	}{
+		{
+			name: "defaults member roles and cleans texts",
+			team: Team{
+				Name:     "  Platform ",
+				Members:  []TeamMember{{Name: " Alice ", Email: " Alice@Example.com"}, {Name: "Bob", Role: ProductOwner}},
+				Settings: TeamSettings{AllowedDomain: "Example.com", Tags: []string{"Backend", "backend"}},
+			},
+			want: Team{
+				Name:     "Platform",
+				Members:  []TeamMember{{Name: "Alice", Email: "alice@example.com", Role: Developer}, {Name: "Bob", Role: ProductOwner}},
+				Settings: TeamSettings{AllowedDomain: "example.com", Tags: []string{"backend"}},
+			},
+		},
+		{
+			name:    "reports every problem",
+			team:    Team{Members: []TeamMember{{Name: ""}, {Name: "Alice", Email: "alice"}, {Name: "alice", Role: "tester"}}, Settings: TeamSettings{Visibility: "secret"}},
+			wantErr: "team name must be provided\nevery member needs a name\nemail of member \"Alice\" is invalid\nmember \"alice\" is listed twice\nrole of member \"alice\" must be one of developer, product-owner, got \"tester\"\nvisibility must be one of public, unlisted, private, got \"secret\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.team.Normalize()
+			err := got.Validate()
+
+			if tt.wantErr != "" {
+				assert.Equal(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestFileTeamStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "teams.json")
+	store, err := OpenFileTeamStore(path)
+	assert.NilError(t, err)
+	team := Team{Id: "platform", Name: "Platform", Members: []TeamMember{{Name: "Alice", Role: Developer}}, Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	assert.NilError(t, store.CreateTeam(team))
+	assert.True(t, errors.Is(store.CreateTeam(team), ErrTeamExists))
+	assert.NilError(t, store.CreateTeam(Team{Id: "mobile", Name: "Mobile"}))
+	assert.NilError(t, store.DeleteTeam("mobile"))
+	assert.True(t, errors.Is(store.DeleteTeam("mobile"), ErrTeamNotFound))
+	assert.True(t, errors.Is(store.UpdateTeam(Team{Id: "mobile"}), ErrTeamNotFound))
+
+	reopened, err := OpenFileTeamStore(path)
+	assert.NilError(t, err)
+	got, err := reopened.Team("platform")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, team)
+	teams, _ := reopened.Teams()
+	assert.Equal(t, len(teams), 1)
+	_, err = reopened.Team("mobile")
+	assert.True(t, errors.Is(err, ErrTeamNotFound))
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Equal(t, len(entries), 1)
+}
+
+func TestOpenFileTeamStore_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "teams.json")
+	os.WriteFile(path, []byte("{"), 0o600)
+
+	_, err := OpenFileTeamStore(path)
+
+	assert.StringContains(t, err.Error(), "can not read teams from")
+}
+
+func TestRoom_Roster(t *testing.T) {
+	room := &Room{
+		Roster: []TeamMember{
+			{Name: "Alice", Role: Developer},
+			{Name: "Bob", Email: "bob@example.com", Role: ProductOwner},
+		},
+		Clients: map[*Client]bool{
+			{Name: "ALICE"}: true,
+			{Name: "Bob"}:   true,
+		},
+	}
+
+	role, ok := room.AssignedRole("alice", nil)
+	assert.True(t, ok)
+	assert.Equal(t, role, Developer)
+	role, ok = room.AssignedRole("Robert", &User{Subject: "bob", Email: "bob@example.com"})
+	assert.True(t, ok)
+	assert.Equal(t, role, ProductOwner)
+	_, ok = room.AssignedRole("Bob", &User{Subject: "other", Email: "bob@other.com"})
+	assert.False(t, ok)
+
+	assert.DeepEqual(t, room.roster(), []RosterEntry{
+		{Name: "Alice", Role: Developer, Present: true},
+		{Name: "Bob", Role: ProductOwner, Present: true},
+	})
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidToken = errors.New("token is invalid")
+
+// TokenSigner turns claims into tokens which can be handed to clients and
+// checks that tokens were issued by this server. A token is the base64url
+// encoded JSON claims and their HMAC-SHA256, separated by a dot.
+type TokenSigner struct {
+	key []byte
+}
+
+// NewTokenSigner signs with key. Without a key a random one is generated,
+// which invalidates all tokens on restart.
+func NewTokenSigner(key []byte) (*TokenSigner, error) {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("can not generate signing key: %w", err)
+		}
+	}
+	return &TokenSigner{key: key}, nil
+}
+
+// ForPurpose returns a signer with a key derived from the key of signer and
+// purpose, so a token issued for one purpose is never accepted for another.
+func (signer *TokenSigner) ForPurpose(purpose string) *TokenSigner {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write([]byte(purpose))
+	return &TokenSigner{key: mac.Sum(nil)}
+}
+
+func (signer *TokenSigner) Sign(claims any) string {
+	payload, _ := json.Marshal(claims)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signer.mac(encoded))
+}
+
+// Verify decodes the claims of token into destination if the token carries a
+// valid signature.
+func (signer *TokenSigner) Verify(token string, destination any) error {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, signer.mac(encoded)) {
+		return ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if err := json.Unmarshal(payload, destination); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func (signer *TokenSigner) mac(encoded string) []byte {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
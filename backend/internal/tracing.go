@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RoomIdKey      = attribute.Key("room.id")
+	ClientRoleKey  = attribute.Key("client.role")
+	MessageTypeKey = attribute.Key("message.type")
+	ClientCountKey = attribute.Key("room.client_count")
+)
+
+// tracer is resolved through the global provider on every use, so spans are
+// exported as soon as the server has configured OpenTelemetry and dropped
+// otherwise.
+func tracer() trace.Tracer {
+	return otel.Tracer("github.com/Hydoc/estimation-poker/backend/internal")
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// broadcastTraced hands msg over to the room loop and measures how long the
+// sender had to wait for it, which grows with the amount of clients in a room.
+func (room *Room) broadcastTraced(ctx context.Context, msg *OutgoingWebsocketMessage) {
+	ctx, span := tracer().Start(ctx, "room.broadcast", trace.WithAttributes(
+		RoomIdKey.String(room.Id.String()),
+		MessageTypeKey.String(msg.Type),
+	))
+	msg.queuedBy = trace.SpanContextFromContext(ctx)
+	room.enqueue(msg)
+	span.End()
+}
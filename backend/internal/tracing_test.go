@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/Hydoc/go-message"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+// recordSpans installs a tracer provider recording every span until the test
+// ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+	return recorder
+}
+
+func TestClient_handleIncoming_Tracing(t *testing.T) {
+	recorder := recordSpans(t)
+
+	broadcastChannel := make(chan *OutgoingWebsocketMessage)
+	room := &Room{
+		Id:        uuid.MustParse("cdf64eb1-48d5-4ef5-b0c5-887b893c85dd"),
+		broadcast: broadcastChannel,
+		Clients:   make(map[*Client]bool),
+	}
+	bus := message.NewBus()
+	bus.Register(estimate, handleEstimate)
+	client := &Client{
+		Name:   "Test",
+		Role:   ProductOwner,
+		room:   room,
+		bus:    bus,
+		logger: slog.New(slog.DiscardHandler),
+	}
+
+	go func() {
+		<-broadcastChannel
+	}()
+	client.handleIncoming(&IncomingWebsocketMessage{Type: estimate, Data: []byte(`"CS-1"`)})
+
+	names := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		names[span.Name()] = span
+	}
+
+	root, ok := names["websocket estimate"]
+	assert.True(t, ok)
+	for _, name := range []string{"fabricate", "bus.dispatch", "room.broadcast"} {
+		span, ok := names[name]
+		assert.True(t, ok)
+		assert.Equal(t, span.Parent().SpanID(), root.SpanContext().SpanID())
+	}
+	assert.DeepEqual(t, root.Attributes(), []attribute.KeyValue{
+		RoomIdKey.String("cdf64eb1-48d5-4ef5-b0c5-887b893c85dd"),
+		ClientRoleKey.String(ProductOwner),
+		MessageTypeKey.String(estimate),
+	})
+}
+
+func TestRoom_Run_TracesDeliveryAsChildOfBroadcast(t *testing.T) {
+	recorder := recordSpans(t)
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	go room.Run()
+
+	room.broadcastTraced(context.Background(), newOutgoingWebsocketMessage(roomLocked, nil))
+	room.Close("test")
+	<-room.done
+
+	names := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		names[span.Name()] = span
+	}
+	broadcast, ok := names["room.broadcast"]
+	assert.True(t, ok)
+	deliver, ok := names["room.deliver"]
+	assert.True(t, ok)
+	assert.Equal(t, deliver.Parent().SpanID(), broadcast.SpanContext().SpanID())
+	assert.Equal(t, deliver.SpanContext().TraceID(), broadcast.SpanContext().TraceID())
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrLoginRequired = errors.New("room requires login")
+	ErrEmailDomain   = errors.New("room is restricted to another email domain")
+)
+
+// User is an account verified by the identity provider. Email is only set if
+// the provider verified it.
+type User struct {
+	Subject string `json:"sub"`
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	Picture string `json:"picture,omitempty"`
+}
+
+// EmailDomain returns the lower cased domain of the verified email address.
+func (user *User) EmailDomain() string {
+	if user == nil {
+		return ""
+	}
+	_, domain, ok := strings.Cut(user.Email, "@")
+	if !ok {
+		return ""
+	}
+	return strings.ToLower(domain)
+}
+
+// AdmitsUser reports why user may not join a room restricted to an email
+// domain, or nil if it may.
+func (room *Room) AdmitsUser(user *User) error {
+	switch {
+	case room.AllowedDomain == "":
+		return nil
+	case user == nil:
+		return ErrLoginRequired
+	case user.EmailDomain() != room.AllowedDomain:
+		return fmt.Errorf("%w %s", ErrEmailDomain, room.AllowedDomain)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestUser_EmailDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		user *User
+		want string
+	}{
+		{name: "guest", user: nil, want: ""},
+		{name: "unverified email", user: &User{Subject: "1"}, want: ""},
+		{name: "lower cased", user: &User{Subject: "1", Email: "Alice@Example.COM"}, want: "example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.user.EmailDomain(), tt.want)
+		})
+	}
+}
+
+func TestRoom_AdmitsUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		allowedDomain string
+		user          *User
+		wantErr       error
+	}{
+		{name: "unrestricted guest", allowedDomain: "", user: nil},
+		{name: "restricted guest", allowedDomain: "example.com", user: nil, wantErr: ErrLoginRequired},
+		{name: "other domain", allowedDomain: "example.com", user: &User{Subject: "1", Email: "a@other.com"}, wantErr: ErrEmailDomain},
+		{name: "subdomain", allowedDomain: "example.com", user: &User{Subject: "1", Email: "a@dev.example.com"}, wantErr: ErrEmailDomain},
+		{name: "allowed domain", allowedDomain: "example.com", user: &User{Subject: "1", Email: "a@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+			room.AllowedDomain = tt.allowedDomain
+
+			err := room.AdmitsUser(tt.user)
+
+			assert.True(t, errors.Is(err, tt.wantErr))
+		})
+	}
+}
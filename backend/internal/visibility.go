@@ -0,0 +1,64 @@
+package internal
+
+import "fmt"
+
+// Visibility decides who finds and who may join a room. It is independent of
+// the password lock, a locked room stays locked whatever its visibility.
+const (
+	// VisibilityPublic rooms are listed in the lobby.
+	VisibilityPublic = "public"
+	// VisibilityUnlisted rooms are not listed but anyone with the link may join.
+	VisibilityUnlisted = "unlisted"
+	// VisibilityPrivate rooms are not listed and can only be joined by invitation.
+	VisibilityPrivate = "private"
+)
+
+func ValidateVisibility(visibility string) error {
+	switch visibility {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		return nil
+	}
+	return fmt.Errorf("visibility must be one of %s, %s, %s, got %q", VisibilityPublic, VisibilityUnlisted, VisibilityPrivate, visibility)
+}
+
+func (room *Room) Visibility() string {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if room.visibility == "" {
+		return VisibilityPublic
+	}
+	return room.visibility
+}
+
+// SetVisibility changes who finds and who may join the room and tells the
+// connected clients about it. Clients already in the room stay.
+func (room *Room) SetVisibility(visibility string) {
+	room.mu.Lock()
+	room.visibility = visibility
+	room.mu.Unlock()
+
+	room.record(&Client{Name: room.NameOfCreator, Role: ProductOwner}, AuditVisibility, visibility)
+	room.enqueue(newOutgoingWebsocketMessage(roomVisibility, visibility))
+}
+
+// IsListed reports whether the room shows up in the lobby. Locked rooms are
+// never listed, since nobody could join them from there.
+func (room *Room) IsListed() bool {
+	return room.Visibility() == VisibilityPublic && !room.IsLocked()
+}
+
+// Admits reports whether username, logged in as user if not nil, may join
+// the room without an invitation or owner token. Private rooms only admit
+// the account that created them or, for rooms created without an account,
+// the creator claiming the room before anybody owned it.
+func (room *Room) Admits(username string, user *User) bool {
+	if room.Visibility() != VisibilityPrivate {
+		return true
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if room.OwnerSubject != "" {
+		return user != nil && user.Subject == room.OwnerSubject
+	}
+	return !room.ownerClaimed && username == room.NameOfCreator
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestValidateVisibility(t *testing.T) {
+	for _, visibility := range []string{VisibilityPublic, VisibilityUnlisted, VisibilityPrivate} {
+		assert.NilError(t, ValidateVisibility(visibility))
+	}
+
+	err := ValidateVisibility("")
+
+	assert.Equal(t, err.Error(), `visibility must be one of public, unlisted, private, got ""`)
+}
+
+func TestRoom_IsListed(t *testing.T) {
+	tests := []struct {
+		name           string
+		visibility     string
+		hashedPassword []byte
+		want           bool
+	}{
+		{name: "zero value is public", want: true},
+		{name: "public", visibility: VisibilityPublic, want: true},
+		{name: "public but locked", visibility: VisibilityPublic, hashedPassword: []byte("hash"), want: false},
+		{name: "unlisted", visibility: VisibilityUnlisted, want: false},
+		{name: "private", visibility: VisibilityPrivate, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := &Room{visibility: tt.visibility, HashedPassword: tt.hashedPassword}
+
+			assert.Equal(t, room.IsListed(), tt.want)
+		})
+	}
+}
+
+func TestRoom_SetVisibility(t *testing.T) {
+	room := NewRoom(uuid.New(), make(chan<- uuid.UUID), "Tester", slog.New(slog.DiscardHandler), new(GuessConfig), nil)
+	client := &Client{Name: "Dev", Role: Developer, send: make(chan *OutgoingWebsocketMessage, 1)}
+	go room.Run()
+	room.join <- client
+
+	room.SetVisibility(VisibilityPrivate)
+
+	assert.DeepEqual(t, <-client.send, newOutgoingWebsocketMessage(roomVisibility, VisibilityPrivate))
+	assert.Equal(t, room.Visibility(), VisibilityPrivate)
+	assert.False(t, room.Admits("Dev", nil))
+	assert.True(t, room.Admits("Tester", nil))
+	trail := room.AuditTrail()
+	assert.Equal(t, trail[len(trail)-1].Action, AuditVisibility)
+}
+
+func TestRoom_Admits(t *testing.T) {
+	tests := []struct {
+		name         string
+		visibility   string
+		ownerSubject string
+		ownerClaimed bool
+		username     string
+		user         *User
+		want         bool
+	}{
+		{name: "public", visibility: VisibilityPublic, username: "Dev", want: true},
+		{name: "unlisted", visibility: VisibilityUnlisted, username: "Dev", want: true},
+		{name: "private for somebody else", visibility: VisibilityPrivate, username: "Dev", want: false},
+		{name: "private for the creator", visibility: VisibilityPrivate, username: "Tester", want: true},
+		{name: "private for the creator's name once owned", visibility: VisibilityPrivate, ownerClaimed: true, username: "Tester", want: false},
+		{name: "private for the creating account", visibility: VisibilityPrivate, ownerSubject: "tester", username: "Tester on the phone", user: &User{Subject: "tester"}, want: true},
+		{name: "private for the creator's name without account", visibility: VisibilityPrivate, ownerSubject: "tester", username: "Tester", want: false},
+		{name: "private for another account", visibility: VisibilityPrivate, ownerSubject: "tester", username: "Tester", user: &User{Subject: "mallory"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := &Room{NameOfCreator: "Tester", visibility: tt.visibility, OwnerSubject: tt.ownerSubject, ownerClaimed: tt.ownerClaimed}
+
+			assert.Equal(t, room.Admits(tt.username, tt.user), tt.want)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Package client talks to an estimation poker server. Client covers the REST
+// api, Session the websocket protocol of a room.
+//
+//	api := client.New("https://poker.example.com")
+//	room, err := api.CreateRoom(ctx, client.CreateRoomRequest{Creator: "Alice"})
+//	...
+//	session, err := client.Dial(ctx, api.BaseURL, client.DialOptions{
+//		Room: room.Code,
+//		Name: "Alice",
+//		Role: client.RoleProductOwner,
+//	}, client.Handlers{
+//		Reveal: func(guesses []client.Guess) { ... },
+//	})
+//	...
+//	go session.Run(ctx)
+//	session.Estimate(ctx, "PROJ-42")
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error is the error envelope the server responds with.
+type Error struct {
+	StatusCode int
+	// Message is a string for most errors and a map of field errors for some.
+	Message   any    `json:"error"`
+	RequestId string `json:"requestId"`
+}
+
+func (err *Error) Error() string {
+	if err.Message == nil {
+		return fmt.Sprintf("estimation poker: %d %s", err.StatusCode, http.StatusText(err.StatusCode))
+	}
+	return fmt.Sprintf("estimation poker: %d %v", err.StatusCode, err.Message)
+}
+
+// Client calls the REST api. Its fields must not be changed while requests
+// are in flight.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Token is sent as bearer token, an api key, an owner token or the admin
+	// token depending on the endpoint.
+	Token string
+}
+
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// WithToken returns a copy of the client sending token, e.g. the owner
+// token of a single room.
+func (client *Client) WithToken(token string) *Client {
+	copied := *client
+	copied.Token = token
+	return &copied
+}
+
+func (client *Client) CreateRoom(ctx context.Context, input CreateRoomRequest) (CreateRoomResponse, error) {
+	var room CreateRoomResponse
+	err := client.do(ctx, http.MethodPost, "/v1/room", input, &room)
+	return room, err
+}
+
+// RoomsQuery filters and pages the lobby, zero values are left out.
+type RoomsQuery struct {
+	Limit        int
+	Cursor       string
+	Sort         string
+	Active       *bool
+	MinPlayers   int
+	CreatedAfter time.Time
+	Tag          string
+	Search       string
+}
+
+func (query RoomsQuery) values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	if query.Limit > 0 {
+		set("limit", strconv.Itoa(query.Limit))
+	}
+	set("cursor", query.Cursor)
+	set("sort", query.Sort)
+	if query.Active != nil {
+		set("active", strconv.FormatBool(*query.Active))
+	}
+	if query.MinPlayers > 0 {
+		set("minPlayers", strconv.Itoa(query.MinPlayers))
+	}
+	if !query.CreatedAfter.IsZero() {
+		set("createdAfter", query.CreatedAfter.Format(time.RFC3339))
+	}
+	set("tag", query.Tag)
+	set("q", query.Search)
+	return values
+}
+
+// Rooms returns a page of the lobby and the cursor of the next page, which is
+// empty on the last page.
+func (client *Client) Rooms(ctx context.Context, query RoomsQuery) ([]RoomOverview, string, error) {
+	path := "/v1/rooms"
+	if values := query.values(); len(values) > 0 {
+		path += "?" + values.Encode()
+	}
+	var page struct {
+		Rooms      []RoomOverview `json:"rooms"`
+		NextCursor string         `json:"nextCursor"`
+	}
+	err := client.do(ctx, http.MethodGet, path, nil, &page)
+	return page.Rooms, page.NextCursor, err
+}
+
+// Decks returns the decks of the server and the name of the default deck.
+func (client *Client) Decks(ctx context.Context) ([]Deck, string, error) {
+	var got struct {
+		Decks   []Deck `json:"decks"`
+		Default string `json:"default"`
+	}
+	err := client.do(ctx, http.MethodGet, "/v1/decks", nil, &got)
+	return got.Decks, got.Default, err
+}
+
+// RoomState returns the state of room, given by id, join code or slug.
+func (client *Client) RoomState(ctx context.Context, room string) (RoomState, error) {
+	var state RoomState
+	err := client.do(ctx, http.MethodGet, roomPath(room, "state"), nil, &state)
+	return state, err
+}
+
+// ConnectionState tells whether name may join room with password or invite,
+// both of which may be empty.
+func (client *Client) ConnectionState(ctx context.Context, room, name, password, invite string) (ConnectionState, error) {
+	var state ConnectionState
+	input := map[string]string{"username": name, "password": password, "invite": invite}
+	err := client.do(ctx, http.MethodPost, roomPath(room, "connection-state"), input, &state)
+	return state, err
+}
+
+// ImportIssues adds issues to the room and returns how many were added. It
+// needs the owner token or an api key with the issues:import scope.
+func (client *Client) ImportIssues(ctx context.Context, room string, titles []string) (int, error) {
+	var got struct {
+		Imported int `json:"imported"`
+	}
+	err := client.do(ctx, http.MethodPost, roomPath(room, "issues"), map[string][]string{"issues": titles}, &got)
+	return got.Imported, err
+}
+
+// ExportRoom needs the owner token or an api key with the exports:read scope.
+func (client *Client) ExportRoom(ctx context.Context, room string) (RoomExport, error) {
+	var export RoomExport
+	err := client.do(ctx, http.MethodGet, roomPath(room, "export"), nil, &export)
+	return export, err
+}
+
+// AuditTrail needs the owner token or an api key with the exports:read
+// scope.
+func (client *Client) AuditTrail(ctx context.Context, room string) ([]AuditEntry, error) {
+	var got struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+	err := client.do(ctx, http.MethodGet, roomPath(room, "audit"), nil, &got)
+	return got.Entries, err
+}
+
+// CreateInvite returns the token of an invite for role, which expires after
+// ttl, or the default of the server if ttl is 0, and may be used maxUses
+// times, 0 meaning unlimited.
+func (client *Client) CreateInvite(ctx context.Context, room, role string, ttl time.Duration, maxUses int) (string, Invite, error) {
+	input := map[string]any{"role": role, "maxUses": maxUses}
+	if ttl > 0 {
+		input["expiresIn"] = ttl.String()
+	}
+	var got struct {
+		Token  string `json:"token"`
+		Invite Invite `json:"invite"`
+	}
+	err := client.do(ctx, http.MethodPost, roomPath(room, "invites"), input, &got)
+	return got.Token, got.Invite, err
+}
+
+// RotateOwnerToken invalidates every owner token of the room and returns the
+// new one. It needs the current owner token.
+func (client *Client) RotateOwnerToken(ctx context.Context, room string) (string, error) {
+	var got struct {
+		OwnerToken string `json:"ownerToken"`
+	}
+	err := client.do(ctx, http.MethodPost, roomPath(room, "owner-token"), nil, &got)
+	return got.OwnerToken, err
+}
+
+func (client *Client) do(ctx context.Context, method, path string, body, destination any) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, client.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if client.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+client.Token)
+	}
+
+	response, err := client.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		apiErr := &Error{StatusCode: response.StatusCode}
+		json.NewDecoder(response.Body).Decode(apiErr)
+		return apiErr
+	}
+	if destination == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(destination)
+}
+
+func roomPath(room string, segments ...string) string {
+	path := "/v1/room/" + url.PathEscape(room)
+	for _, segment := range segments {
+		path += "/" + segment
+	}
+	return path
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+func TestClient(t *testing.T) {
+	active := true
+	tests := []struct {
+		name     string
+		call     func(api *Client) (any, error)
+		status   int
+		response string
+		wantPath string
+		wantBody string
+		want     any
+		wantErr  string
+	}{
+		{
+			name: "creates a room",
+			call: func(api *Client) (any, error) {
+				return api.CreateRoom(context.Background(), CreateRoomRequest{Creator: "Alice", RoomDetails: RoomDetails{Title: "Sprint"}})
+			},
+			status:   http.StatusCreated,
+			response: `{"id": "6f1c1d46-3b4c-4a4e-9d2c-0d1a2b3c4d5e", "code": "ABC123", "ownerToken": "owner"}`,
+			wantPath: "POST /v1/room",
+			wantBody: `{"creator":"Alice","title":"Sprint"}`,
+			want:     "ABC123 owner",
+		},
+		{
+			name: "pages the lobby",
+			call: func(api *Client) (any, error) {
+				rooms, next, err := api.Rooms(context.Background(), RoomsQuery{Limit: 2, Active: &active, CreatedAfter: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Search: "sprint"})
+				return [2]any{len(rooms), next}, err
+			},
+			status:   http.StatusOK,
+			response: `{"rooms": [{"id": "6f1c1d46-3b4c-4a4e-9d2c-0d1a2b3c4d5e", "playerCount": 2}], "nextCursor": "next"}`,
+			wantPath: "GET /v1/rooms?active=true&createdAfter=2024-01-01T00%3A00%3A00Z&limit=2&q=sprint",
+			want:     [2]any{1, "next"},
+		},
+		{
+			name: "escapes rooms",
+			call: func(api *Client) (any, error) {
+				return api.ImportIssues(context.Background(), "sprint 12", []string{"Login"})
+			},
+			status:   http.StatusCreated,
+			response: `{"imported": 1}`,
+			wantPath: "POST /v1/room/sprint%2012/issues",
+			wantBody: `{"issues":["Login"]}`,
+			want:     1,
+		},
+		{
+			name: "returns error envelopes",
+			call: func(api *Client) (any, error) {
+				return api.RoomState(context.Background(), "unknown")
+			},
+			status:   http.StatusNotFound,
+			response: `{"error": "the requested resource could not be found", "requestId": "abc"}`,
+			wantPath: "GET /v1/room/unknown/state",
+			wantErr:  "estimation poker: 404 the requested resource could not be found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotBody, gotAuth string
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				body, _ := io.ReadAll(request.Body)
+				gotPath, gotBody, gotAuth = request.Method+" "+request.URL.RequestURI(), string(body), request.Header.Get("Authorization")
+				writer.WriteHeader(tt.status)
+				io.WriteString(writer, tt.response)
+			}))
+			defer server.Close()
+
+			got, err := tt.call(New(server.URL + "/").WithToken("secret"))
+
+			assert.Equal(t, gotPath, tt.wantPath)
+			assert.Equal(t, gotBody, tt.wantBody)
+			assert.Equal(t, gotAuth, "Bearer secret")
+			if tt.wantErr != "" {
+				assert.Equal(t, err.Error(), tt.wantErr)
+				var apiErr *Error
+				assert.True(t, errors.As(err, &apiErr))
+				assert.Equal(t, apiErr.RequestId, "abc")
+				return
+			}
+			assert.NilError(t, err)
+			if room, ok := got.(CreateRoomResponse); ok {
+				got = room.Code + " " + room.OwnerToken
+			}
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
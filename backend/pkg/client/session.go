@@ -0,0 +1,478 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+var (
+	// ErrRoomClosed ends Run once the server closed the room.
+	ErrRoomClosed = errors.New("estimation poker: room was closed")
+	// ErrDisconnected ends Run once an operator disconnected the session.
+	ErrDisconnected = errors.New("estimation poker: session was disconnected")
+	ErrNotConnected = errors.New("estimation poker: session is not connected")
+	// ErrRejected is returned by commands the server answered with an error.
+	ErrRejected = errors.New("estimation poker: command was rejected")
+)
+
+type DialOptions struct {
+	// Room is the id, join code or slug of the room.
+	Room string
+	Name string
+	// Role defaults to RoleDeveloper.
+	Role string
+	// OwnerToken claims ownership of the room. The session keeps the token
+	// of the latest permissions, so ownership survives reconnects.
+	OwnerToken string
+	// Invite is only sent with the first handshake, which redeems it.
+	// Reconnects rely on the owner token or the session in Header instead.
+	Invite string
+	// Password unlocks a locked room, an invite or owner token replace it.
+	Password string
+	// Header is sent with every handshake, e.g. the session cookie of a
+	// logged in user.
+	Header    http.Header
+	Reconnect ReconnectPolicy
+}
+
+// ReconnectPolicy controls how Run redials after the connection was lost.
+// The delay doubles with every attempt. A zero policy never reconnects.
+type ReconnectPolicy struct {
+	MaxAttempts int
+	MinDelay    time.Duration
+	MaxDelay    time.Duration
+}
+
+// Handlers receive the messages of the room. Every handler is optional and
+// called from the goroutine of Run, one message at a time.
+type Handlers struct {
+	Permissions     func(Permissions)
+	Users           func([]User)
+	Leave           func(name string)
+	DeveloperAction func()
+	EveryoneDone    func(EveryoneDone)
+	YouGuessed      func(cardId string)
+	YouSkipped      func()
+	Estimate        func(ticket string)
+	NewRound        func()
+	Reveal          func([]Guess)
+	Statistics      func(Statistics)
+	BreakRequested  func(name string)
+	RoomLocked      func()
+	RoomOpened      func()
+	// Issues tells that the issues changed, RoomState returns them.
+	Issues         func()
+	RoomDetails    func(RoomDetails)
+	RoomVisibility func(visibility string)
+	RoomExpiring   func(Expiry)
+	RoomClosed     func(reason string)
+	Disconnected   func(reason string)
+	Maintenance    func(Notice)
+	Error          func(message string)
+	// Unknown receives messages of types this package does not know yet.
+	Unknown func(Message)
+	// Reconnecting is called before every redial with the error that ended
+	// the connection.
+	Reconnecting func(attempt int, err error)
+}
+
+// Session is a connection to a room. Commands may be sent from any
+// goroutine while Run dispatches the incoming messages. If the server speaks
+// Protocol, commands wait for their ack, which only arrives while Run is
+// running, and return ErrRejected if the server refused them. Older servers
+// don't acknowledge, so commands return once they are sent.
+type Session struct {
+	baseURL  string
+	options  DialOptions
+	handlers Handlers
+
+	mu         sync.Mutex
+	connection *websocket.Conn
+	ownerToken string
+	invite     string
+	closed     bool
+	lastId     uint64
+	pending    map[string]chan error
+}
+
+// Dial joins the room. Handshakes refused by the server return an *Error.
+func Dial(ctx context.Context, baseURL string, options DialOptions, handlers Handlers) (*Session, error) {
+	if options.Role == "" {
+		options.Role = RoleDeveloper
+	}
+	session := &Session{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		options:    options,
+		handlers:   handlers,
+		ownerToken: options.OwnerToken,
+		invite:     options.Invite,
+		pending:    make(map[string]chan error),
+	}
+	connection, err := session.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session.connection = connection
+	return session, nil
+}
+
+func (session *Session) dial(ctx context.Context) (*websocket.Conn, error) {
+	query := url.Values{"name": {session.options.Name}}
+	session.mu.Lock()
+	if session.ownerToken != "" {
+		query.Set("ownerToken", session.ownerToken)
+	}
+	if session.invite != "" {
+		query.Set("invite", session.invite)
+	}
+	session.mu.Unlock()
+	if session.options.Password != "" {
+		query.Set("password", session.options.Password)
+	}
+	endpoint := session.baseURL + roomPath(session.options.Room, session.options.Role) + "?" + query.Encode()
+
+	connection, response, err := websocket.Dial(ctx, endpoint, &websocket.DialOptions{
+		HTTPHeader:   session.options.Header,
+		Subprotocols: []string{Protocol},
+	})
+	if err != nil && response != nil && response.StatusCode != http.StatusSwitchingProtocols {
+		apiErr := &Error{StatusCode: response.StatusCode}
+		json.NewDecoder(response.Body).Decode(apiErr)
+		return nil, apiErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the handshake redeemed the invite, a single use one would refuse the
+	// next
+	session.mu.Lock()
+	session.invite = ""
+	session.mu.Unlock()
+	// the users message of big rooms exceeds the default limit
+	connection.SetReadLimit(1 << 20)
+	return connection, nil
+}
+
+// OwnerToken returns the owner token of the latest permissions, if the
+// session owns the room.
+func (session *Session) OwnerToken() string {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.ownerToken
+}
+
+// Run dispatches incoming messages to the handlers until ctx is done, the
+// session is closed or the connection is lost for good. It returns nil after
+// Close, ErrRoomClosed or ErrDisconnected if the server ended the session.
+func (session *Session) Run(ctx context.Context) error {
+	for {
+		err := session.read(ctx)
+		session.abandon()
+		session.mu.Lock()
+		closed := session.closed
+		session.mu.Unlock()
+		switch {
+		case closed:
+			return nil
+		case ctx.Err() != nil:
+			session.Close()
+			return ctx.Err()
+		case errors.Is(err, ErrRoomClosed), errors.Is(err, ErrDisconnected):
+			return err
+		}
+
+		if err := session.reconnect(ctx, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (session *Session) read(ctx context.Context) error {
+	session.mu.Lock()
+	connection := session.connection
+	session.mu.Unlock()
+	if connection == nil {
+		return ErrNotConnected
+	}
+
+	for {
+		var msg Message
+		if err := wsjson.Read(ctx, connection, &msg); err != nil {
+			return err
+		}
+		if err := session.dispatch(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// reconnect redials with backoff after cause ended the connection. Refused
+// handshakes are not retried.
+func (session *Session) reconnect(ctx context.Context, cause error) error {
+	policy := session.options.Reconnect
+	delay := policy.MinDelay
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		if session.handlers.Reconnecting != nil {
+			session.handlers.Reconnecting(attempt, cause)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		connection, err := session.dial(ctx)
+		var apiErr *Error
+		switch {
+		case errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError:
+			return err
+		case err != nil:
+			cause = err
+			delay = min(max(2*delay, time.Millisecond), max(policy.MaxDelay, policy.MinDelay))
+			continue
+		}
+
+		session.mu.Lock()
+		if session.closed {
+			session.mu.Unlock()
+			connection.CloseNow()
+			return nil
+		}
+		session.connection = connection
+		session.mu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("estimation poker: connection lost: %w", cause)
+}
+
+func (session *Session) dispatch(msg Message) error {
+	if msg.Id != "" && (msg.Type == TypeAck || msg.Type == TypeError) {
+		return session.resolve(msg)
+	}
+
+	handlers := session.handlers
+	var err error
+	switch msg.Type {
+	case TypePermissions:
+		err = decode(msg.Data, func(permissions Permissions) {
+			session.mu.Lock()
+			session.ownerToken = permissions.OwnerToken
+			session.mu.Unlock()
+			if handlers.Permissions != nil {
+				handlers.Permissions(permissions)
+			}
+		})
+	case TypeUsers:
+		err = decode(msg.Data, handlers.Users)
+	case TypeLeave:
+		err = decode(msg.Data, handlers.Leave)
+	case TypeDeveloperAction:
+		notify(handlers.DeveloperAction)
+	case TypeEveryoneDone:
+		err = decode(msg.Data, handlers.EveryoneDone)
+	case TypeYouGuessed:
+		err = decode(msg.Data, handlers.YouGuessed)
+	case TypeYouSkipped:
+		notify(handlers.YouSkipped)
+	case TypeEstimate:
+		err = decode(msg.Data, handlers.Estimate)
+	case TypeNewRound:
+		notify(handlers.NewRound)
+	case TypeReveal:
+		err = decode(msg.Data, handlers.Reveal)
+	case TypeStatistics:
+		err = decode(msg.Data, handlers.Statistics)
+	case TypeBreakRequested:
+		err = decode(msg.Data, handlers.BreakRequested)
+	case TypeRoomLocked:
+		notify(handlers.RoomLocked)
+	case TypeRoomOpened:
+		notify(handlers.RoomOpened)
+	case TypeIssues:
+		notify(handlers.Issues)
+	case TypeRoomDetails:
+		err = decode(msg.Data, handlers.RoomDetails)
+	case TypeRoomVisibility:
+		err = decode(msg.Data, handlers.RoomVisibility)
+	case TypeRoomExpiring:
+		err = decode(msg.Data, handlers.RoomExpiring)
+	case TypeRoomClosed:
+		if err = decode(msg.Data, handlers.RoomClosed); err == nil {
+			return ErrRoomClosed
+		}
+	case TypeDisconnected:
+		if err = decode(msg.Data, handlers.Disconnected); err == nil {
+			return ErrDisconnected
+		}
+	case TypeMaintenance:
+		err = decode(msg.Data, handlers.Maintenance)
+	case TypeError:
+		err = decode(msg.Data, handlers.Error)
+	default:
+		if handlers.Unknown != nil {
+			handlers.Unknown(msg)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("estimation poker: invalid %s message: %w", msg.Type, err)
+	}
+	return nil
+}
+
+// resolve hands the ack or error msg to the command waiting for it.
+func (session *Session) resolve(msg Message) error {
+	var result error
+	if msg.Type == TypeError {
+		var reason string
+		if err := json.Unmarshal(msg.Data, &reason); err != nil {
+			return fmt.Errorf("estimation poker: invalid %s message: %w", msg.Type, err)
+		}
+		result = fmt.Errorf("%w: %s", ErrRejected, reason)
+	}
+
+	session.mu.Lock()
+	reply, ok := session.pending[msg.Id]
+	delete(session.pending, msg.Id)
+	session.mu.Unlock()
+	if ok {
+		reply <- result
+	}
+	return nil
+}
+
+// abandon fails the commands still waiting for an ack, which will never
+// arrive once the connection is gone.
+func (session *Session) abandon() {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	for id, reply := range session.pending {
+		reply <- ErrNotConnected
+		delete(session.pending, id)
+	}
+}
+
+// decode passes data as T to handler, if there is one.
+func decode[T any](data json.RawMessage, handler func(T)) error {
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if handler != nil {
+		handler(value)
+	}
+	return nil
+}
+
+func notify(handler func()) {
+	if handler != nil {
+		handler()
+	}
+}
+
+// Close leaves the room. Run returns nil afterwards.
+func (session *Session) Close() error {
+	session.mu.Lock()
+	session.closed = true
+	connection := session.connection
+	session.connection = nil
+	session.mu.Unlock()
+	session.abandon()
+	if connection == nil {
+		return nil
+	}
+	return connection.Close(websocket.StatusNormalClosure, "")
+}
+
+func (session *Session) send(ctx context.Context, messageType string, data any) error {
+	session.mu.Lock()
+	connection := session.connection
+	if connection == nil {
+		session.mu.Unlock()
+		return ErrNotConnected
+	}
+	var (
+		id    string
+		reply chan error
+	)
+	if connection.Subprotocol() == Protocol {
+		session.lastId++
+		id = strconv.FormatUint(session.lastId, 10)
+		reply = make(chan error, 1)
+		session.pending[id] = reply
+	}
+	session.mu.Unlock()
+
+	err := wsjson.Write(ctx, connection, struct {
+		Id   string `json:"id,omitempty"`
+		Type string `json:"type"`
+		Data any    `json:"data"`
+	}{id, messageType, data})
+	if err != nil || reply == nil {
+		session.forget(id)
+		return err
+	}
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		session.forget(id)
+		return ctx.Err()
+	}
+}
+
+func (session *Session) forget(id string) {
+	session.mu.Lock()
+	delete(session.pending, id)
+	session.mu.Unlock()
+}
+
+// Guess votes for the card with cardId. Only developers can guess.
+func (session *Session) Guess(ctx context.Context, cardId string) error {
+	return session.send(ctx, TypeGuess, cardId)
+}
+
+func (session *Session) Skip(ctx context.Context) error {
+	return session.send(ctx, TypeSkip, nil)
+}
+
+// Estimate starts a round for ticket. Only product owners can estimate.
+func (session *Session) Estimate(ctx context.Context, ticket string) error {
+	return session.send(ctx, TypeEstimate, ticket)
+}
+
+func (session *Session) NewRound(ctx context.Context) error {
+	return session.send(ctx, TypeNewRound, nil)
+}
+
+func (session *Session) Reveal(ctx context.Context) error {
+	return session.send(ctx, TypeReveal, nil)
+}
+
+// LockRoom protects the room with password. Only the owner can lock it.
+func (session *Session) LockRoom(ctx context.Context, password string) error {
+	return session.send(ctx, TypeLockRoom, map[string]string{"password": password})
+}
+
+func (session *Session) OpenRoom(ctx context.Context) error {
+	return session.send(ctx, TypeOpenRoom, nil)
+}
+
+func (session *Session) AddIssue(ctx context.Context, title string) error {
+	return session.send(ctx, TypeAddIssue, title)
+}
+
+// RotateOwnerToken asks for a new owner token, which arrives as permissions.
+func (session *Session) RotateOwnerToken(ctx context.Context) error {
+	return session.send(ctx, TypeRotateOwner, nil)
+}
@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"github.com/Hydoc/estimation-poker/backend/internal/assert"
+)
+
+// scriptedServer runs the script of the nth connection for the nth handshake
+// and refuses every handshake without a script. It speaks the legacy
+// protocol unless started with other accept options.
+type scriptedServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	scripts []func(connection *websocket.Conn, request *http.Request)
+	queries []string
+}
+
+func newScriptedServer(t *testing.T, scripts ...func(connection *websocket.Conn, request *http.Request)) *scriptedServer {
+	return startScriptedServer(t, nil, scripts...)
+}
+
+func startScriptedServer(t *testing.T, options *websocket.AcceptOptions, scripts ...func(connection *websocket.Conn, request *http.Request)) *scriptedServer {
+	server := &scriptedServer{scripts: scripts}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		server.mu.Lock()
+		server.queries = append(server.queries, request.URL.RawQuery)
+		attempt := len(server.queries) - 1
+		server.mu.Unlock()
+		if attempt >= len(server.scripts) {
+			writer.WriteHeader(http.StatusNotFound)
+			writer.Write([]byte(`{"error": "the requested resource could not be found"}`))
+			return
+		}
+
+		connection, err := websocket.Accept(writer, request, options)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer connection.CloseNow()
+		server.scripts[attempt](connection, request)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (server *scriptedServer) handshakes() []string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return slices.Clone(server.queries)
+}
+
+func write(connection *websocket.Conn, messageType string, data any) {
+	wsjson.Write(context.Background(), connection, map[string]any{"type": messageType, "data": data})
+}
+
+func TestSession_dispatchesTypedMessages(t *testing.T) {
+	commands := make(chan Message, 2)
+	server := newScriptedServer(t, func(connection *websocket.Conn, _ *http.Request) {
+		write(connection, TypePermissions, Permissions{CanLockRoom: true, OwnerToken: "owner"})
+		write(connection, TypeUsers, []User{{Name: "Alice", Role: RoleProductOwner}, {Name: "Bob", Role: RoleDeveloper, IsDone: true}})
+		write(connection, TypeEstimate, "PROJ-1")
+		write(connection, TypeEveryoneDone, nil)
+		write(connection, TypeReveal, []map[string]any{{"name": "Bob", "role": RoleDeveloper, "guess": 3, "card": map[string]any{"id": "3", "value": 3}, "doSkip": false}})
+		write(connection, TypeIssues, nil)
+		write(connection, "future-message", 1)
+		for range 2 {
+			var command Message
+			wsjson.Read(context.Background(), connection, &command)
+			commands <- command
+		}
+		write(connection, TypeRoomClosed, "idle")
+		connection.Read(context.Background())
+	})
+
+	var got []string
+	session, err := Dial(context.Background(), server.URL, DialOptions{Room: "sprint", Name: "Alice", Role: RoleProductOwner}, Handlers{
+		Permissions: func(permissions Permissions) { got = append(got, "permissions "+permissions.OwnerToken) },
+		Users: func(users []User) {
+			got = append(got, "users "+users[1].Name)
+		},
+		Estimate:     func(ticket string) { got = append(got, "estimate "+ticket) },
+		EveryoneDone: func(done EveryoneDone) { got = append(got, "everyone done") },
+		Reveal: func(guesses []Guess) {
+			got = append(got, "reveal "+guesses[0].Card.Id)
+		},
+		Issues:     func() { got = append(got, "issues") },
+		Unknown:    func(msg Message) { got = append(got, "unknown "+msg.Type) },
+		RoomClosed: func(reason string) { got = append(got, "closed "+reason) },
+	})
+	assert.NilError(t, err)
+	assert.NilError(t, session.Estimate(context.Background(), "PROJ-2"))
+	assert.NilError(t, session.LockRoom(context.Background(), "secret"))
+
+	err = session.Run(context.Background())
+
+	assert.True(t, errors.Is(err, ErrRoomClosed))
+	assert.DeepEqual(t, got, []string{"permissions owner", "users Bob", "estimate PROJ-1", "everyone done", "reveal 3", "issues", "unknown future-message", "closed idle"})
+	assert.Equal(t, session.OwnerToken(), "owner")
+	estimate, lock := <-commands, <-commands
+	assert.Equal(t, estimate.Type, TypeEstimate)
+	assert.Equal(t, string(estimate.Data), `"PROJ-2"`)
+	assert.Equal(t, lock.Type, TypeLockRoom)
+	assert.Equal(t, string(lock.Data), `{"password":"secret"}`)
+	assert.DeepEqual(t, server.handshakes(), []string{"name=Alice"})
+}
+
+func TestSession_waitsForAcks(t *testing.T) {
+	server := startScriptedServer(t, &websocket.AcceptOptions{Subprotocols: []string{Protocol}}, func(connection *websocket.Conn, _ *http.Request) {
+		var command Message
+		wsjson.Read(context.Background(), connection, &command)
+		write(connection, TypeEstimate, command.Data)
+		wsjson.Write(context.Background(), connection, Message{Id: command.Id, Type: TypeAck})
+		wsjson.Read(context.Background(), connection, &command)
+		wsjson.Write(context.Background(), connection, map[string]any{"id": command.Id, "type": TypeError, "data": "command is not permitted"})
+		wsjson.Read(context.Background(), connection, &command)
+		connection.Close(websocket.StatusGoingAway, "restart")
+	})
+	estimates := make(chan string, 1)
+	session, err := Dial(context.Background(), server.URL, DialOptions{Room: "sprint", Name: "Alice"}, Handlers{
+		Estimate: func(ticket string) { estimates <- ticket },
+	})
+	assert.NilError(t, err)
+	done := make(chan error)
+	go func() { done <- session.Run(context.Background()) }()
+
+	assert.NilError(t, session.Estimate(context.Background(), "PROJ-1"))
+	assert.Equal(t, <-estimates, "PROJ-1")
+	err = session.Reveal(context.Background())
+	assert.True(t, errors.Is(err, ErrRejected))
+	assert.Equal(t, err.Error(), "estimation poker: command was rejected: command is not permitted")
+	assert.True(t, errors.Is(session.NewRound(context.Background()), ErrNotConnected))
+	assert.StringContains(t, (<-done).Error(), "connection lost")
+}
+
+func TestSession_reconnectsWithTheOwnerToken(t *testing.T) {
+	server := newScriptedServer(t,
+		func(connection *websocket.Conn, _ *http.Request) {
+			write(connection, TypePermissions, Permissions{CanLockRoom: true, OwnerToken: "owner"})
+			connection.Close(websocket.StatusGoingAway, "restart")
+		},
+		func(connection *websocket.Conn, _ *http.Request) {
+			write(connection, TypeDisconnected, "bye")
+			connection.Read(context.Background())
+		},
+	)
+
+	var attempts []int
+	session, err := Dial(context.Background(), server.URL, DialOptions{
+		Room:      "sprint",
+		Name:      "Alice",
+		Reconnect: ReconnectPolicy{MaxAttempts: 3, MinDelay: time.Millisecond},
+	}, Handlers{
+		Reconnecting: func(attempt int, err error) { attempts = append(attempts, attempt) },
+	})
+	assert.NilError(t, err)
+
+	err = session.Run(context.Background())
+
+	assert.True(t, errors.Is(err, ErrDisconnected))
+	assert.DeepEqual(t, attempts, []int{1})
+	assert.DeepEqual(t, server.handshakes(), []string{"name=Alice", "name=Alice&ownerToken=owner"})
+}
+
+func TestSession_reconnectsWithoutTheRedeemedInvite(t *testing.T) {
+	server := newScriptedServer(t,
+		func(connection *websocket.Conn, _ *http.Request) {
+			connection.Close(websocket.StatusGoingAway, "restart")
+		},
+		func(connection *websocket.Conn, _ *http.Request) {
+			write(connection, TypeDisconnected, "bye")
+			connection.Read(context.Background())
+		},
+	)
+
+	session, err := Dial(context.Background(), server.URL, DialOptions{
+		Room:      "sprint",
+		Name:      "Bob",
+		Invite:    "single-use",
+		Reconnect: ReconnectPolicy{MaxAttempts: 3, MinDelay: time.Millisecond},
+	}, Handlers{})
+	assert.NilError(t, err)
+
+	err = session.Run(context.Background())
+
+	assert.True(t, errors.Is(err, ErrDisconnected))
+	// a single use invite would refuse the second handshake
+	assert.DeepEqual(t, server.handshakes(), []string{"invite=single-use&name=Bob", "name=Bob"})
+}
+
+func TestSession_stopsWhenTheHandshakeIsRefused(t *testing.T) {
+	server := newScriptedServer(t, func(connection *websocket.Conn, _ *http.Request) {
+		connection.Close(websocket.StatusGoingAway, "restart")
+	})
+
+	session, err := Dial(context.Background(), server.URL, DialOptions{Room: "sprint", Name: "Alice", Reconnect: ReconnectPolicy{MaxAttempts: 5}}, Handlers{})
+	assert.NilError(t, err)
+
+	err = session.Run(context.Background())
+
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, apiErr.StatusCode, http.StatusNotFound)
+	assert.Equal(t, len(server.handshakes()), 2)
+
+	_, err = Dial(context.Background(), server.URL, DialOptions{Room: "sprint", Name: "Alice"}, Handlers{})
+	assert.Equal(t, err.Error(), "estimation poker: 404 the requested resource could not be found")
+}
+
+func TestSession_Close(t *testing.T) {
+	server := newScriptedServer(t, func(connection *websocket.Conn, _ *http.Request) {
+		connection.Read(context.Background())
+	})
+	session, err := Dial(context.Background(), server.URL, DialOptions{Room: "sprint", Name: "Alice", Reconnect: ReconnectPolicy{MaxAttempts: 5}}, Handlers{})
+	assert.NilError(t, err)
+	done := make(chan error)
+	go func() { done <- session.Run(context.Background()) }()
+
+	assert.NilError(t, session.Close())
+
+	assert.NilError(t, <-done)
+	assert.True(t, errors.Is(session.Skip(context.Background()), ErrNotConnected))
+}
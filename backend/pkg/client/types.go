@@ -0,0 +1,232 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleDeveloper    = "developer"
+	RoleProductOwner = "product-owner"
+
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+
+	// Protocol is the websocket subprotocol sessions offer. Servers speaking
+	// it acknowledge every command.
+	Protocol = "estimation-poker.v1"
+)
+
+// Types of the messages a session sends to the server.
+const (
+	TypeGuess       = "guess"
+	TypeSkip        = "skip"
+	TypeEstimate    = "estimate"
+	TypeNewRound    = "new-round"
+	TypeReveal      = "reveal"
+	TypeLockRoom    = "lock-room"
+	TypeOpenRoom    = "open-room"
+	TypeAddIssue    = "add-issue"
+	TypeRotateOwner = "rotate-owner-token"
+)
+
+// Types of the messages the server sends to a session. Estimate, new-round,
+// reveal and rotate-owner-token are sent in both directions.
+const (
+	TypePermissions     = "permissions"
+	TypeUsers           = "users"
+	TypeLeave           = "leave"
+	TypeDeveloperAction = "developer-action"
+	TypeEveryoneDone    = "everyone-done"
+	TypeYouGuessed      = "you-guessed"
+	TypeYouSkipped      = "you-skipped"
+	TypeStatistics      = "statistics"
+	TypeBreakRequested  = "break-requested"
+	TypeRoomLocked      = "room-locked"
+	TypeRoomOpened      = "room-opened"
+	TypeIssues          = "issues"
+	TypeRoomDetails     = "room-details"
+	TypeRoomVisibility  = "room-visibility"
+	TypeRoomExpiring    = "room-expiring"
+	TypeRoomClosed      = "room-closed"
+	TypeDisconnected    = "disconnected"
+	TypeMaintenance     = "maintenance"
+	TypeError           = "error"
+	TypeAck             = "ack"
+)
+
+// Message is a websocket message in either direction. Data is decoded
+// according to Type. Id pairs commands with their ack or error.
+type Message struct {
+	Id   string          `json:"id,omitempty"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Permissions tell a session whether it owns the room. Only the owner gets
+// the owner token, which reclaims ownership when reconnecting. It is empty
+// for everybody else.
+type Permissions struct {
+	CanLockRoom bool   `json:"canLockRoom"`
+	OwnerToken  string `json:"ownerToken"`
+}
+
+// User is a participant of a room. IsDone is only reported for developers.
+type User struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	IsDone   bool   `json:"isDone"`
+	Verified bool   `json:"verified,omitempty"`
+	Avatar   string `json:"avatar,omitempty"`
+}
+
+// Card is a card of a deck. Numeric cards carry a Value, special cards like
+// "?" or "coffee" don't.
+type Card struct {
+	Id          string   `json:"id"`
+	Label       string   `json:"label"`
+	Value       *float64 `json:"value"`
+	Special     bool     `json:"special"`
+	Guess       float64  `json:"guess"`
+	Description string   `json:"description"`
+}
+
+type Deck struct {
+	Name    string `json:"name"`
+	Label   string `json:"label"`
+	Guesses []Card `json:"guesses"`
+}
+
+// Guess is the revealed vote of a developer. Card is nil if it skipped.
+type Guess struct {
+	Name   string  `json:"name"`
+	Role   string  `json:"role"`
+	Guess  float64 `json:"guess"`
+	Card   *Card   `json:"card"`
+	DoSkip bool    `json:"doSkip"`
+}
+
+type Statistics struct {
+	Votes   int      `json:"votes"`
+	Ignored int      `json:"ignored"`
+	Skipped int      `json:"skipped"`
+	Average *float64 `json:"average"`
+	Median  *float64 `json:"median"`
+	Min     *float64 `json:"min"`
+	Max     *float64 `json:"max"`
+}
+
+// EveryoneDone names the developers of the team roster who are not in the
+// room. It is empty for rooms without a team.
+type EveryoneDone struct {
+	Missing []string `json:"missing"`
+}
+
+type Expiry struct {
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Notice is a message of the operators of the server.
+type Notice struct {
+	Message string `json:"message"`
+}
+
+type RoomDetails struct {
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Team        string   `json:"team,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+type Issue struct {
+	Title string `json:"title"`
+	Guess int    `json:"guess"`
+}
+
+type RosterEntry struct {
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Present bool   `json:"present"`
+}
+
+type RoomState struct {
+	InProgress      bool          `json:"inProgress"`
+	IsLocked        bool          `json:"isLocked"`
+	Issues          []Issue       `json:"issues"`
+	Deck            string        `json:"deck"`
+	PossibleGuesses []Card        `json:"possibleGuesses"`
+	Visibility      string        `json:"visibility"`
+	AllowedDomain   string        `json:"allowedDomain,omitempty"`
+	TeamId          string        `json:"teamId,omitempty"`
+	Roster          []RosterEntry `json:"roster,omitempty"`
+	RoomDetails
+}
+
+// RoomOverview is a room as listed in the lobby.
+type RoomOverview struct {
+	Id          uuid.UUID `json:"id"`
+	Code        string    `json:"code,omitempty"`
+	Slug        string    `json:"slug,omitempty"`
+	PlayerCount int       `json:"playerCount"`
+	RoomDetails
+}
+
+type CreateRoomRequest struct {
+	Creator       string `json:"creator"`
+	Deck          string `json:"deck,omitempty"`
+	Slug          string `json:"slug,omitempty"`
+	Visibility    string `json:"visibility,omitempty"`
+	AllowedDomain string `json:"allowedDomain,omitempty"`
+	TeamId        string `json:"teamId,omitempty"`
+	RoomDetails
+}
+
+// CreateRoomResponse only carries an owner token for rooms created with an
+// api key.
+type CreateRoomResponse struct {
+	Id         uuid.UUID `json:"id"`
+	Code       string    `json:"code"`
+	Slug       string    `json:"slug,omitempty"`
+	OwnerToken string    `json:"ownerToken,omitempty"`
+}
+
+type ConnectionState struct {
+	CanConnect bool   `json:"canConnect"`
+	Reason     string `json:"reason"`
+	Role       string `json:"role,omitempty"`
+}
+
+type Invite struct {
+	Id        uuid.UUID `json:"id"`
+	RoomId    uuid.UUID `json:"room"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	MaxUses   int       `json:"maxUses,omitempty"`
+}
+
+type AuditEntry struct {
+	RoomId  uuid.UUID       `json:"roomId"`
+	Seq     int             `json:"seq,omitempty"`
+	Time    time.Time       `json:"time"`
+	Actor   string          `json:"actor"`
+	Role    string          `json:"role"`
+	Action  string          `json:"action"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// RoomExport are the results of a room.
+type RoomExport struct {
+	Room struct {
+		Id            uuid.UUID    `json:"id"`
+		NameOfCreator string       `json:"nameOfCreator"`
+		Deck          string       `json:"deck"`
+		Created       time.Time    `json:"created"`
+		Issues        []Issue      `json:"issues"`
+		AuditTrail    []AuditEntry `json:"auditTrail"`
+	} `json:"room"`
+	Details RoomDetails `json:"details"`
+}
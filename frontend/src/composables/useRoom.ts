@@ -3,19 +3,28 @@ import { isJust, just, type Maybe, nothing } from "@kaumlaut/pure/maybe";
 import {
   type ConnectionState,
   type DeveloperDone,
+  isBreakRequestedWebsocketMessage,
   isConnectionState,
+  isDisconnectedWebsocketMessage,
+  isErrorWebsocketMessage,
   isEstimateWebsocketMessage,
   isEveryoneDoneWebsocketMessage,
   isIssuesWebsocketMessage,
   isLeaveWebsocketMessage,
+  isMaintenanceWebsocketMessage,
   isNewRoundWebsocketMessage,
   isPermissionsWebsocketMessage,
   isReceivableWebsocketMessage,
   isRevealWebsocketMessage,
+  isRoomClosedWebsocketMessage,
+  isRoomDetailsWebsocketMessage,
+  isRoomExpiringWebsocketMessage,
   isRoomLockedWebsocketMessage,
   isRoomMetadata,
   isRoomOpenedWebsocketMessage,
   isRoomStateResponse,
+  isRoomVisibilityWebsocketMessage,
+  isStatisticsWebsocketMessage,
   type Issue,
   isUsersWebsocketMessage,
   isYouGuessedWebsocketMessage,
@@ -27,6 +36,7 @@ import {
   type RoomState,
   RoundState,
   type SendableWebsocketMessage,
+  type Statistics,
   type UserOverview,
 } from "@/types/room.ts";
 import { useWebsocket } from "@/composables/useWebsocket.ts";
@@ -34,7 +44,7 @@ import { useWebsocket } from "@/composables/useWebsocket.ts";
 export type UseRoom = {
   roomState: ComputedRef<RoomState>;
   roomNotifications: Ref<string[]>;
-  joinRoom(name: string, role: Role, roomId: string): Promise<void>;
+  joinRoom(name: string, role: Role, roomId: string, password?: string): Promise<void>;
   leaveRoom(): void;
   send(message: SendableWebsocketMessage): void;
   roomMetadata(roomId: string): Promise<RoomMetadata>;
@@ -42,12 +52,18 @@ export type UseRoom = {
   fetchRoomState(): Promise<void>;
 };
 
+// ownerTokenKey is where the owner token of a room is kept, so the owner keeps
+// their rights after reloading the page or reconnecting.
+function ownerTokenKey(roomId: string): string {
+  return `ownerToken:${roomId}`;
+}
+
 export function useRoom(): UseRoom {
   const websocket = useWebsocket();
   const roomId = ref<Maybe<string>>(nothing());
   const name = ref<Maybe<string>>(nothing());
   const role = ref<Maybe<Role>>(nothing());
-  const guess = ref<Maybe<number>>(nothing());
+  const guess = ref<Maybe<string>>(nothing());
   const issueToGuess = ref<Maybe<string>>(nothing());
   const doSkip = ref<boolean>(false);
   const roundState = ref<RoundState>(RoundState.Waiting);
@@ -59,9 +75,10 @@ export function useRoom(): UseRoom {
   const developerDone: Ref<DeveloperDone[]> = ref([]);
   const issues = ref<Issue[]>([]);
   const possibleGuesses = ref<PossibleGuess[]>([]);
+  const statistics = ref<Maybe<Statistics>>(nothing());
   const permissions = ref<Permissions>({
     canLockRoom: false,
-    key: "",
+    ownerToken: "",
   });
 
   const roomState = computed(
@@ -82,6 +99,7 @@ export function useRoom(): UseRoom {
       isConnected: websocket.isConnected.value,
       permissions: permissions.value,
       possibleGuesses: possibleGuesses.value,
+      statistics: statistics.value,
     }),
   );
 
@@ -92,11 +110,25 @@ export function useRoom(): UseRoom {
     roundState.value = RoundState.Waiting;
     showAllGuesses.value = false;
     developerDone.value = [];
+    statistics.value = nothing();
   }
 
-  async function joinRoom(username: string, userRole: Role, roomIdToJoin: string) {
+  async function joinRoom(
+    username: string,
+    userRole: Role,
+    roomIdToJoin: string,
+    password: string = "",
+  ) {
     const roleUrl = userRole === Role.Developer ? "developer" : "product-owner";
-    const url = `${window.location.host}/v1/room/${roomIdToJoin}/${roleUrl}?name=${username}`;
+    const query = new URLSearchParams({ name: username });
+    if (password !== "") {
+      query.set("password", password);
+    }
+    const ownerToken = sessionStorage.getItem(ownerTokenKey(roomIdToJoin));
+    if (ownerToken) {
+      query.set("ownerToken", ownerToken);
+    }
+    const url = `${window.location.host}/v1/room/${roomIdToJoin}/${roleUrl}?${query}`;
     const connected = await websocket.connect(url, onWebsocketMessage);
     if (!connected) {
       throw new Error("Could not connect");
@@ -147,6 +179,10 @@ export function useRoom(): UseRoom {
 
     if (isEveryoneDoneWebsocketMessage(result.value).success) {
       roundState.value = RoundState.End;
+      const missing = result.value.data?.missing ?? [];
+      if (missing.length > 0) {
+        roomNotifications.value.push(`${missing.join(", ")} did not join the round…`);
+      }
       return;
     }
 
@@ -156,6 +192,16 @@ export function useRoom(): UseRoom {
       return;
     }
 
+    if (isStatisticsWebsocketMessage(result.value).success) {
+      statistics.value = just(result.value.data);
+      return;
+    }
+
+    if (isBreakRequestedWebsocketMessage(result.value).success) {
+      roomNotifications.value.push(`${result.value.data} needs a break…`);
+      return;
+    }
+
     if (isRoomLockedWebsocketMessage(result.value).success) {
       roomIsLocked.value = true;
       return;
@@ -166,6 +212,55 @@ export function useRoom(): UseRoom {
       return;
     }
 
+    if (isRoomExpiringWebsocketMessage(result.value).success) {
+      const { reason, expiresAt } = result.value.data;
+      const time = new Date(expiresAt).toLocaleTimeString([], {
+        hour: "2-digit",
+        minute: "2-digit",
+      });
+      const cause = reason === "idle" ? "nobody was active" : "it reached its maximum age";
+      roomNotifications.value.push(`The room closes at ${time} because ${cause}…`);
+      return;
+    }
+
+    if (isRoomClosedWebsocketMessage(result.value).success) {
+      leaveRoom();
+      roomNotifications.value.push("The room was closed…");
+      return;
+    }
+
+    if (isRoomDetailsWebsocketMessage(result.value).success) {
+      const title = result.value.data.title;
+      roomNotifications.value.push(
+        title ? `The room is called "${title}" now…` : "The room details changed…",
+      );
+      return;
+    }
+
+    if (isRoomVisibilityWebsocketMessage(result.value).success) {
+      roomNotifications.value.push(`The room is ${result.value.data} now…`);
+      return;
+    }
+
+    if (isDisconnectedWebsocketMessage(result.value).success) {
+      const reason = result.value.data;
+      leaveRoom();
+      roomNotifications.value.push(
+        reason ? `You were disconnected: ${reason}…` : "You were disconnected…",
+      );
+      return;
+    }
+
+    if (isMaintenanceWebsocketMessage(result.value).success) {
+      roomNotifications.value.push(`${result.value.data.message}…`);
+      return;
+    }
+
+    if (isErrorWebsocketMessage(result.value).success) {
+      roomNotifications.value.push(`Something went wrong: ${result.value.data}…`);
+      return;
+    }
+
     if (isNewRoundWebsocketMessage(result.value).success) {
       resetRound();
       return;
@@ -178,6 +273,9 @@ export function useRoom(): UseRoom {
 
     if (isPermissionsWebsocketMessage(result.value).success) {
       permissions.value = result.value.data;
+      if (isJust(roomId.value) && result.value.data.ownerToken !== "") {
+        sessionStorage.setItem(ownerTokenKey(roomId.value.value), result.value.data.ownerToken);
+      }
       return;
     }
 
@@ -193,7 +291,7 @@ export function useRoom(): UseRoom {
     issues.value = [];
     permissions.value = {
       canLockRoom: false,
-      key: "",
+      ownerToken: "",
     };
     resetRound();
   }
@@ -242,7 +340,12 @@ export function useRoom(): UseRoom {
     if (!isJust(roomId.value)) {
       throw new Error("Could not fetch room state");
     }
-    const response = await fetch(`/v1/room/${roomId.value.value}/state`);
+    // private rooms only show their state to the owner
+    const ownerToken = sessionStorage.getItem(ownerTokenKey(roomId.value.value));
+    const url = `/v1/room/${roomId.value.value}/state`;
+    const response = ownerToken
+      ? await fetch(url, { headers: { Authorization: `Bearer ${ownerToken}` } })
+      : await fetch(url);
 
     if (!response.ok) {
       throw new Error("Could not fetch room state");
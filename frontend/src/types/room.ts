@@ -32,6 +32,7 @@ export type Developer = User & {
 export type DeveloperDone = {
   doSkip: boolean;
   guess: number;
+  card: PossibleGuess | null;
   name: string;
   role: Role.Developer;
 };
@@ -46,7 +47,17 @@ export enum Role {
 
 export type ConnectionState = {
   canConnect: boolean;
-  reason: "wrong password" | "round already started" | "username already taken" | "";
+  reason:
+    | "wrong password"
+    | "round already started"
+    | "username already taken"
+    | "room is private"
+    | "invite is invalid"
+    | "invite has expired"
+    | "invite has been used up"
+    | "room requires login"
+    | "room is restricted to another email domain"
+    | "";
 };
 
 export type Issue = {
@@ -61,7 +72,7 @@ export type RoomMetadata = {
 
 export type Permissions = {
   canLockRoom: boolean;
-  key: string;
+  ownerToken: string;
 };
 
 export enum RoundState {
@@ -72,7 +83,7 @@ export enum RoundState {
 
 export type RoomState = Readonly<{
   id: Maybe<string>;
-  guess: Maybe<number>;
+  guess: Maybe<string>;
   role: Maybe<Role>;
   name: Maybe<string>;
   doSkip: boolean;
@@ -87,6 +98,7 @@ export type RoomState = Readonly<{
   isConnected: boolean;
   permissions: Permissions;
   possibleGuesses: PossibleGuess[];
+  statistics: Maybe<Statistics>;
 }>;
 
 export type SendableWebsocketMessageType =
@@ -119,15 +131,60 @@ export type ReceivableWebsocketMessage = {
     | "room-opened"
     | "issues"
     | "permissions"
-    | "users";
+    | "users"
+    | "statistics"
+    | "break-requested"
+    | "room-expiring"
+    | "room-closed"
+    | "room-details"
+    | "room-visibility"
+    | "disconnected"
+    | "maintenance"
+    | "error";
   data?: any;
 };
 
 export type PossibleGuess = {
+  id: string;
+  label: string;
+  value: number | null;
+  special: boolean;
   guess: number;
   description: string;
 };
 
+export type EveryoneDone = {
+  missing: string[];
+};
+
+export type RoomDetails = {
+  title?: string;
+  description?: string;
+  team?: string;
+  tags?: string[];
+};
+
+export type Visibility = "public" | "unlisted" | "private";
+
+export type Expiry = {
+  reason: "idle" | "max-age";
+  expiresAt: string;
+};
+
+export type Notice = {
+  message: string;
+};
+
+export type Statistics = {
+  votes: number;
+  ignored: number;
+  skipped: number;
+  average: number | null;
+  median: number | null;
+  min: number | null;
+  max: number | null;
+};
+
 const isProductOwner = isObjectWithKeysMatchingGuard<ProductOwner>({
   name: isNonEmptyString,
   role: isExactString(Role.ProductOwner),
@@ -139,9 +196,19 @@ const isDeveloper = isObjectWithKeysMatchingGuard<Developer>({
   role: isExactString(Role.Developer),
 });
 
+const isPossibleGuess = isObjectWithKeysMatchingGuard<PossibleGuess>({
+  id: isNonEmptyString,
+  label: isString,
+  value: isNullOr(isNumber),
+  special: isBool,
+  description: isNonEmptyString,
+  guess: isNumber,
+});
+
 const isDeveloperDone = isObjectWithKeysMatchingGuard<DeveloperDone>({
   doSkip: isBool,
   guess: isNumber,
+  card: isNullOr(isPossibleGuess),
   name: isString,
   role: isExactString(Role.Developer),
 });
@@ -167,6 +234,15 @@ export const isReceivableWebsocketMessage =
       "issues",
       "permissions",
       "users",
+      "statistics",
+      "break-requested",
+      "room-expiring",
+      "room-closed",
+      "room-details",
+      "room-visibility",
+      "disconnected",
+      "maintenance",
+      "error",
     ]),
     data: isAlways,
   });
@@ -197,10 +273,10 @@ export const isEstimateWebsocketMessage = isObjectWithKeysMatchingGuard<{
 
 export const isYouGuessedWebsocketMessage = isObjectWithKeysMatchingGuard<{
   type: "you-guessed";
-  data: number;
+  data: string;
 }>({
   type: isExactString("you-guessed"),
-  data: isNumber,
+  data: isNonEmptyString,
 });
 
 export const isYouSkippedWebsocketMessage = isObjectWithKeysMatchingGuard<{
@@ -213,10 +289,14 @@ export const isYouSkippedWebsocketMessage = isObjectWithKeysMatchingGuard<{
 
 export const isEveryoneDoneWebsocketMessage = isObjectWithKeysMatchingGuard<{
   type: "everyone-done";
-  data: null;
+  data: EveryoneDone | null;
 }>({
   type: isExactString("everyone-done"),
-  data: isNull,
+  data: isNullOr(
+    isObjectWithKeysMatchingGuard<EveryoneDone>({
+      missing: isListOf(isString),
+    }),
+  ),
 });
 
 export const isRevealWebsocketMessage = isObjectWithKeysMatchingGuard<{
@@ -227,6 +307,30 @@ export const isRevealWebsocketMessage = isObjectWithKeysMatchingGuard<{
   data: isListOf(isDeveloperDone),
 });
 
+export const isStatisticsWebsocketMessage = isObjectWithKeysMatchingGuard<{
+  type: "statistics";
+  data: Statistics;
+}>({
+  type: isExactString("statistics"),
+  data: isObjectWithKeysMatchingGuard<Statistics>({
+    votes: isNumber,
+    ignored: isNumber,
+    skipped: isNumber,
+    average: isNullOr(isNumber),
+    median: isNullOr(isNumber),
+    min: isNullOr(isNumber),
+    max: isNullOr(isNumber),
+  }),
+});
+
+export const isBreakRequestedWebsocketMessage = isObjectWithKeysMatchingGuard<{
+  type: "break-requested";
+  data: string;
+}>({
+  type: isExactString("break-requested"),
+  data: isString,
+});
+
 export const isRoomLockedWebsocketMessage = isObjectWithKeysMatchingGuard<{
   type: "room-locked";
   data: null;
@@ -243,6 +347,67 @@ export const isRoomOpenedWebsocketMessage = isObjectWithKeysMatchingGuard<{
   data: isNull,
 });
 
+export const isRoomExpiringWebsocketMessage = isObjectWithKeysMatchingGuard<{
+  type: "room-expiring";
+  data: Expiry;
+}>({
+  type: isExactString("room-expiring"),
+  data: isObjectWithKeysMatchingGuard<Expiry>({
+    reason: isOneStringOf(["idle", "max-age"]),
+    expiresAt: isNonEmptyString,
+  }),
+});
+
+export const isRoomClosedWebsocketMessage = isObjectWithKeysMatchingGuard<{
+  type: "room-closed";
+  data: string;
+}>({
+  type: isExactString("room-closed"),
+  data: isString,
+});
+
+export const isRoomDetailsWebsocketMessage = isObjectWithKeysMatchingGuard<{
+  type: "room-details";
+  data: RoomDetails;
+}>({
+  type: isExactString("room-details"),
+  data: isObjectWithKeysMatchingGuard<RoomDetails>({}),
+});
+
+export const isRoomVisibilityWebsocketMessage = isObjectWithKeysMatchingGuard<{
+  type: "room-visibility";
+  data: Visibility;
+}>({
+  type: isExactString("room-visibility"),
+  data: isOneStringOf(["public", "unlisted", "private"]),
+});
+
+export const isDisconnectedWebsocketMessage = isObjectWithKeysMatchingGuard<{
+  type: "disconnected";
+  data: string;
+}>({
+  type: isExactString("disconnected"),
+  data: isString,
+});
+
+export const isMaintenanceWebsocketMessage = isObjectWithKeysMatchingGuard<{
+  type: "maintenance";
+  data: Notice;
+}>({
+  type: isExactString("maintenance"),
+  data: isObjectWithKeysMatchingGuard<Notice>({
+    message: isString,
+  }),
+});
+
+export const isErrorWebsocketMessage = isObjectWithKeysMatchingGuard<{
+  type: "error";
+  data: string;
+}>({
+  type: isExactString("error"),
+  data: isString,
+});
+
 export const isNewRoundWebsocketMessage = isObjectWithKeysMatchingGuard<{
   type: "new-round";
   data: null;
@@ -265,16 +430,11 @@ export const isPermissionsWebsocketMessage = isObjectWithKeysMatchingGuard<{
 }>({
   type: isExactString("permissions"),
   data: isObjectWithKeysMatchingGuard<Permissions>({
-    key: isString,
+    ownerToken: isString,
     canLockRoom: isBool,
   }),
 });
 
-const isPossibleGuess = isObjectWithKeysMatchingGuard<PossibleGuess>({
-  description: isNonEmptyString,
-  guess: isNumber,
-});
-
 const isIssue = isObjectWithKeysMatchingGuard<Issue>({
   title: isNonEmptyString,
   guess: isNumber,
@@ -301,7 +461,18 @@ export const isRoomMetadata = isObjectWithKeysMatchingGuard<RoomMetadata>({
 
 export const isConnectionState = isObjectWithKeysMatchingGuard<ConnectionState>({
   canConnect: isBool,
-  reason: isOneStringOf(["round already started", "username already taken", "wrong password", ""]),
+  reason: isOneStringOf([
+    "round already started",
+    "username already taken",
+    "wrong password",
+    "room is private",
+    "invite is invalid",
+    "invite has expired",
+    "invite has been used up",
+    "room requires login",
+    "room is restricted to another email domain",
+    "",
+  ]),
 });
 
 export const isWrongPasswordConnectionStatus = isObjectWithKeysMatchingGuard<ConnectionState>({
@@ -321,3 +492,34 @@ export const isUsernameAlreadyTakenConnectionStatus =
     canConnect: isFalse,
     reason: isExactString("username already taken"),
   });
+
+export const isRoomIsPrivateConnectionStatus = isObjectWithKeysMatchingGuard<ConnectionState>({
+  canConnect: isFalse,
+  reason: isExactString("room is private"),
+});
+
+export const isInviteInvalidConnectionStatus = isObjectWithKeysMatchingGuard<ConnectionState>({
+  canConnect: isFalse,
+  reason: isExactString("invite is invalid"),
+});
+
+export const isInviteExpiredConnectionStatus = isObjectWithKeysMatchingGuard<ConnectionState>({
+  canConnect: isFalse,
+  reason: isExactString("invite has expired"),
+});
+
+export const isInviteUsedUpConnectionStatus = isObjectWithKeysMatchingGuard<ConnectionState>({
+  canConnect: isFalse,
+  reason: isExactString("invite has been used up"),
+});
+
+export const isLoginRequiredConnectionStatus = isObjectWithKeysMatchingGuard<ConnectionState>({
+  canConnect: isFalse,
+  reason: isExactString("room requires login"),
+});
+
+export const isEmailDomainRestrictedConnectionStatus =
+  isObjectWithKeysMatchingGuard<ConnectionState>({
+    canConnect: isFalse,
+    reason: isExactString("room is restricted to another email domain"),
+  });
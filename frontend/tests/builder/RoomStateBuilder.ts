@@ -1,6 +1,7 @@
 import { Role, RoomState, RoundState, UserOverview } from "../../src/types/room";
 import { just, nothing } from "@kaumlaut/pure/maybe";
 import { Permissions } from "../../src/types/room";
+import { card } from "./card";
 
 export class RoomStateBuilder {
   private constructor(private roomState: RoomState) {}
@@ -88,11 +89,11 @@ export class RoomStateBuilder {
         { name: "Product Owner Test", role: Role.ProductOwner },
       ]),
       possibleGuesses: [
-        { guess: 1, description: "Bis zu 4 Std." },
-        { guess: 2, description: "Bis zu 8 Std." },
-        { guess: 3, description: "Bis zu 3 Tagen" },
-        { guess: 4, description: "Bis zu 5 Tagen" },
-        { guess: 5, description: "Mehr als 5 Tage" },
+        card(1, "Bis zu 4 Std."),
+        card(2, "Bis zu 8 Std."),
+        card(3, "Bis zu 3 Tagen"),
+        card(4, "Bis zu 5 Tagen"),
+        card(5, "Mehr als 5 Tage"),
       ],
       role: just(Role.Developer),
       doSkip: false,
@@ -103,8 +104,9 @@ export class RoomStateBuilder {
       roundState: RoundState.Waiting,
       permissions: {
         canLockRoom: false,
-        key: "",
+        ownerToken: "",
       },
+      statistics: nothing(),
     });
   }
 
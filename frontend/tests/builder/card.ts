@@ -0,0 +1,12 @@
+import { PossibleGuess } from "../../src/types/room";
+
+export function card(value: number, description: string = `${value}`): PossibleGuess {
+  return {
+    id: `${value}`,
+    label: description,
+    value,
+    special: false,
+    guess: value,
+    description,
+  };
+}
@@ -3,6 +3,7 @@ import { VIcon } from "vuetify/components";
 import DeveloperCard from "../../src/components/DeveloperCard.vue";
 import { vuetifyMount } from "../vuetifyMount";
 import { Developer, DeveloperDone, Role } from "../../src/types/room";
+import { card } from "../builder/card";
 
 describe("DeveloperCard", () => {
   describe("rendering", () => {
@@ -37,6 +38,7 @@ describe("DeveloperCard", () => {
         ...dev,
         doSkip: false,
         guess: 2,
+        card: card(2, "Up to 8h"),
       });
       expect(wrapper.find(".reveal").exists()).to.be.true;
       expect(wrapper.find(".waiting-for-guess").exists()).to.be.false;
@@ -55,6 +57,7 @@ describe("DeveloperCard", () => {
         ...dev,
         doSkip: true,
         guess: 0,
+        card: null,
       });
       expect(wrapper.find(".reveal").exists()).to.be.true;
       expect(wrapper.find(".waiting-for-guess").exists()).to.be.false;
@@ -3,6 +3,7 @@ import DeveloperRoundView from "../../src/components/DeveloperRoundView.vue";
 import { VIcon } from "vuetify/components";
 import { vuetifyMount } from "../vuetifyMount";
 import { just, nothing } from "@kaumlaut/pure/maybe";
+import { card } from "../builder/card";
 
 describe("DeveloperRoundView", () => {
   describe("rendering", () => {
@@ -14,11 +15,11 @@ describe("DeveloperRoundView", () => {
           didSkip: false,
           hasIssueToGuess: true,
           possibleGuesses: [
-            { guess: 1, description: "Up to 4h" },
-            { guess: 2, description: "Up to 8h" },
-            { guess: 3, description: "Up to 3 days" },
-            { guess: 4, description: "Up to 5 days" },
-            { guess: 5, description: "More than 5 days" },
+            card(1, "Up to 4h"),
+            card(2, "Up to 8h"),
+            card(3, "Up to 3 days"),
+            card(4, "Up to 5 days"),
+            card(5, "More than 5 days"),
           ],
         },
       });
@@ -48,16 +49,16 @@ describe("DeveloperRoundView", () => {
     it("should render with correct guess when developer did guess", async () => {
       const wrapper = vuetifyMount(DeveloperRoundView, {
         props: {
-          guess: just(2),
+          guess: just("2"),
           showAllGuesses: false,
           didSkip: false,
           hasIssueToGuess: true,
           possibleGuesses: [
-            { guess: 1, description: "Up to 4h" },
-            { guess: 2, description: "Up to 8h" },
-            { guess: 3, description: "Up to 3 days" },
-            { guess: 4, description: "Up to 5 days" },
-            { guess: 5, description: "More than 5 days" },
+            card(1, "Up to 4h"),
+            card(2, "Up to 8h"),
+            card(3, "Up to 3 days"),
+            card(4, "Up to 5 days"),
+            card(5, "More than 5 days"),
           ],
         },
       });
@@ -74,11 +75,11 @@ describe("DeveloperRoundView", () => {
           didSkip: true,
           hasIssueToGuess: true,
           possibleGuesses: [
-            { guess: 1, description: "Up to 4h" },
-            { guess: 2, description: "Up to 8h" },
-            { guess: 3, description: "Up to 3 days" },
-            { guess: 4, description: "Up to 5 days" },
-            { guess: 5, description: "More than 5 days" },
+            card(1, "Up to 4h"),
+            card(2, "Up to 8h"),
+            card(3, "Up to 3 days"),
+            card(4, "Up to 5 days"),
+            card(5, "More than 5 days"),
           ],
         },
       });
@@ -99,20 +100,20 @@ describe("DeveloperRoundView", () => {
           didSkip: false,
           hasIssueToGuess: true,
           possibleGuesses: [
-            { guess: 1, description: "Up to 4h" },
-            { guess: 2, description: "Up to 8h" },
-            { guess: 3, description: "Up to 3 days" },
-            { guess: 4, description: "Up to 5 days" },
-            { guess: 5, description: "More than 5 days" },
+            card(1, "Up to 4h"),
+            card(2, "Up to 8h"),
+            card(3, "Up to 3 days"),
+            card(4, "Up to 5 days"),
+            card(5, "More than 5 days"),
           ],
         },
       });
 
       await wrapper.findAll(".card").at(2).trigger("click");
-      expect(wrapper.emitted("guess")).deep.equal([[3]]);
+      expect(wrapper.emitted("guess")).deep.equal([["3"]]);
 
       await wrapper.findAll(".card").at(3).trigger("click");
-      expect(wrapper.emitted("guess")).deep.equal([[3], [4]]);
+      expect(wrapper.emitted("guess")).deep.equal([["3"], ["4"]]);
     });
 
     it("should emit skip on skip card press", async () => {
@@ -123,11 +124,11 @@ describe("DeveloperRoundView", () => {
           didSkip: false,
           hasIssueToGuess: true,
           possibleGuesses: [
-            { guess: 1, description: "Up to 4h" },
-            { guess: 2, description: "Up to 8h" },
-            { guess: 3, description: "Up to 3 days" },
-            { guess: 4, description: "Up to 5 days" },
-            { guess: 5, description: "More than 5 days" },
+            card(1, "Up to 4h"),
+            card(2, "Up to 8h"),
+            card(3, "Up to 3 days"),
+            card(4, "Up to 5 days"),
+            card(5, "More than 5 days"),
           ],
         },
       });
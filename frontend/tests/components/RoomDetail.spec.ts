@@ -8,6 +8,7 @@ import { vuetifyMount } from "../vuetifyMount";
 import { RoundState } from "../../src/types/room";
 import { nothing } from "@kaumlaut/pure/maybe";
 import { RoomStateBuilder } from "../builder/RoomStateBuilder";
+import { card } from "../builder/card";
 
 const ResizeObserverMock = vi.fn(() => ({
   observe: vi.fn(),
@@ -44,11 +45,11 @@ describe("RoomDetail", () => {
       expect(wrapper.findComponent(DeveloperRoundView).props("didSkip")).to.be.false;
       expect(wrapper.findComponent(DeveloperRoundView).props("hasIssueToGuess")).to.be.false;
       expect(wrapper.findComponent(DeveloperRoundView).props("possibleGuesses")).deep.equal([
-        { guess: 1, description: "Bis zu 4 Std." },
-        { guess: 2, description: "Bis zu 8 Std." },
-        { guess: 3, description: "Bis zu 3 Tagen" },
-        { guess: 4, description: "Bis zu 5 Tagen" },
-        { guess: 5, description: "Mehr als 5 Tage" },
+        card(1, "Bis zu 4 Std."),
+        card(2, "Bis zu 8 Std."),
+        card(3, "Bis zu 3 Tagen"),
+        card(4, "Bis zu 5 Tagen"),
+        card(5, "Mehr als 5 Tage"),
       ]);
     });
   });
@@ -71,8 +72,8 @@ describe("RoomDetail", () => {
     it("should emit guess when developer round view emits guess", () => {
       const wrapper = createWrapper();
 
-      wrapper.findComponent(DeveloperRoundView).vm.$emit("guess", 1);
-      expect(wrapper.emitted("guess")).deep.equal([[1]]);
+      wrapper.findComponent(DeveloperRoundView).vm.$emit("guess", "1");
+      expect(wrapper.emitted("guess")).deep.equal([["1"]]);
     });
 
     it("should emit reveal when table overview emits reveal", () => {
@@ -3,6 +3,7 @@ import { VBottomSheet, VCard, VProgressCircular } from "vuetify/components";
 import RoundSummary from "../../src/components/RoundSummary.vue";
 import { vuetifyMount } from "../vuetifyMount";
 import { DeveloperDone, Role } from "../../src/types/room";
+import { card } from "../builder/card";
 
 const ResizeObserverMock = vi.fn(() => ({
   observe: vi.fn(),
@@ -34,9 +35,9 @@ describe("RoundSummary", () => {
 
     it("should render for multiple cards including the coffee", () => {
       const wrapper = createWrapper([
-        { guess: 1, role: Role.Developer, name: "Test Dev 1", doSkip: false },
-        { guess: 2, role: Role.Developer, name: "Test Dev 2", doSkip: false },
-        { guess: 0, role: Role.Developer, name: "Test Dev 2", doSkip: true },
+        { guess: 1, card: card(1), role: Role.Developer, name: "Test Dev 1", doSkip: false },
+        { guess: 2, card: card(2), role: Role.Developer, name: "Test Dev 2", doSkip: false },
+        { guess: 0, card: null, role: Role.Developer, name: "Test Dev 2", doSkip: true },
       ]);
 
       expect(wrapper.findComponent(VCard).exists()).to.be.true;
@@ -51,13 +52,27 @@ describe("RoundSummary", () => {
         33.33333333333333,
       );
     });
+
+    it("should render fractional cards in numeric order", () => {
+      const wrapper = createWrapper([
+        { guess: 13, card: card(13), role: Role.Developer, name: "Test Dev 1", doSkip: false },
+        { guess: 0.5, card: card(0.5), role: Role.Developer, name: "Test Dev 2", doSkip: false },
+        { guess: 2, card: card(2), role: Role.Developer, name: "Test Dev 3", doSkip: false },
+      ]);
+
+      expect(wrapper.getComponent(VCard).findAll(".card").map((it) => it.text())).deep.equal([
+        "0.5",
+        "2",
+        "13",
+      ]);
+    });
   });
 });
 
 function createWrapper(
   developerDone: DeveloperDone[] = [
-    { guess: 2, role: Role.Developer, name: "Test Dev 1", doSkip: false },
-    { guess: 2, role: Role.Developer, name: "Test Dev 2", doSkip: false },
+    { guess: 2, card: card(2), role: Role.Developer, name: "Test Dev 1", doSkip: false },
+    { guess: 2, card: card(2), role: Role.Developer, name: "Test Dev 2", doSkip: false },
   ],
 ) {
   return vuetifyMount(RoundSummary, {
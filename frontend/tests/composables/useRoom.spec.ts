@@ -2,6 +2,7 @@ import { describe, it, expect, vi } from "vitest";
 import { useRoom } from "../../src/composables/useRoom";
 import { just, nothing } from "@kaumlaut/pure/maybe";
 import { Role, RoundState } from "../../src/types/room";
+import { card } from "../builder/card";
 
 const websocketSendSpy = vi.fn();
 const websocketCloseSpy = vi.fn();
@@ -53,9 +54,10 @@ describe("useRoom", () => {
         isConnected: false,
         permissions: {
           canLockRoom: false,
-          key: "",
+          ownerToken: "",
         },
         possibleGuesses: [],
+        statistics: nothing(),
       });
     });
   });
@@ -73,6 +75,32 @@ describe("useRoom", () => {
       expect(composable.roomState.value.role).deep.equal(just(role));
       expect(composable.roomState.value.name).deep.equal(just(name));
     });
+
+    it("should join a locked room with the password", async () => {
+      const composable = useRoom();
+
+      await composable.joinRoom("Tester", Role.Developer, "an-id", "top secret");
+
+      expect(websocketUrl).toContain("/v1/room/an-id/developer?name=Tester&password=top+secret");
+    });
+
+    it("should rejoin with the owner token it received", async () => {
+      const composable = useRoom();
+      await composable.joinRoom("Paula", Role.ProductOwner, "owned-id");
+      await websocketOnMessage({
+        data: JSON.stringify({
+          type: "permissions",
+          data: { canLockRoom: true, ownerToken: "the-token" },
+        }),
+      });
+      composable.leaveRoom();
+
+      await useRoom().joinRoom("Paula", Role.ProductOwner, "owned-id");
+
+      expect(websocketUrl).toContain(
+        "/v1/room/owned-id/product-owner?name=Paula&ownerToken=the-token",
+      );
+    });
   });
 
   describe("send", () => {
@@ -189,6 +217,25 @@ describe("useRoom", () => {
       expect(connectionState.reason).equal("wrong password");
     });
 
+    it.each([
+      "room is private",
+      "invite is invalid",
+      "invite has expired",
+      "invite has been used up",
+      "room requires login",
+      "room is restricted to another email domain",
+    ])("should accept the reason %s", async (reason) => {
+      // @ts-ignore
+      global.fetch = vi.fn(() => ({
+        ok: true,
+        json: () => Promise.resolve({ canConnect: false, reason }),
+      }));
+
+      const connectionState = await useRoom().connectionState("my-id", "Tester", "");
+
+      expect(connectionState.reason).equal(reason);
+    });
+
     it("should throw error if response is not ok", async () => {
       // @ts-ignore
       global.fetch = vi.fn(() => ({
@@ -245,14 +292,32 @@ describe("useRoom", () => {
   });
 
   describe("fetchRoomState", () => {
+    it("should send the owner token", async () => {
+      // @ts-ignore
+      global.fetch = vi.fn(() => ({
+        ok: true,
+        json: () =>
+          Promise.resolve({ issues: [], isLocked: false, inProgress: false, possibleGuesses: [] }),
+      }));
+      sessionStorage.setItem("ownerToken:private-id", "the-token");
+      const composable = useRoom();
+      await composable.joinRoom("Paula", Role.ProductOwner, "private-id");
+
+      await composable.fetchRoomState();
+
+      expect(global.fetch).toHaveBeenNthCalledWith(1, "/v1/room/private-id/state", {
+        headers: { Authorization: "Bearer the-token" },
+      });
+    });
+
     it("should fetch and set state", async () => {
       const issues = [
         { title: "Good issue", guess: -1 },
         { title: "Good issue #2", guess: -1 },
       ];
       const possibleGuesses = [
-        { guess: 1, description: "4h" },
-        { guess: 2, description: "5h" },
+        card(1, "4h"),
+        card(2, "5h"),
       ];
       // @ts-ignore
       global.fetch = vi.fn(() => {
@@ -323,4 +388,85 @@ describe("useRoom", () => {
       ]);
     });
   });
+
+  describe("onWebsocketMessage", () => {
+    async function joinedRoom() {
+      const composable = useRoom();
+      await composable.joinRoom("Tester", Role.Developer, "my-id");
+      return composable;
+    }
+
+    function receive(type: string, data: any) {
+      return websocketOnMessage({ data: JSON.stringify({ type, data }) });
+    }
+
+    it("should warn before the room expires", async () => {
+      const composable = await joinedRoom();
+
+      await receive("room-expiring", { reason: "idle", expiresAt: "2026-01-01T12:30:00Z" });
+
+      expect(composable.roomNotifications.value).toHaveLength(1);
+      expect(composable.roomNotifications.value[0]).toContain("because nobody was active");
+    });
+
+    it("should tell about changed room details", async () => {
+      const composable = await joinedRoom();
+
+      await receive("room-details", { title: "Sprint 42", tags: ["backend"] });
+      await receive("room-details", {});
+
+      expect(composable.roomNotifications.value).deep.equal([
+        'The room is called "Sprint 42" now…',
+        "The room details changed…",
+      ]);
+    });
+
+    it("should tell about a changed visibility", async () => {
+      const composable = await joinedRoom();
+
+      await receive("room-visibility", "private");
+
+      expect(composable.roomNotifications.value).deep.equal(["The room is private now…"]);
+    });
+
+    it("should leave the room when it was closed", async () => {
+      const composable = await joinedRoom();
+
+      await receive("room-closed", "idle");
+
+      expect(composable.roomState.value.id).deep.equal(nothing());
+      expect(composable.roomState.value.isConnected).to.be.false;
+      expect(composable.roomNotifications.value).deep.equal(["The room was closed…"]);
+    });
+
+    it("should leave the room when an operator disconnected the user", async () => {
+      const composable = await joinedRoom();
+
+      await receive("disconnected", "please rejoin later");
+
+      expect(composable.roomState.value.id).deep.equal(nothing());
+      expect(composable.roomState.value.isConnected).to.be.false;
+      expect(composable.roomNotifications.value).deep.equal([
+        "You were disconnected: please rejoin later…",
+      ]);
+    });
+
+    it("should notify about maintenance", async () => {
+      const composable = await joinedRoom();
+
+      await receive("maintenance", { message: "Restarting at 18:00" });
+
+      expect(composable.roomNotifications.value).deep.equal(["Restarting at 18:00…"]);
+    });
+
+    it("should notify about rejected messages", async () => {
+      const composable = await joinedRoom();
+
+      await receive("error", "rate limit exceeded, message was dropped");
+
+      expect(composable.roomNotifications.value).deep.equal([
+        "Something went wrong: rate limit exceeded, message was dropped…",
+      ]);
+    });
+  });
 });
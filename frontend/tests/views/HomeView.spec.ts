@@ -139,6 +139,7 @@ describe("HomeView", () => {
         "Name",
         Role.Developer,
         "room-id",
+        "",
       );
       expect(useRouter().push).toHaveBeenNthCalledWith(1, "/room/room-id");
     });
@@ -192,6 +193,7 @@ describe("HomeView", () => {
         "Tester",
         Role.Developer,
         "room-id",
+        "",
       );
       expect(useRouter().push).toHaveBeenNthCalledWith(1, "/room/room-id");
     });
@@ -244,6 +246,7 @@ describe("HomeView", () => {
         "Tester",
         Role.Developer,
         "first-id",
+        "",
       );
       expect(useRouter().push).toHaveBeenNthCalledWith(1, "/room/first-id");
     });
@@ -25,7 +25,7 @@ const defaultRoomState = RoomStateBuilder.init()
   ])
   .withPermissions({
     canLockRoom: true,
-    key: "abc",
+    ownerToken: "abc",
   });
 
 const ResizeObserverMock = vi.fn(() => ({
@@ -123,11 +123,11 @@ describe("RoomView", () => {
     it("should send correct message on guess", () => {
       const wrapper = createWrapper();
 
-      wrapper.findComponent(RoomDetail).vm.$emit("guess", 2);
+      wrapper.findComponent(RoomDetail).vm.$emit("guess", "2");
       // @ts-ignore
       expect(estimationStore.send).toHaveBeenNthCalledWith(1, {
         type: "guess",
-        data: 2,
+        data: "2",
       });
     });
 
@@ -205,7 +205,7 @@ describe("RoomView", () => {
       // @ts-ignore
       expect(estimationStore.send).toHaveBeenNthCalledWith(1, {
         type: "lock-room",
-        data: { password: "top secret", key: "abc" },
+        data: { password: "top secret" },
       });
     });
 
@@ -223,7 +223,7 @@ describe("RoomView", () => {
       // @ts-ignore
       expect(estimationStore.send).toHaveBeenNthCalledWith(1, {
         type: "open-room",
-        data: { key: "abc" },
+        data: null,
       });
     });
 
@@ -233,7 +233,7 @@ describe("RoomView", () => {
         .withRoomIsLocked(true)
         .withPermissions({
           canLockRoom: true,
-          key: "",
+          ownerToken: "",
         })
         .build();
       Object.defineProperty(global.navigator, "clipboard", {
@@ -327,6 +327,7 @@ describe("RoomView", () => {
         "Name",
         Role.Developer,
         "room-id",
+        "",
       );
     });
 
@@ -466,6 +467,55 @@ describe("RoomView", () => {
       // @ts-ignore
       expect(estimationStore.joinRoom).not.toHaveBeenCalled();
     });
+
+    it.each([
+      ["room is private", "The room is private, ask its owner for an invite"],
+      ["invite is invalid", "The invite is invalid"],
+      ["invite has expired", "The invite has expired"],
+      ["invite has been used up", "The invite has already been used up"],
+      ["room requires login", "The room requires you to log in"],
+      [
+        "room is restricted to another email domain",
+        "The room is restricted to another email domain",
+      ],
+    ])("should not join when the connection state says %s", async (reason, message) => {
+      // @ts-ignore
+      estimationStore.roomState = defaultRoomState.withConnected(false).build();
+      // @ts-ignore
+      estimationStore.roomMetadata = vi.fn(() =>
+        Promise.resolve({
+          exists: true,
+          isLocked: false,
+        }),
+      );
+      // @ts-ignore
+      estimationStore.connectionState = vi.fn(() =>
+        Promise.resolve({
+          canConnect: false,
+          reason,
+        }),
+      );
+      // @ts-ignore
+      estimationStore.joinRoom = vi.fn();
+      (useRoute as Mock).mockReturnValue({
+        params: {
+          id: "room-id",
+        },
+      });
+      const wrapper = createWrapper();
+
+      wrapper.findComponent(RoomForm).vm.$emit("update:name", "Name");
+      wrapper.findComponent(RoomForm).vm.$emit("update:role", Role.Developer);
+      wrapper.findComponent(RoomForm).vm.$emit("submit");
+
+      await nextTick();
+      await nextTick();
+      await nextTick();
+
+      expect(wrapper.findComponent(RoomForm).props("errorMessage")).equal(message);
+      // @ts-ignore
+      expect(estimationStore.joinRoom).not.toHaveBeenCalled();
+    });
   });
 
   it("should return first id in params when array is given", () => {